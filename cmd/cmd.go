@@ -4,10 +4,17 @@ import (
 	"flag"
 	"fmt"
 	"katanacrawlgo/internal/utils"
+	"katanacrawlgo/pkg/challenge"
 	"katanacrawlgo/pkg/crawlergo"
 	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/filter"
 	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/crawlergo/safemode"
+	"katanacrawlgo/pkg/graphql"
+	"katanacrawlgo/pkg/identity"
 	"katanacrawlgo/pkg/katana/types"
+	"katanacrawlgo/pkg/paraminv"
+	"katanacrawlgo/pkg/upload"
 	"log"
 	"math"
 	neturlparse "net/url"
@@ -15,24 +22,40 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ttacon/chalk"
 	"github.com/urfave/cli/v2"
 )
 
 type Result struct {
-	ReqList       []Request `json:"req_list"`
-	AllReqList    []Request `json:"all_req_list"`
-	AllDomainList []string  `json:"all_domain_list"`
-	SubDomainList []string  `json:"sub_domain_list"`
+	ReqList        []Request                `json:"req_list"`
+	AllReqList     []Request                `json:"all_req_list"`
+	AllDomainList  []string                 `json:"all_domain_list"`
+	SubDomainList  []string                 `json:"sub_domain_list"`
+	BlockedActions []safemode.BlockedAction `json:"blocked_actions,omitempty"`
+	Findings       []*model.Finding         `json:"findings,omitempty"`
+	GraphQL        []string                 `json:"graphql_endpoints,omitempty"`
+	OpenAPI        []string                 `json:"openapi_specs,omitempty"`
+	Uploads        []upload.Endpoint        `json:"upload_endpoints,omitempty"`
+	ChallengeHosts []challenge.HostReport   `json:"challenge_hosts,omitempty"`
+	Stats          crawlergo.RunStats       `json:"stats"`
 }
 
 type Request struct {
-	Url     string                 `json:"url"`
-	Method  string                 `json:"method"`
-	Headers map[string]interface{} `json:"headers"`
-	Data    string                 `json:"data"`
-	Source  string                 `json:"source"`
+	Url                string                 `json:"url"`
+	Method             string                 `json:"method"`
+	Headers            map[string]interface{} `json:"headers"`
+	Data               string                 `json:"data"`
+	Source             string                 `json:"source"`
+	ResourceType       string                 `json:"resource_type,omitempty"`
+	Initiator          *model.Initiator       `json:"initiator,omitempty"`
+	EventSequence      []string               `json:"event_sequence,omitempty"`
+	RedirectChain      []model.RedirectHop    `json:"redirect_chain,omitempty"`
+	Frame              string                 `json:"frame,omitempty"`
+	OpenRedirectParams []string               `json:"open_redirect_params,omitempty"`
+	Challenge          *challenge.Result      `json:"challenge,omitempty"`
+	Variant            string                 `json:"variant,omitempty"`
 }
 
 type ProxyTask struct {
@@ -70,12 +93,19 @@ var (
 	pushProxyPoolMax        int
 	pushProxyWG             sync.WaitGroup
 	urlScope                []string
+	jsonResultFile          string
+	paramInventory          *paraminv.Inventory        // 两个引擎共享的参数清单，未开启时为空
+	graphqlEndpoints        = graphql.NewEndpointSet() // katana发现的GraphQL端点，交给crawlergo内省
+	uploadEndpoints         = upload.NewEndpointSet()  // katana发现的文件上传接口，与crawlergo结果合并输出
+	challengeTracker        = challenge.NewTracker()   // 两个引擎共享的挑战页面记录和退避状态
 )
 
 func cmd() {
 	isHeadless := flag.Bool("headless", false, chalk.Green.Color("浏览器是否可见"))
 	chromium := flag.String("chromium", "", chalk.Green.Color("无头浏览器chromium路径配置"))
-	customHeaders := flag.String("headers", "{\"User-Agent\": \""+config.DefaultUA+"\"}", chalk.Green.Color("自定义请求头参数，要以json格式被序列化"))
+	chromiumWS := flag.String("chromiumWS", "", chalk.Green.Color("crawlergo连接的远程Chrome DevTools地址，用,分割多个，如：ws://127.0.0.1:9222,ws://127.0.0.1:9223"))
+	customHeaders := flag.String("headers", "{}", chalk.Green.Color("自定义请求头参数，要以json格式被序列化，User-Agent 默认由身份配置文件提供"))
+	identityProfiles := flag.String("identity", identity.DefaultProfile, chalk.Green.Color("浏览器身份配置文件，用,分割时按目标域名轮换，可选："+strings.Join(identity.Names(), ",")))
 	maxCrawler := flag.Int("maxCrawler", config.MaxCrawlCount, chalk.Green.Color("URL启动的任务最大的爬行个数"))
 	mode := flag.String("mode", "smart", chalk.Green.Color("爬行模式，simple/smart/strict,默认smart"))
 	proxy := flag.String("proxy", "", chalk.Green.Color("请求的代理，针对访问URL在墙外的情况，默认直连为空"))
@@ -85,6 +115,33 @@ func cmd() {
 	resultTxt := flag.String("resultTxtPath", "", chalk.Green.Color("结果文件"))
 	encode := flag.Bool("encodeUrlWithCharset", false, chalk.Green.Color("是否对URL进行编码"))
 	depth := flag.Int("depth", 2, chalk.Green.Color("最大爬行深度，默认是2"))
+	routeDiscovery := flag.Bool("routeDiscovery", true, chalk.Green.Color("是否读取前端框架的路由表发现SPA路由"))
+	outputJson := flag.String("outputJson", "", chalk.Green.Color("crawlergo完整结果的json输出文件，为空则不输出"))
+	harDir := flag.String("harDir", "", chalk.Green.Color("crawlergo浏览器流量的HAR输出目录，为空则不输出"))
+	scrollMaxSteps := flag.Int("scrollMaxSteps", config.ScrollMaxSteps, chalk.Green.Color("页面最大滚动次数，用于触发懒加载，为0则不滚动"))
+	scrollStepSize := flag.Int("scrollStepSize", config.ScrollStepSize, chalk.Green.Color("页面每次滚动的像素"))
+	stateExplore := flag.Bool("stateExplore", false, chalk.Green.Color("是否通过多次点击的交互序列探索页面的DOM状态"))
+	stateMaxActions := flag.Int("stateMaxActions", config.StateMaxActions, chalk.Green.Color("每个URL状态探索的最大交互次数"))
+	safeMode := flag.Bool("safeMode", true, chalk.Green.Color("安全模式，阻止删除、注销等危险的点击、表单提交和请求"))
+	sinkTelemetry := flag.Bool("sinkTelemetry", false, chalk.Green.Color("是否记录JS异常、控制台错误，以及填充值和URL参数到达innerHTML、eval等危险sink的调用"))
+	frameCrawl := flag.Bool("frameCrawl", true, chalk.Green.Color("是否爬取iframe，收集同域frame中的链接并填充表单、触发事件"))
+	safeModeRules := flag.String("safeModeRules", "", chalk.Green.Color("安全模式自定义规则的YAML文件"))
+	formRules := flag.String("formRules", "", chalk.Green.Color("自定义表单填充规则的YAML文件，katana和crawlergo共用，规则追加在内置规则之前"))
+	formVariants := flag.Int("formVariants", 0, chalk.Green.Color("每个表单按不同下拉框选项和单选组取值提交的最大次数，katana和crawlergo共用，0则只提交一次"))
+	paramDir := flag.String("paramDir", "", chalk.Green.Color("参数清单输出目录，按host输出参数JSON并生成params.txt字典，为空则不输出"))
+	graphqlIntrospect := flag.Bool("graphqlIntrospect", false, chalk.Green.Color("是否对发现的GraphQL端点发送内省查询，为每个query字段生成一个请求"))
+	graphqlMutations := flag.Bool("graphqlMutations", false, chalk.Green.Color("内省时是否同时生成mutation请求，mutation可能修改数据，需要显式开启"))
+	openAPI := flag.Bool("openapi", true, chalk.Green.Color("是否探测swagger.json、/v3/api-docs等常见位置及Swagger UI引用的OpenAPI文档，并将其中的接口生成请求"))
+	openAPIPost := flag.Bool("openapiPost", false, chalk.Green.Color("是否发送OpenAPI文档中的POST接口，katana和crawlergo共用，POST可能创建或修改数据，需要显式开启，否则只发送GET接口"))
+	challengeMaxDelay := flag.Int("challengeMaxDelay", int(challenge.DefaultMaxDelay/time.Second), chalk.Green.Color("域名返回验证码或JS挑战页面后的最大退避秒数，连续返回时退避时间翻倍，0则不退避"))
+	challengeMaxConsecutive := flag.Int("challengeMaxConsecutive", challenge.DefaultMaxConsecutive, chalk.Green.Color("域名连续返回验证码或JS挑战页面多少次后放弃该域名，与是否退避无关，0则不放弃"))
+	filterStore := flag.String("filterStore", filter.StoreMemory, chalk.Green.Color("crawlergo去重集合的存储方式，memory内存/disk磁盘/bloom布隆过滤器，大型站点使用disk或bloom限制内存占用"))
+	filterFalsePositive := flag.Float64("filterFalsePositive", filter.DefaultBloomFalsePositive, chalk.Green.Color("bloom存储的误判率，误判的请求会被当作重复请求过滤"))
+	filterRules := flag.String("filterRules", "", chalk.Green.Color("crawlergo过滤规则的YAML文件，按URL正则、请求方法、参数名或DSL表达式过滤或放行请求"))
+	filterAudit := flag.String("filterAudit", "", chalk.Green.Color("crawlergo过滤判定的JSONL审计日志文件，记录每个请求被过滤的原因、标记模板和保留下来的请求"))
+	filterProfile := flag.String("filterProfile", filter.ProfileDefault, chalk.Green.Color("crawlergo智能去重的内置配置：default、aggressive（伪静态多的CMS）、gentle（API）"))
+	filterConfig := flag.String("filterConfig", "", chalk.Green.Color("crawlergo智能去重的YAML配置文件，覆盖内置配置的阈值及不使用的标记（disabled_marks）"))
+	harMode := flag.String("harMode", config.HarModeTab, chalk.Green.Color("HAR输出模式，tab每个标签页一个文件/target每个目标一个文件"))
 	flag.Parse()
 	startCheck(*resultTxt)
 	options := &types.Options{}
@@ -92,6 +149,10 @@ func cmd() {
 		log.Println(chalk.Red.Color("URL文件和URL必须有一个！！！"))
 		os.Exit(0)
 	}
+	if *scrollMaxSteps < 0 || *scrollStepSize <= 0 {
+		log.Println(chalk.Red.Color("页面最大滚动次数不能小于0，滚动步长必须大于0"))
+		os.Exit(-1)
+	}
 	var urls []string
 	if *urlTxt != "" {
 		urlList := utils.GetUrlListFromTxt(*urlTxt)
@@ -114,11 +175,22 @@ func cmd() {
 		options.AutomaticFormFill = true
 	}
 	options.KnownFiles = ""
+	if *openAPI {
+		options.KnownFiles = "openapi"
+	}
+	options.OpenAPI = *openAPI
+	options.OpenAPIPost = *openAPIPost
+	options.FormConfig = *formRules
+	options.FormVariants = *formVariants
 	options.BodyReadSize = math.MaxInt
 	options.Timeout = 15
 	options.Retries = 1
 	options.AutomaticFormFill = true
 	options.Proxy = *proxy
+	options.IdentityProfiles = strings.Split(*identityProfiles, ",")
+	challengeTracker.MaxDelay = time.Duration(*challengeMaxDelay) * time.Second
+	challengeTracker.MaxConsecutive = *challengeMaxConsecutive
+	options.ChallengeTracker = challengeTracker
 
 	// 请求头要单独将json处理为键值对,目前不设置
 	options.Strategy = "depth-first"
@@ -140,6 +212,11 @@ func cmd() {
 	options.Parallelism = 5
 	options.RateLimit = 20
 	options.ExtensionFilter = []string{"css", "jpg", "jpeg", "png", "ico", "gif", "webp", "mp3", "mp4", "ttf", "tif", "tiff", "woff", "woff2", "'+", "+'", "/+"}
+	if *paramDir != "" {
+		paramInventory = paraminv.New()
+		options.FormExtraction = true
+	}
+	options.OnResult = handleKatanaResult
 	katanaRun(options)
 
 	// 执行crawlergo之前将结果文件读取
@@ -153,9 +230,38 @@ func cmd() {
 	ignoreList := make([]string, 0)
 	taskConfig.NoHeadless = *isHeadless
 	taskConfig.ChromiumPath = *chromium
+	if *chromiumWS != "" {
+		taskConfig.ChromiumWSUrls = strings.Split(*chromiumWS, ",")
+	}
 	taskConfig.Proxy = *proxy
 	taskConfig.EncodeURLWithCharset = *encode
+	taskConfig.RouteDiscovery = *routeDiscovery
+	taskConfig.HarDir = *harDir
+	taskConfig.HarMode = *harMode
+	taskConfig.ScrollMaxSteps = *scrollMaxSteps
+	taskConfig.ScrollStepSize = *scrollStepSize
+	taskConfig.StateExplore = *stateExplore
+	taskConfig.StateMaxActions = *stateMaxActions
+	taskConfig.SafeMode = *safeMode
+	taskConfig.SafeModeRules = *safeModeRules
+	taskConfig.FormRulesFile = *formRules
+	taskConfig.FormVariants = *formVariants
+	taskConfig.FrameCrawl = *frameCrawl
+	taskConfig.SinkTelemetry = *sinkTelemetry
+	taskConfig.GraphQLIntrospect = *graphqlIntrospect
+	taskConfig.GraphQLMutations = *graphqlMutations
+	taskConfig.GraphQLEndpoints = graphqlEndpoints.List()
+	taskConfig.OpenAPIDiscovery = *openAPI
+	taskConfig.OpenAPIPost = *openAPIPost
+	taskConfig.ChallengeTracker = challengeTracker
+	taskConfig.IdentityProfiles = strings.Split(*identityProfiles, ",")
 	taskConfig.FilterMode = *mode
+	taskConfig.FilterStore = *filterStore
+	taskConfig.FilterFalsePositive = *filterFalsePositive
+	taskConfig.FilterRulesFile = *filterRules
+	taskConfig.FilterAuditFile = *filterAudit
+	taskConfig.FilterProfile = *filterProfile
+	taskConfig.FilterConfigFile = *filterConfig
 	taskConfig.MaxCrawlCount = *maxCrawler
 	taskConfig.ExtraHeadersString = *customHeaders
 	taskConfig.MaxTabsCount = config.MaxTabsCount
@@ -168,11 +274,18 @@ func cmd() {
 	taskConfig.MaxRunTime = config.MaxRunTime
 	taskConfig.CustomFormValues = map[string]string{}
 	taskConfig.ResultFile = fmt.Sprintf("crawlergo-%s.txt", *resultTxt)
+	jsonResultFile = *outputJson
 	if *blackKey != "" {
 		ignoreList = strings.Split(*blackKey, ",")
 	}
 	taskConfig.IgnoreKeywords = ignoreList
 	crawlergoRun()
+	reportChallengeHosts()
+	if paramInventory != nil {
+		if err := paramInventory.WriteFiles(*paramDir); err != nil {
+			log.Println(chalk.Red.Color("error: 参数清单写入失败, " + err.Error()))
+		}
+	}
 
 	//全部程序执行完之后将三个文件进行合并，这里暂时只有两个
 	finalResult := make([]string, 0)
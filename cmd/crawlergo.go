@@ -10,11 +10,14 @@ import (
 	"katanacrawlgo/pkg/crawlergo/model"
 	"katanacrawlgo/pkg/crawlergo/tools"
 	"katanacrawlgo/pkg/crawlergo/tools/requests"
+	"katanacrawlgo/pkg/upload"
 	"log"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/panjf2000/ants/v2"
 	"github.com/ttacon/chalk"
@@ -68,7 +71,9 @@ func crawlergoRun() {
 	// 检查自定义的表单参数配置
 	taskConfig.CustomFormValues, err = parseCustomFormValues(customFormTypeValues.Value())
 	if err != nil {
-		log.Println(chalk.Red.Color("error: 自定义键值数据解析出错1"))
+		log.Println(chalk.Red.Color("error: 自定义键值数据解析出错1, " + err.Error()))
+		log.Println(chalk.Red.Color("可用的表单类型: " + strings.Join(config.AllowedFormName, ",")))
+		os.Exit(-1)
 	}
 	taskConfig.CustomFormKeywordValues, err = keywordStringToMap(customFormKeywordValues.Value())
 	if err != nil {
@@ -96,7 +101,10 @@ func crawlergoRun() {
 	}
 	go handleExit(task)
 	task.Run()
+	// 浏览器已关闭，删除上传用的样例文件
+	_ = upload.Cleanup()
 	result := task.Result
+	reportRunStats(result.Stats)
 
 	// 内置请求代理
 	if pushAddress != "" {
@@ -105,7 +113,26 @@ func crawlergoRun() {
 
 	// 输出结果
 	outputResult(result, taskConfig.ResultFile)
+	if jsonResultFile != "" {
+		outputJsonResult(result, jsonResultFile)
+	}
+	if paramInventory != nil {
+		collectCrawlergoParams(result.AllReqList)
+	}
+}
 
+/*
+*
+将crawlergo请求中的参数加入参数清单
+*/
+func collectCrawlergoParams(reqList []*model.Request) {
+	for _, req := range reqList {
+		source := "crawlergo"
+		if req.Source != "" {
+			source += ":" + req.Source
+		}
+		paramInventory.AddRequest(source, req.Method, req.URL.String(), tools.ConvertHeaders(req.Headers), req.PostData)
+	}
 }
 
 func getOption() model.Options {
@@ -131,6 +158,9 @@ func parseCustomFormValues(customData []string) (map[string]string, error) {
 			return nil, errors.New("error: invalid form item: " + item)
 		}
 		key := keyValue[0]
+		if alias, ok := config.FormNameAliases[key]; ok {
+			key = alias
+		}
 		if !tools.StringSliceContain(config.AllowedFormName, key) {
 			return nil, errors.New("error: not allowed form key: " + key)
 		}
@@ -161,6 +191,103 @@ func outputResult(result *crawlergo.Result, resultFile string) {
 	}
 }
 
+/*
+*
+以json格式输出完整结果
+*/
+func outputJsonResult(result *crawlergo.Result, jsonFile string) {
+	jsonResult := Result{
+		ReqList:        convertRequests(result.ReqList),
+		AllReqList:     convertRequests(result.AllReqList),
+		AllDomainList:  result.AllDomainList,
+		SubDomainList:  result.SubDomainList,
+		BlockedActions: result.BlockedActions,
+		Findings:       result.Findings,
+		GraphQL:        result.GraphQLEndpoints,
+		OpenAPI:        result.OpenAPISpecs,
+		Uploads:        mergeUploadEndpoints(result.UploadEndpoints),
+		ChallengeHosts: result.ChallengeHosts,
+		Stats:          result.Stats,
+	}
+	data, err := json.MarshalIndent(jsonResult, "", "  ")
+	if err != nil {
+		log.Println(chalk.Red.Color("error: 结果序列化失败, " + err.Error()))
+		return
+	}
+	if err := os.WriteFile(jsonFile, data, 0644); err != nil {
+		log.Println(chalk.Red.Color("error: 结果写入" + jsonFile + "失败, " + err.Error()))
+	}
+}
+
+/*
+*
+合并crawlergo与katana发现的文件上传接口
+*/
+func mergeUploadEndpoints(endpoints []upload.Endpoint) []upload.Endpoint {
+	for i := range endpoints {
+		uploadEndpoints.Add(&endpoints[i])
+	}
+	return uploadEndpoints.List()
+}
+
+/*
+*
+输出返回过验证码或JS挑战页面的域名
+*/
+func reportChallengeHosts() {
+	for _, report := range challengeTracker.Report() {
+		message := fmt.Sprintf("挑战页面: %s, 提供方: %s, 次数: %d, 首次: %s", report.Host, strings.Join(report.Providers, ","), report.Challenges, report.FirstURL)
+		if report.GaveUp {
+			message += ", 已放弃爬取"
+		}
+		log.Println(chalk.Yellow.Color(message))
+	}
+}
+
+/*
+*
+输出去重状态和内存占用
+*/
+func reportRunStats(stats crawlergo.RunStats) {
+	log.Println(chalk.Green.Color(fmt.Sprintf("运行统计: 耗时 %s, 请求 %d, 去重存储 %s, 去重集合 %d/%d, 统计键 %d, 去重内存约 %d KB, 堆内存 %d MB",
+		stats.Duration.Round(time.Second), stats.AllRequests, stats.FilterStore, stats.Filter.UniqueIds, stats.Filter.MarkedIds,
+		stats.Filter.CounterKeys, stats.Filter.MemoryBytes/1024, stats.HeapBytes/1024/1024)))
+	reasons := make([]string, 0, len(stats.Decisions))
+	for reason := range stats.Decisions {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	var counts []string
+	for _, reason := range reasons {
+		counts = append(counts, fmt.Sprintf("%s %d", reason, stats.Decisions[reason]))
+	}
+	if len(counts) > 0 {
+		log.Println(chalk.Green.Color("过滤判定: " + strings.Join(counts, ", ")))
+	}
+}
+
+func convertRequests(reqList []*model.Request) []Request {
+	requests := make([]Request, 0, len(reqList))
+	for _, req := range reqList {
+		requests = append(requests, Request{
+			Url:                req.URL.String(),
+			Method:             req.Method,
+			Headers:            req.Headers,
+			Data:               req.PostData,
+			Source:             req.Source,
+			ResourceType:       req.ResourceType,
+			Initiator:          req.Initiator,
+			EventSequence:      req.EventSequence,
+			RedirectChain:      req.RedirectChain,
+			Frame:              req.Frame,
+			OpenRedirectParams: req.OpenRedirectParams(),
+			Challenge:          req.Challenge,
+			Variant:            req.Variant,
+		})
+	}
+	return requests
+}
+
 /*
 *
 原生被动代理推送支持
@@ -199,6 +326,7 @@ func handleExit(t *crawlergo.CrawlerTask) {
 	<-signalChan
 	t.Pool.Tune(1)
 	t.Pool.Release()
-	t.Browser.Close()
+	t.CloseBrowser()
+	_ = upload.Cleanup()
 	os.Exit(-1)
 }
@@ -2,7 +2,11 @@ package main
 
 import (
 	"katanacrawlgo/internal/runner"
+	"katanacrawlgo/pkg/graphql"
+	"katanacrawlgo/pkg/katana/navigation"
+	"katanacrawlgo/pkg/katana/output"
 	"katanacrawlgo/pkg/katana/types"
+	"katanacrawlgo/pkg/upload"
 	"log"
 	"net/url"
 	"os"
@@ -37,6 +41,108 @@ func katanaRun(options *types.Options) {
 	}
 }
 
+/*
+*
+katana每个结果的回调
+*/
+func handleKatanaResult(result output.Result) {
+	collectKatanaGraphQL(result)
+	collectKatanaUploads(result)
+	if paramInventory != nil {
+		collectKatanaParams(result)
+	}
+}
+
+/*
+*
+识别katana结果中的GraphQL端点：请求路径、请求体，以及JS响应中的GraphQL客户端配置
+*/
+func collectKatanaGraphQL(result output.Result) {
+	req := result.Request
+	if req == nil {
+		return
+	}
+	requests := []navigation.Request{*req}
+	if result.Response != nil {
+		requests = append(requests, result.Response.XhrRequests...)
+	}
+	for _, item := range requests {
+		parsed, err := url.Parse(item.URL)
+		if err != nil {
+			continue
+		}
+		if graphql.IsEndpointPath(parsed.Path) || graphql.IsRequest(item.Method, item.URL, headerValue(item.Headers, "Content-Type"), item.Body) {
+			graphqlEndpoints.Add(item.URL)
+		}
+	}
+
+	if result.Response == nil || result.Response.Body == "" {
+		return
+	}
+	base, err := url.Parse(req.URL)
+	if err != nil {
+		return
+	}
+	contentType := headerValue(result.Response.Headers, "Content-Type")
+	if !strings.HasSuffix(base.Path, ".js") && !strings.Contains(contentType, "javascript") {
+		return
+	}
+	for _, endpoint := range graphql.DetectInJS(result.Response.Body) {
+		if resolved, err := base.Parse(endpoint); err == nil {
+			graphqlEndpoints.Add(resolved.String())
+		}
+	}
+}
+
+/*
+*
+记录katana结果中以multipart表单提交的文件上传接口
+*/
+func collectKatanaUploads(result output.Result) {
+	if result.Request == nil {
+		return
+	}
+	requests := []navigation.Request{*result.Request}
+	if result.Response != nil {
+		requests = append(requests, result.Response.XhrRequests...)
+	}
+	for _, item := range requests {
+		uploadEndpoints.Add(upload.Detect(item.Method, item.URL, headerValue(item.Headers, "Content-Type"), item.Body))
+	}
+}
+
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+/*
+*
+将katana结果中的请求、表单字段和XHR请求加入参数清单
+*/
+func collectKatanaParams(result output.Result) {
+	if req := result.Request; req != nil {
+		source := "katana"
+		if req.Source != "" {
+			source += ":" + req.Source
+		}
+		paramInventory.AddRequest(source, req.Method, req.URL, req.Headers, req.Body)
+	}
+	if result.Response == nil {
+		return
+	}
+	for _, form := range result.Response.Forms {
+		paramInventory.AddForm("katana:form", form.Method, form.Action, form.Parameters)
+	}
+	for _, xhr := range result.Response.XhrRequests {
+		paramInventory.AddRequest("katana:xhr", xhr.Method, xhr.URL, xhr.Headers, xhr.Body)
+	}
+}
+
 func parseUrl(_url string) string {
 	u, err := url.Parse(_url)
 	if err != nil {
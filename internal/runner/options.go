@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 
+	"katanacrawlgo/pkg/formfill"
 	"katanacrawlgo/pkg/katana/types"
 	"katanacrawlgo/pkg/katana/utils"
 
@@ -14,7 +15,6 @@ import (
 	"github.com/projectdiscovery/gologger/formatter"
 	errorutil "github.com/projectdiscovery/utils/errors"
 	fileutil "github.com/projectdiscovery/utils/file"
-	"gopkg.in/yaml.v3"
 )
 
 // validateOptions validates the provided options for crawler
@@ -67,19 +67,36 @@ func validateOptions(options *types.Options) error {
 	return nil
 }
 
-// readCustomFormConfig reads custom form fill config
+// exampleFormConfig is written as the default form config, it only holds
+// the format as custom rules are added to the built-in rules
+const exampleFormConfig = `# Custom form fill rules, matched before the built-in rules with the same priority.
+# Set replace_defaults to use only these rules.
+#
+# rules:
+#   - name: coupon
+#     priority: 100
+#     types: [text]
+#     autocomplete: []
+#     match: 'coupon|promo'
+#     value: FREE2024
+#   - name: contact
+#     priority: 100
+#     match: 'contact.?mail'
+#     generator: email
+#     args: {domain: example.org}
+rules: []
+`
+
+// readCustomFormConfig reads custom form fill rules
 func readCustomFormConfig(formConfig string) error {
-	file, err := os.Open(formConfig)
+	rules, err := formfill.Load(formConfig)
 	if err != nil {
 		return errorutil.NewWithErr(err).Msgf("could not read form config")
 	}
-	defer file.Close()
-
-	var data utils.FormFillData
-	if err := yaml.NewDecoder(file).Decode(&data); err != nil {
-		return errorutil.NewWithErr(err).Msgf("could not decode form config")
+	if rules.Legacy() {
+		gologger.Warning().Msgf("Form config %s uses the old format, its values were migrated to form fill rules", formConfig)
 	}
-	utils.FormData = data
+	utils.FormRules = rules
 	return nil
 }
 
@@ -128,12 +145,5 @@ func initExampleFormFillConfig() error {
 	if err := os.MkdirAll(filepath.Dir(defaultConfig), 0775); err != nil {
 		return err
 	}
-	exampleConfig, err := os.Create(defaultConfig)
-	if err != nil {
-		return errorutil.NewWithErr(err).Msgf("could not get home directory")
-	}
-	defer exampleConfig.Close()
-
-	err = yaml.NewEncoder(exampleConfig).Encode(utils.DefaultFormFillData)
-	return err
+	return os.WriteFile(defaultConfig, []byte(exampleFormConfig), 0644)
 }
\ No newline at end of file
@@ -0,0 +1,107 @@
+package challenge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		status   int
+		headers  map[string]string
+		body     string
+		provider string
+		kind     string
+	}{
+		{"cloudflare header", 403, map[string]string{"Cf-Mitigated": "challenge", "Server": "cloudflare"}, "<html></html>", "cloudflare", KindInterstitial},
+		{"cloudflare page", 503, map[string]string{"Server": "cloudflare"}, `<html><head><title>Just a moment...</title></head><body><script>window._cf_chl_opt={cType:'managed'};</script></body></html>`, "cloudflare", KindInterstitial},
+		{"akamai", 403, map[string]string{"Server": "AkamaiGHost"}, "<HTML><HEAD>\n<TITLE>Access Denied</TITLE>\n</HEAD><BODY>\n<H1>Access Denied</H1>\nReference&#32;&#35;18.aa\n</BODY></HTML>", "akamai", KindInterstitial},
+		{"datadome", 403, map[string]string{"X-DataDome": "protected"}, `<html><script src="https://ct.captcha-delivery.com/c.js"></script></html>`, "datadome", KindInterstitial},
+		{"aws waf", 405, map[string]string{"x-amzn-waf-action": "captcha"}, "", "aws-waf", KindInterstitial},
+		{"aws waf challenge script", 202, nil, `<html><script src="https://abc.token.awswaf.com/abc/def/challenge.js"></script></html>`, "aws-waf", KindInterstitial},
+		{"recaptcha on verification page", 200, nil, `<html><head><title>Security check</title><script src="https://www.google.com/recaptcha/api.js"></script></head><body><div class="g-recaptcha" data-sitekey="x"></div></body></html>`, "recaptcha", KindCaptcha},
+		{"hcaptcha with blocking status", 403, nil, `<html><script src="https://hcaptcha.com/1/api.js" async></script><div class="h-captcha"></div></html>`, "hcaptcha", KindCaptcha},
+		{"geetest slider", 200, nil, `<html><head><title>滑动验证</title></head><script>initGeetest({gt: "x"}, cb)</script></html>`, "geetest", KindCaptcha},
+		{"rate limit", 429, map[string]string{"Retry-After": "30"}, "Too Many Requests", "generic", KindRateLimit},
+	} {
+		result := Detect(test.status, test.headers, test.body)
+		require.NotNil(t, result, test.name)
+		assert.Equal(t, test.provider, result.Provider, test.name)
+		assert.Equal(t, test.kind, result.Kind, test.name)
+		assert.NotEmpty(t, result.Evidence, test.name)
+	}
+
+	// ordinary pages behind a protection or with a captcha on a form
+	assert.Nil(t, Detect(200, map[string]string{"Server": "cloudflare"}, `<html><head><title>Shop</title><script src="/cdn-cgi/challenge-platform/scripts/jsd/main.js"></script></head></html>`))
+	assert.Nil(t, Detect(200, nil, `<html><head><title>Login</title><script src="https://www.google.com/recaptcha/api.js"></script></head><form><div class="g-recaptcha"></div></form></html>`))
+	assert.Nil(t, Detect(403, map[string]string{"Server": "AkamaiGHost"}, "<html><h1>Forbidden</h1></html>"))
+	assert.Nil(t, Detect(200, nil, `<html><head><title>Shop</title><script src="https://abc.token.awswaf.com/abc/def/challenge.js" defer></script></head></html>`), "AWS WAF SDK on an ordinary page")
+	assert.Nil(t, Detect(404, nil, "not found"))
+}
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+	var slept []time.Duration
+	tracker.sleep = func(delay time.Duration) {
+		slept = append(slept, delay)
+	}
+	start := time.Now()
+	tracker.clock = func() time.Time {
+		return start
+	}
+	cloudflare := &Result{Provider: "cloudflare", Kind: KindInterstitial}
+
+	assert.True(t, tracker.Wait("a.com"))
+	assert.Empty(t, slept)
+
+	tracker.Record("a.com", "https://a.com/", cloudflare)
+	tracker.Record("a.com", "https://a.com/x", &Result{Provider: "recaptcha", Kind: KindCaptcha})
+	assert.Equal(t, 2*DefaultBaseDelay, tracker.Delay("a.com"))
+	assert.True(t, tracker.Wait("a.com"))
+	assert.Equal(t, []time.Duration{2 * DefaultBaseDelay}, slept)
+	// requests reserved while backing off are spaced by the delay
+	assert.True(t, tracker.Wait("a.com"))
+	assert.Equal(t, []time.Duration{2 * DefaultBaseDelay, 4 * DefaultBaseDelay}, slept)
+	assert.Zero(t, tracker.Delay("b.com"))
+
+	tracker.Record("a.com", "https://a.com/y", nil)
+	assert.Zero(t, tracker.Delay("a.com"), "an ordinary page resets the back-off")
+
+	for i := 0; i < DefaultMaxConsecutive; i++ {
+		tracker.Record("a.com", "https://a.com/z", cloudflare)
+	}
+	assert.Equal(t, DefaultMaxDelay, tracker.Delay("a.com"))
+	assert.True(t, tracker.GaveUp("a.com"))
+	assert.False(t, tracker.Wait("a.com"))
+
+	reports := tracker.Report()
+	require.Len(t, reports, 1)
+	assert.Equal(t, "a.com", reports[0].Host)
+	assert.Equal(t, []string{"cloudflare", "recaptcha"}, reports[0].Providers)
+	assert.Equal(t, 2+DefaultMaxConsecutive, reports[0].Challenges)
+	assert.Equal(t, "https://a.com/", reports[0].FirstURL)
+	assert.True(t, reports[0].GaveUp)
+
+	noBackOff := NewTracker()
+	noBackOff.MaxDelay = 0
+	for i := 0; i < DefaultMaxConsecutive; i++ {
+		noBackOff.Record("a.com", "https://a.com/", cloudflare)
+	}
+	assert.Zero(t, noBackOff.Delay("a.com"))
+	assert.False(t, noBackOff.Wait("a.com"), "giving up does not depend on back-off")
+
+	disabled := NewTracker()
+	disabled.MaxDelay = 0
+	disabled.MaxConsecutive = 0
+	for i := 0; i < DefaultMaxConsecutive; i++ {
+		disabled.Record("a.com", "https://a.com/", cloudflare)
+	}
+	wait, ok := disabled.Reserve("a.com")
+	assert.Zero(t, wait)
+	assert.True(t, ok, "hosts are never given up when MaxConsecutive is 0")
+	assert.Len(t, disabled.Report(), 1)
+}
@@ -0,0 +1,178 @@
+// Package challenge recognises CAPTCHA and JS challenge pages placed in front
+// of content by bot protection (Cloudflare, Akamai, DataDome, reCAPTCHA,
+// hCaptcha, slider captchas, ...) and tracks the hosts serving them so the
+// crawlers can back off. It is used by both crawlergo and katana.
+package challenge
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kinds of challenge pages
+const (
+	KindInterstitial = "interstitial" // the protection replaced the page with a check
+	KindCaptcha      = "captcha"      // the page only asks to solve a captcha
+	KindRateLimit    = "rate_limit"   // the server refuses further requests for now
+)
+
+// Result describes a detected challenge page
+type Result struct {
+	Provider string   `json:"provider"`
+	Kind     string   `json:"kind"`
+	Evidence []string `json:"evidence"`
+}
+
+// where a marker is looked for
+const (
+	inHeader = iota
+	inScript
+	inBody
+	inTitle
+)
+
+type marker struct {
+	provider string
+	kind     string
+	where    int
+	header   string // header name for inHeader markers
+	pattern  *regexp.Regexp
+	// status codes the response must have for the marker to count, any if empty
+	status []int
+}
+
+var blockingStatus = []int{403, 429, 503}
+
+// AWS WAF answers challenges with 202 and captchas with 405
+var awsWAFStatus = append([]int{202, 405}, blockingStatus...)
+
+// Interstitial markers identify a challenge on their own or together with a
+// blocking status. Captcha widgets also appear on ordinary login and contact
+// forms, so they only count on pages that look like a verification page.
+var markers = []marker{
+	{provider: "cloudflare", kind: KindInterstitial, where: inHeader, header: "cf-mitigated", pattern: regexp.MustCompile(`(?i)challenge`)},
+	{provider: "cloudflare", kind: KindInterstitial, where: inBody, pattern: regexp.MustCompile(`/cdn-cgi/challenge-platform/h/[a-z]/orchestrate/|window\._cf_chl_opt|cf-browser-verification|cf_chl_prog`)},
+	{provider: "cloudflare", kind: KindInterstitial, where: inTitle, pattern: regexp.MustCompile(`(?i)^(just a moment\.\.\.|attention required! \| cloudflare)$`)},
+	{provider: "akamai", kind: KindInterstitial, where: inBody, pattern: regexp.MustCompile(`(?i)/_sec/cp_challenge/|bm-verify=|sec-if-cpt-container`)},
+	{provider: "akamai", kind: KindInterstitial, where: inBody, pattern: regexp.MustCompile(`(?s)<H1>Access Denied</H1>.*Reference&#32;(#|&#35;)`), status: []int{403}},
+	{provider: "datadome", kind: KindInterstitial, where: inScript, pattern: regexp.MustCompile(`(?i)captcha-delivery\.com`)},
+	{provider: "datadome", kind: KindInterstitial, where: inBody, pattern: regexp.MustCompile(`(?i)geo\.captcha-delivery\.com|ct\.captcha-delivery\.com`)},
+	{provider: "datadome", kind: KindInterstitial, where: inHeader, header: "x-datadome", pattern: regexp.MustCompile(`.`), status: blockingStatus},
+	{provider: "perimeterx", kind: KindInterstitial, where: inBody, pattern: regexp.MustCompile(`(?i)id=["']px-captcha["']|_pxCaptcha|window\._pxAppId`), status: blockingStatus},
+	{provider: "aws-waf", kind: KindInterstitial, where: inHeader, header: "x-amzn-waf-action", pattern: regexp.MustCompile(`(?i)captcha|challenge`)},
+	{provider: "aws-waf", kind: KindInterstitial, where: inScript, pattern: regexp.MustCompile(`(?i)\.awswaf\.com/.*/(challenge|captcha)\.js`), status: awsWAFStatus},
+	{provider: "imperva", kind: KindInterstitial, where: inBody, pattern: regexp.MustCompile(`_Incapsula_Resource`), status: blockingStatus},
+	{provider: "sucuri", kind: KindInterstitial, where: inHeader, header: "x-sucuri-block", pattern: regexp.MustCompile(`.`)},
+
+	{provider: "recaptcha", kind: KindCaptcha, where: inScript, pattern: regexp.MustCompile(`(?i)(google\.com|recaptcha\.net)/recaptcha/`)},
+	{provider: "recaptcha", kind: KindCaptcha, where: inBody, pattern: regexp.MustCompile(`class=["'][^"']*\bg-recaptcha\b`)},
+	{provider: "hcaptcha", kind: KindCaptcha, where: inScript, pattern: regexp.MustCompile(`(?i)hcaptcha\.com/1/api\.js`)},
+	{provider: "hcaptcha", kind: KindCaptcha, where: inBody, pattern: regexp.MustCompile(`class=["'][^"']*\bh-captcha\b`)},
+	{provider: "turnstile", kind: KindCaptcha, where: inScript, pattern: regexp.MustCompile(`(?i)challenges\.cloudflare\.com/turnstile/`)},
+	{provider: "geetest", kind: KindCaptcha, where: inScript, pattern: regexp.MustCompile(`(?i)geetest\.com/|/gt\.js`)},
+	{provider: "geetest", kind: KindCaptcha, where: inBody, pattern: regexp.MustCompile(`initGeetest4?\s*\(`)},
+	{provider: "aliyun", kind: KindCaptcha, where: inBody, pattern: regexp.MustCompile(`nc_1_n1z|AWSC\.use\(\s*["']nc["']`)},
+	{provider: "tencent", kind: KindCaptcha, where: inScript, pattern: regexp.MustCompile(`(?i)(captcha\.qq\.com|turing\.captcha\.qcloud\.com)/TCaptcha`)},
+	{provider: "slider", kind: KindCaptcha, where: inBody, pattern: regexp.MustCompile(`(?i)class=["'][^"']*\b(slider-captcha|captcha-slider|slide-verify|slidercaptcha)\b`)},
+}
+
+var (
+	titleRegex  = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	scriptRegex = regexp.MustCompile(`(?i)<script[^>]+src\s*=\s*["']?([^"'\s>]+)`)
+	// titles and headings of pages whose purpose is the verification
+	verificationRegex = regexp.MustCompile(`(?i)captcha|verify (you are|that you are) (a )?human|are you a robot|security check|human verification|access denied|bot detection|人机验证|安全验证|滑动验证|请完成验证`)
+)
+
+// Detect returns the challenge a response shows, nil when it is an
+// ordinary page. Header names are matched case-insensitively.
+func Detect(status int, headers map[string]string, body string) *Result {
+	lowerHeaders := make(map[string]string, len(headers))
+	for key, value := range headers {
+		lowerHeaders[strings.ToLower(key)] = value
+	}
+	var title string
+	if match := titleRegex.FindStringSubmatch(body); match != nil {
+		title = strings.TrimSpace(match[1])
+	}
+	var scripts []string
+	for _, match := range scriptRegex.FindAllStringSubmatch(body, -1) {
+		scripts = append(scripts, match[1])
+	}
+
+	var interstitial, captcha *Result
+	for _, marker := range markers {
+		evidence := marker.match(status, lowerHeaders, title, scripts, body)
+		if evidence == "" {
+			continue
+		}
+		target := &captcha
+		if marker.kind == KindInterstitial {
+			target = &interstitial
+		}
+		if *target == nil {
+			*target = &Result{Provider: marker.provider, Kind: marker.kind}
+		}
+		if (*target).Provider == marker.provider {
+			(*target).Evidence = append((*target).Evidence, evidence)
+		}
+	}
+
+	if interstitial != nil {
+		return interstitial
+	}
+	if captcha != nil && (containsStatus(blockingStatus, status) || verificationRegex.MatchString(title)) {
+		if title != "" {
+			captcha.Evidence = append(captcha.Evidence, "title "+truncate(title))
+		}
+		return captcha
+	}
+	if status == 429 {
+		return &Result{Provider: "generic", Kind: KindRateLimit, Evidence: []string{"status 429"}}
+	}
+	return nil
+}
+
+// match returns the evidence for the marker, empty if it is absent
+func (m marker) match(status int, headers map[string]string, title string, scripts []string, body string) string {
+	if len(m.status) > 0 && !containsStatus(m.status, status) {
+		return ""
+	}
+	switch m.where {
+	case inHeader:
+		if value, ok := headers[m.header]; ok && m.pattern.MatchString(value) {
+			return fmt.Sprintf("header %s: %s", m.header, value)
+		}
+	case inScript:
+		for _, src := range scripts {
+			if m.pattern.MatchString(src) {
+				return "script " + truncate(src)
+			}
+		}
+	case inBody:
+		if found := m.pattern.FindString(body); found != "" {
+			return "body " + truncate(found)
+		}
+	case inTitle:
+		if m.pattern.MatchString(title) {
+			return "title " + truncate(title)
+		}
+	}
+	return ""
+}
+
+func truncate(value string) string {
+	if len(value) > 100 {
+		return value[:100] + "..."
+	}
+	return value
+}
+
+func containsStatus(values []int, status int) bool {
+	for _, value := range values {
+		if value == status {
+			return true
+		}
+	}
+	return false
+}
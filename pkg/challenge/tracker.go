@@ -0,0 +1,199 @@
+package challenge
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default back-off settings
+const (
+	DefaultBaseDelay      = 2 * time.Second
+	DefaultMaxDelay       = 60 * time.Second
+	DefaultMaxConsecutive = 8
+)
+
+// HostReport summarises the challenges a host served
+type HostReport struct {
+	Host       string    `json:"host"`
+	Providers  []string  `json:"providers"`
+	Challenges int       `json:"challenges"` // challenge pages received
+	FirstURL   string    `json:"first_url"`  // first URL answered with a challenge
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+	GaveUp     bool      `json:"gave_up,omitempty"` // crawling of the host was stopped
+}
+
+type hostState struct {
+	report      HostReport
+	consecutive int
+	next        time.Time // earliest start of the next request while backing off
+}
+
+// Tracker counts challenge pages per host. Consecutive challenges double the
+// delay between requests to the host, an ordinary page resets it, and after
+// MaxConsecutive challenges in a row the host is given up. While backing off
+// each request reserves its own start time, so concurrent requests to a host
+// are spaced by the delay instead of all firing when it ends. It is safe for
+// concurrent use and may be shared by both engines.
+type Tracker struct {
+	BaseDelay      time.Duration // delay after the first challenge
+	MaxDelay       time.Duration // upper bound of the delay, back-off is disabled when 0
+	MaxConsecutive int           // challenges in a row before giving up, never when 0, independent of MaxDelay
+
+	mutex sync.Mutex
+	hosts map[string]*hostState
+	sleep func(time.Duration)
+	clock func() time.Time
+}
+
+// NewTracker returns a tracker with the default back-off settings
+func NewTracker() *Tracker {
+	return &Tracker{
+		BaseDelay:      DefaultBaseDelay,
+		MaxDelay:       DefaultMaxDelay,
+		MaxConsecutive: DefaultMaxConsecutive,
+	}
+}
+
+// Record registers the outcome of a request to a host, result is nil for an
+// ordinary page
+func (t *Tracker) Record(host, rawURL string, result *Result) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.hosts == nil {
+		t.hosts = map[string]*hostState{}
+	}
+	state, ok := t.hosts[host]
+	if result == nil {
+		if ok {
+			state.consecutive = 0
+			state.next = time.Time{}
+		}
+		return
+	}
+	now := time.Now()
+	if !ok {
+		state = &hostState{report: HostReport{Host: host, FirstURL: rawURL, FirstSeen: now}}
+		t.hosts[host] = state
+	}
+	state.consecutive++
+	state.report.Challenges++
+	state.report.LastSeen = now
+	if !containsString(state.report.Providers, result.Provider) {
+		state.report.Providers = append(state.report.Providers, result.Provider)
+	}
+	// the next request waits for the delay after this challenge
+	if delay := t.delay(host); delay > 0 {
+		state.next = t.now().Add(delay)
+	}
+	if t.MaxConsecutive > 0 && state.consecutive >= t.MaxConsecutive {
+		state.report.GaveUp = true
+	}
+}
+
+// Delay returns the back-off delay between requests to a host
+func (t *Tracker) Delay(host string) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.delay(host)
+}
+
+func (t *Tracker) delay(host string) time.Duration {
+	state, ok := t.hosts[host]
+	if !ok || state.consecutive == 0 || t.MaxDelay <= 0 {
+		return 0
+	}
+	delay := t.BaseDelay
+	for i := 1; i < state.consecutive && delay < t.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > t.MaxDelay {
+		delay = t.MaxDelay
+	}
+	return delay
+}
+
+// Reserve books the next request slot of a host and returns how long the
+// caller has to wait for it. Slots are spaced by the back-off delay, so
+// requests reserved together are serialized. ok is false when the host was
+// given up.
+func (t *Tracker) Reserve(host string) (wait time.Duration, ok bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	state, exist := t.hosts[host]
+	if !exist {
+		return 0, true
+	}
+	if state.report.GaveUp {
+		return 0, false
+	}
+	delay := t.delay(host)
+	if delay <= 0 {
+		return 0, true
+	}
+	now := t.now()
+	slot := state.next
+	if slot.Before(now) {
+		slot = now
+	}
+	state.next = slot.Add(delay)
+	return slot.Sub(now), true
+}
+
+// Wait sleeps until the reserved slot of a host and reports whether it may
+// still be crawled
+func (t *Tracker) Wait(host string) bool {
+	wait, ok := t.Reserve(host)
+	if !ok {
+		return false
+	}
+	if wait > 0 {
+		if t.sleep != nil {
+			t.sleep(wait)
+		} else {
+			time.Sleep(wait)
+		}
+	}
+	return !t.GaveUp(host)
+}
+
+func (t *Tracker) now() time.Time {
+	if t.clock != nil {
+		return t.clock()
+	}
+	return time.Now()
+}
+
+// GaveUp reports whether crawling of a host was stopped
+func (t *Tracker) GaveUp(host string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	state, ok := t.hosts[host]
+	return ok && state.report.GaveUp
+}
+
+// Report returns the hosts that served challenges, sorted by host
+func (t *Tracker) Report() []HostReport {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	reports := make([]HostReport, 0, len(t.hosts))
+	for _, state := range t.hosts {
+		report := state.report
+		report.Providers = append([]string(nil), report.Providers...)
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Host < reports[j].Host
+	})
+	return reports
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
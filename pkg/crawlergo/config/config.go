@@ -1,13 +1,15 @@
 package config
 
 import (
+	"katanacrawlgo/pkg/formfill"
+	"katanacrawlgo/pkg/identity"
 	"time"
 
 	mapset "github.com/deckarep/golang-set"
 )
 
 const (
-	DefaultUA               = "Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/79.0.3945.0 Safari/537.36"
+	DefaultUA               = identity.DefaultUserAgent
 	MaxTabsCount            = 10
 	TabRunTimeout           = 20 * time.Second
 	DefaultInputText        = "admin"
@@ -21,6 +23,28 @@ const (
 	DefaultEventTriggerMode = EventTriggerAsync
 	MaxCrawlCount           = 200
 	MaxRunTime              = 60 * 60
+	ScrollStepSize          = 800
+	ScrollMaxSteps          = 20
+	ScrollInterval          = 300 * time.Millisecond
+	LoadMoreMaxClicks       = 10
+	LoadMoreMaxTextLength   = 30
+	StateMaxActions         = 30
+	StateMaxDepth           = 3
+	StateMaxClickables      = 50
+	StateActionDelay        = 500 * time.Millisecond
+	FormVariantDelay        = 500 * time.Millisecond // 每次按选项组合提交表单后的等待时间
+	MaxRedirectHops         = 10
+	FrameMaxCount           = 10
+	BrowserHealthInterval   = 10 * time.Second // 远程浏览器健康检查的间隔
+	BrowserHealthTimeout    = 5 * time.Second
+	BrowserMaxFailures      = 3  // 连续检查失败次数达到后移除远程浏览器
+	MaxFindingsPerPage      = 50 // 每个页面最多记录的运行时发现
+	FindingValueMaxLength   = 200
+	SinkMarkerMinLength     = 8  // 作为sink标记的最短URL参数值，纯数字需要再长2位，纯字母的单词不作为标记
+	GraphQLTimeout          = 10 // GraphQL内省请求的超时时间，单位秒
+	GraphQLMaxEndpoints     = 10 // 每个任务最多内省的GraphQL端点数量
+	OpenAPITimeout          = 10 // 下载OpenAPI文档的超时时间，单位秒
+	OpenAPIMaxSpecs         = 20 // 每个任务最多解析的OpenAPI文档数量
 )
 
 // 请求方法
@@ -46,6 +70,12 @@ const (
 	EventTriggerSync  = "sync"
 )
 
+// HAR 输出模式
+const (
+	HarModeTab    = "tab"    // 每个标签页一个HAR文件
+	HarModeTarget = "target" // 同一目标的所有标签页合并为一个HAR文件
+)
+
 // 请求的来源
 const (
 	FromTarget      = "Target"     //初始输入的目标
@@ -64,6 +94,10 @@ const (
 	FromHashChange  = "HashChange"
 	FromStaticRes   = "StaticResource"
 	FromStaticRegex = "StaticRegex"
+	FromRouter      = "Router"  //前端路由表中解析
+	FromFrame       = "Frame"   //子frame的导航请求
+	FromGraphQL     = "GraphQL" //GraphQL端点及内省生成的操作
+	FromOpenAPI     = "OpenAPI" //OpenAPI文档及其中定义的接口
 )
 
 // content-type
@@ -94,52 +128,14 @@ var (
 )
 
 var DefaultIgnoreKeywords = []string{"logout", "quit", "exit"}
-var AllowedFormName = []string{"default", "mail", "code", "phone", "username", "password", "qq", "id_card", "url", "date", "number"}
 
-type ContinueResourceList []string
+// 可以自定义填充内容的表单类型，即表单填充规则的名称
+var AllowedFormName = append([]string{"default"}, formfill.Default().Names()...)
 
-var InputTextMap = map[string]map[string]interface{}{
-	"mail": {
-		"keyword": []string{"mail"},
-		"value":   "admin@qq.com",
-	},
-	"code": {
-		"keyword": []string{"yanzhengma", "code", "ver", "captcha"},
-		"value":   "123a",
-	},
-	"phone": {
-		"keyword": []string{"phone", "number", "tel", "shouji"},
-		"value":   "18812345678",
-	},
-	"username": {
-		"keyword": []string{"name", "user", "id", "login", "account"},
-		"value":   "superadmin",
-	},
-	"password": {
-		"keyword": []string{"pass", "pwd", "password"},
-		"value":   "123456",
-	},
-	"qq": {
-		"keyword": []string{"qq", "wechat", "tencent", "weixin"},
-		"value":   "123456789",
-	},
-	"IDCard": {
-		"keyword": []string{"card", "shenfen"},
-		"value":   "511702197409284963",
-	},
-	"url": {
-		"keyword": []string{"url", "site", "web", "blog", "link"},
-		"value":   "https://www.baidu.com/",
-	},
-	"date": {
-		"keyword": []string{"date", "time", "year", "now"},
-		"value":   "2023-01-01",
-	},
-	"number": {
-		"keyword": []string{"day", "age", "num", "count"},
-		"value":   "10",
-	},
-}
+// 旧版本中与规则名称不同的表单类型，自定义填充内容时转换为规则名称
+var FormNameAliases = map[string]string{"IDCard": "id_card"}
+
+type ContinueResourceList []string
 
 func init() {
 	StaticSuffixSet = initSet(StaticSuffix)
@@ -2,16 +2,21 @@ package engine
 
 import (
 	"context"
-	"katanacrawlgo/pkg/crawlergo/config"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"katanacrawlgo/pkg/crawlergo/js"
+	"katanacrawlgo/pkg/formfill"
+	"katanacrawlgo/pkg/upload"
 	"log"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/ttacon/chalk"
 
 	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
 )
 
@@ -27,6 +32,8 @@ func (tab *Tab) AfterDOMRun() {
 		return
 	}
 
+	tab.detectMetaRefresh()
+
 	tab.domWG.Add(2)
 	go tab.fillForm()
 	go tab.setObserverJS()
@@ -64,7 +71,7 @@ func (tab *Tab) getBodyNodeId() bool {
 */
 func (tab *Tab) fillForm() {
 	defer tab.domWG.Done()
-	tab.fillFormWG.Add(3)
+	tab.fillFormWG.Add(4)
 	f := FillForm{
 		tab: tab,
 	}
@@ -72,6 +79,7 @@ func (tab *Tab) fillForm() {
 	go f.fillInput()
 	go f.fillMultiSelect()
 	go f.fillTextarea()
+	go f.fillShadowForm()
 
 	tab.fillFormWG.Wait()
 }
@@ -117,21 +125,27 @@ func (f *FillForm) fillInput() {
 		return
 	}
 
-	// 找出 type 为空 或者 type=text
+	// label 只能通过JS获取
+	var labels map[string]string
+	_ = f.tab.evaluateResult(js.FormLabelsJS, &labels)
+
 	for _, node := range nodes {
 		// 兜底超时
 		tCtxN, cancelN := context.WithTimeout(ctx, time.Second*5)
-		attrType := node.AttributeValue("type")
-		if attrType == "text" || attrType == "" {
-			inputName := node.AttributeValue("id") + node.AttributeValue("class") + node.AttributeValue("name")
-			value := f.GetMatchInputText(inputName)
-			var nodeIds = []cdp.NodeID{node.NodeID}
-			// 先使用模拟输入
-			_ = chromedp.SendKeys(nodeIds, value, chromedp.ByNodeID).Do(tCtxN)
-			// 再直接赋值JS属性
-			_ = chromedp.SetAttributeValue(nodeIds, "value", value, chromedp.ByNodeID).Do(tCtxN)
-		} else if attrType == "email" || attrType == "password" || attrType == "tel" {
-			value := f.GetMatchInputText(attrType)
+		attrType := strings.ToLower(node.AttributeValue("type"))
+		if fillableInputTypes[attrType] {
+			value := f.GetMatchFieldText(formfill.Field{
+				Type:         attrType,
+				Name:         node.AttributeValue("name"),
+				ID:           node.AttributeValue("id"),
+				Class:        node.AttributeValue("class"),
+				Label:        labels[node.AttributeValue("id")] + node.AttributeValue("aria-label"),
+				Placeholder:  node.AttributeValue("placeholder"),
+				Autocomplete: node.AttributeValue("autocomplete"),
+				Min:          node.AttributeValue("min"),
+				Max:          node.AttributeValue("max"),
+				Step:         node.AttributeValue("step"),
+			})
 			var nodeIds = []cdp.NodeID{node.NodeID}
 			// 先使用模拟输入
 			_ = chromedp.SendKeys(nodeIds, value, chromedp.ByNodeID).Do(tCtxN)
@@ -140,24 +154,50 @@ func (f *FillForm) fillInput() {
 		} else if attrType == "radio" || attrType == "checkbox" {
 			var nodeIds = []cdp.NodeID{node.NodeID}
 			_ = chromedp.SetAttributeValue(nodeIds, "checked", "true", chromedp.ByNodeID).Do(tCtxN)
-		} else if attrType == "file" || attrType == "image" {
-			var nodeIds = []cdp.NodeID{node.NodeID}
-			wd, _ := os.Getwd()
-			filePath := wd + "/upload/image.png"
-			_ = chromedp.RemoveAttribute(nodeIds, "accept", chromedp.ByNodeID).Do(tCtxN)
-			_ = chromedp.RemoveAttribute(nodeIds, "required", chromedp.ByNodeID).Do(tCtxN)
-			_ = chromedp.SendKeys(nodeIds, filePath, chromedp.ByNodeID).Do(tCtxN)
+		} else if attrType == "file" && f.tab.remoteBrowser {
+			// 远程浏览器无法读取本地的临时目录，在页面中构造样例文件
+			f.tab.setFileInputData(tCtxN, node.NodeID, upload.Select(node.AttributeValue("accept")))
+		} else if attrType == "file" {
+			// 按 accept 属性选择样例文件，文件位于临时目录
+			filePath, err := upload.Path(node.AttributeValue("accept"))
+			if err != nil {
+				log.Println(chalk.Red.Color("error: " + err.Error()))
+			} else {
+				_ = dom.SetFileInputFiles([]string{filePath}).WithNodeID(node.NodeID).Do(tCtxN)
+			}
 		}
 		cancelN()
 	}
 }
 
+/*
+*
+使用 DataTransfer 将样例文件的内容设置到文件输入框
+*/
+func (tab *Tab) setFileInputData(ctx context.Context, nodeID cdp.NodeID, sample *upload.Sample) {
+	object, err := dom.ResolveNode().WithNodeID(nodeID).Do(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = runtime.ReleaseObject(object.ObjectID).Do(ctx)
+	}()
+	data := base64.StdEncoding.EncodeToString(sample.Data())
+	_, exp, err := runtime.CallFunctionOn(fmt.Sprintf(js.SetFileInputJS, data, sample.Name, sample.MIME)).
+		WithObjectID(object.ObjectID).Do(ctx)
+	if err != nil {
+		log.Println(chalk.Red.Color("error: " + err.Error()))
+	} else if exp != nil {
+		log.Println(chalk.Red.Color("error: " + exp.Text))
+	}
+}
+
 func (f *FillForm) fillTextarea() {
 	defer f.tab.fillFormWG.Done()
 	ctx := f.tab.GetExecutor()
 	tCtx, cancel := context.WithTimeout(ctx, time.Second*2)
 	defer cancel()
-	value := f.GetMatchInputText("other")
+	value := f.GetMatchFieldText(formfill.Field{Type: "textarea"})
 
 	textareaNodes, textareaErr := f.tab.GetNodeIDs(`textarea`)
 	if textareaErr != nil || len(textareaNodes) == 0 {
@@ -186,25 +226,126 @@ func (f *FillForm) fillMultiSelect() {
 	_ = chromedp.SetJavascriptAttribute(optionNodes, "selected", "true", chromedp.ByNodeID).Do(tCtx)
 }
 
+/*
+*
+填充 shadow DOM 中的输入框，节点查询无法穿透 shadow root，通过JS填充
+*/
+func (f *FillForm) fillShadowForm() {
+	defer f.tab.fillFormWG.Done()
+	f.fillWith(f.tab.evaluateResult, true)
+}
+
+type formField struct {
+	ID           string `json:"id"`
+	Tag          string `json:"tag"`
+	Type         string `json:"type"`
+	Name         string `json:"name"`
+	ElemID       string `json:"elemId"`
+	Class        string `json:"class"`
+	Label        string `json:"label"`
+	Placeholder  string `json:"placeholder"`
+	Autocomplete string `json:"autocomplete"`
+	Min          string `json:"min"`
+	Max          string `json:"max"`
+	Step         string `json:"step"`
+}
+
+// 按填充规则填充内容的 input 类型，其余类型如 hidden、submit 保持原值
+var fillableInputTypes = map[string]bool{
+	"": true, "text": true, "email": true, "password": true, "tel": true, "search": true, "url": true,
+	"number": true, "range": true, "date": true, "datetime-local": true, "month": true, "week": true,
+	"time": true, "color": true,
+}
+
+/*
+*
+使用指定的执行方式填充表单，填充内容的匹配规则与节点填充相同
+shadowOnly 为 true 时只填充 shadow DOM 中的输入框
+*/
+func (f *FillForm) fillWith(evaluate func(expression string, res interface{}) error, shadowOnly bool) {
+	var fields []formField
+	if err := evaluate(fmt.Sprintf(js.FormFieldsJS, shadowOnly), &fields); err != nil || len(fields) == 0 {
+		return
+	}
+	values := map[string]string{}
+	for _, field := range fields {
+		fillField := formfill.Field{
+			Type:         field.Type,
+			Name:         field.Name,
+			ID:           field.ElemID,
+			Class:        field.Class,
+			Label:        field.Label,
+			Placeholder:  field.Placeholder,
+			Autocomplete: field.Autocomplete,
+			Min:          field.Min,
+			Max:          field.Max,
+			Step:         field.Step,
+		}
+		if field.Tag == "textarea" {
+			fillField.Type = "textarea"
+			values[field.ID] = f.GetMatchFieldText(fillField)
+			continue
+		}
+		switch {
+		case fillableInputTypes[field.Type]:
+			values[field.ID] = f.GetMatchFieldText(fillField)
+		case field.Type == "radio" || field.Type == "checkbox":
+			values[field.ID] = ""
+		}
+	}
+	data, _ := json.Marshal(values)
+	_ = evaluate(fmt.Sprintf(js.FormFillJS, string(data), shadowOnly), nil)
+}
+
+/*
+*
+按名称匹配填充内容，名称为 id、class、name 等属性拼接的文本
+*/
 func (f *FillForm) GetMatchInputText(name string) string {
+	return f.GetMatchFieldText(formfill.Field{Name: name})
+}
+
+/*
+*
+按表单填充规则匹配填充内容
+*/
+func (f *FillForm) GetMatchFieldText(field formfill.Field) string {
+	return MatchFieldText(&f.tab.config, f.tab.sinkCanary, field)
+}
+
+/*
+*
+按标签页配置匹配填充内容
+优先使用自定义关键词，其次是与规则同名的自定义值，最后使用规则的值
+sink 检测时未匹配规则和自由文本规则的输入框填入标记，优先于 default 自定义值，到达sink的值可以确定来自填充
+*/
+func MatchFieldText(config *TabConfig, sinkCanary string, field formfill.Field) string {
 	// 如果自定义了关键词，模糊匹配
-	for key, value := range f.tab.config.CustomFormKeywordValues {
+	name := field.ID + field.Class + field.Name
+	for key, value := range config.CustomFormKeywordValues {
 		if strings.Contains(name, key) {
 			return value
 		}
 	}
 
-	name = strings.ToLower(name)
-	for key, item := range config.InputTextMap {
-		for _, keyword := range item["keyword"].([]string) {
-			if strings.Contains(name, keyword) {
-				if customValue, ok := f.tab.config.CustomFormValues[key]; ok {
-					return customValue
-				} else {
-					return item["value"].(string)
-				}
-			}
+	rules := config.FormRules
+	if rules == nil {
+		rules = formfill.Default()
+	}
+	rule := rules.Match(field)
+	if rule != nil {
+		if customValue, ok := config.CustomFormValues[rule.Name]; ok {
+			return customValue
+		}
+	}
+	if sinkCanary != "" && (rule == nil || rule.FreeText) {
+		return sinkCanary
+	}
+	if rule == nil {
+		if customValue, ok := config.CustomFormValues["default"]; ok {
+			return customValue
 		}
+		return rules.Default
 	}
-	return f.tab.config.CustomFormValues["default"]
+	return rules.RuleValue(rule, field)
 }
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"katanacrawlgo/pkg/crawlergo/config"
 	"katanacrawlgo/pkg/crawlergo/js"
-	"katanacrawlgo/pkg/crawlergo/tools"
 	"log"
 	"time"
 
@@ -29,13 +28,31 @@ err := EvaluateAsDevTools(snippet(submitJS, cashX(true), sel, nodes[0]), &res).D
 */
 func (tab *Tab) AfterLoadedRun() {
 	defer tab.WG.Done()
-	tab.formSubmitWG.Add(2)
+	tab.formSubmitWG.Add(3)
 	tab.loadedWG.Add(3)
 	tab.removeLis.Add(1)
 
+	tab.lock.Lock()
+	tab.interacting = true
+	tab.lock.Unlock()
+
+	// 在点击和提交之前，禁止危险的按钮、链接和表单，滚动时会点击加载更多和翻页
+	tab.applySafeMode()
+
+	// 先滚动页面加载更多内容，之后的表单提交和事件触发覆盖新增的DOM
+	tab.scrollPage()
+
+	// 滚动新增的DOM节点同样需要分类，已分类的节点不会重复处理
+	tab.applySafeMode()
+
 	go tab.formSubmit()
 	tab.formSubmitWG.Wait()
 
+	// 按下拉框和单选组的不同取值再次提交表单
+	if tab.config.FormVariants > 0 {
+		tab.submitFormVariants()
+	}
+
 	if tab.config.EventTriggerMode == config.EventTriggerAsync {
 		go tab.triggerJavascriptProtocol()
 		go tab.triggerInlineEvents()
@@ -49,6 +66,21 @@ func (tab *Tab) AfterLoadedRun() {
 		tab.triggerJavascriptProtocol()
 	}
 
+	// 在同域的子frame中填充、提交表单并触发事件
+	if tab.config.FrameCrawl {
+		tab.crawlFrames()
+	}
+
+	// 事件触发之后，前端路由表已经初始化完成
+	if tab.config.RouteDiscovery {
+		tab.discoverRoutes()
+	}
+
+	// 单次事件触发无法到达的状态，通过交互序列继续探索
+	if tab.config.StateExplore {
+		tab.exploreStates()
+	}
+
 	// 事件触发之后 需要等待一点时间让浏览器成功发出ajax请求 更新DOM
 	time.Sleep(tab.config.BeforeExitDelay)
 
@@ -68,6 +100,7 @@ func (tab *Tab) formSubmit() {
 	// 接下来尝试三种方式提交表单
 	go tab.clickSubmit()
 	go tab.clickAllButton()
+	go tab.submitShadowForms()
 }
 
 /*
@@ -76,7 +109,7 @@ func (tab *Tab) formSubmit() {
 */
 func (tab *Tab) setFormToFrame() {
 	// 首先新建 frame
-	nameStr := tools.RandSeq(8)
+	nameStr := tab.formFrameName
 	tab.Evaluate(fmt.Sprintf(js.NewFrameTemplate, nameStr, nameStr))
 
 	// 接下来将所有的 form 节点target都指向它
@@ -104,7 +137,7 @@ func (tab *Tab) clickSubmit() {
 	ctx := tab.GetExecutor()
 
 	// 获取所有的form节点 直接执行submit
-	formNodes, formErr := tab.GetNodeIDs(`form`)
+	formNodes, formErr := tab.GetNodeIDs(`form:not([crawlergo-safe-blocked])`)
 	if formErr != nil || len(formNodes) == 0 {
 		if formErr != nil {
 			log.Println(chalk.Red.Color("error: " + formErr.Error()))
@@ -116,7 +149,7 @@ func (tab *Tab) clickSubmit() {
 	_ = chromedp.Submit(formNodes, chromedp.ByNodeID).Do(tCtx1)
 
 	// 获取所有的input标签
-	inputNodes, inputErr := tab.GetNodeIDs(`form input[type=submit]`)
+	inputNodes, inputErr := tab.GetNodeIDs(`form:not([crawlergo-safe-blocked]) input[type=submit]:not([crawlergo-safe-blocked])`)
 	if inputErr != nil || len(inputNodes) == 0 {
 		if inputErr != nil {
 			log.Println(chalk.Red.Color("error: " + inputErr.Error()))
@@ -138,7 +171,7 @@ func (tab *Tab) clickAllButton() {
 	// 获取所有的form中的button节点
 	ctx := tab.GetExecutor()
 	// 获取所有的button标签
-	btnNodeIDs, bErr := tab.GetNodeIDs(`form button`)
+	btnNodeIDs, bErr := tab.GetNodeIDs(`form:not([crawlergo-safe-blocked]) button:not([crawlergo-safe-blocked])`)
 	if bErr != nil || len(btnNodeIDs) == 0 {
 		if bErr != nil {
 			log.Println(chalk.Red.Color("error: " + bErr.Error()))
@@ -162,6 +195,15 @@ func (tab *Tab) clickAllButton() {
 	}
 }
 
+/*
+*
+提交 shadow DOM 中的表单，节点查询无法穿透 shadow root，通过JS点击和提交
+*/
+func (tab *Tab) submitShadowForms() {
+	defer tab.formSubmitWG.Done()
+	tab.Evaluate(fmt.Sprintf(js.FormSubmitJS, tab.formFrameName, true))
+}
+
 /*
 *
 触发内联事件
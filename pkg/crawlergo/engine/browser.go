@@ -17,6 +17,7 @@ type Browser struct {
 	tabs         []*context.Context
 	tabCancels   []context.CancelFunc
 	ExtraHeaders map[string]interface{}
+	remote       bool // 连接的远程浏览器，关闭时只断开连接，不关闭浏览器
 	lock         sync.Mutex
 }
 
@@ -48,6 +49,8 @@ func InitBrowser(chromiumPath string, extraHeaders map[string]interface{}, proxy
 		chromedp.Flag("disable-webgl", true),
 
 		chromedp.Flag("disable-popup-blocking", true),
+		// 去掉 navigator.webdriver 等自动化特征
+		chromedp.Flag("disable-blink-features", "AutomationControlled"),
 
 		chromedp.WindowSize(1920, 1080),
 	)
@@ -80,42 +83,61 @@ func InitBrowser(chromiumPath string, extraHeaders map[string]interface{}, proxy
 }
 
 func ConnectBrowser(wsUrl string, extraHeaders map[string]interface{}) *Browser {
+	bro, err := connectBrowser(wsUrl, extraHeaders)
+	if err != nil {
+		// couldn't connect to the remote browser, need to exit
+		log.Println(chalk.Red.Color("error: 浏览器上下文解析失败: " + err.Error()))
+	}
+	return bro
+}
+
+/*
+*
+连接远程浏览器，连接失败时返回错误
+*/
+func connectBrowser(wsUrl string, extraHeaders map[string]interface{}) (*Browser, error) {
 	var bro Browser
 	allocCtx, cancel := chromedp.NewRemoteAllocator(context.Background(), wsUrl)
 	bctx, _ := chromedp.NewContext(allocCtx,
 		chromedp.WithLogf(log.Printf),
 	)
-
-	err := chromedp.Run(bctx)
-	if err != nil {
-		// couldn't connect to the remote browser, need to exit
-		log.Println(chalk.Red.Color("error: 浏览器上下文解析失败: " + err.Error()))
-	}
 	bro.Cancel = &cancel
 	bro.Ctx = &bctx
 	bro.ExtraHeaders = extraHeaders
+	bro.remote = true
 
-	return &bro
+	err := chromedp.Run(bctx)
+	return &bro, err
 }
 
 func (bro *Browser) NewTab(timeout time.Duration) (*context.Context, context.CancelFunc) {
 	bro.lock.Lock()
 	ctx, cancel := chromedp.NewContext(*bro.Ctx)
-	//defer cancel()
-	tCtx, _ := context.WithTimeout(ctx, timeout)
+	tCtx, tCancel := context.WithTimeout(ctx, timeout)
+	tabCancel := func() {
+		tCancel()
+		cancel()
+	}
 	bro.tabs = append(bro.tabs, &tCtx)
-	bro.tabCancels = append(bro.tabCancels, cancel)
-	//defer cancel2()
+	bro.tabCancels = append(bro.tabCancels, tabCancel)
 	bro.lock.Unlock()
 
-	//return bro.Ctx, &cancel
-	return &tCtx, cancel
+	return &tCtx, tabCancel
 }
 
+/*
+*
+关闭所有标签页和浏览器
+远程浏览器可能被其它程序共用，只关闭创建的标签页并断开连接
+*/
 func (bro *Browser) Close() {
 	for _, cancel := range bro.tabCancels {
 		cancel()
 	}
+	if bro.remote {
+		(*bro.Cancel)()
+		return
+	}
 
 	for _, ctx := range bro.tabs {
 		browser.Close().Do(*ctx)
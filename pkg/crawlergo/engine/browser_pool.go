@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+	"github.com/ttacon/chalk"
+)
+
+type browserEndpoint struct {
+	URL      string
+	browser  *Browser
+	active   int // 正在使用的标签页数量
+	failures int // 连续健康检查失败的次数
+}
+
+/*
+*
+远程浏览器池，标签页分配给正在使用的标签页最少的浏览器
+定期检查浏览器是否存活，连续失败的浏览器会被移除
+*/
+type BrowserPool struct {
+	endpoints []*browserEndpoint
+	probe     func(bro *Browser) error
+	lock      sync.Mutex
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+/*
+*
+连接多个远程浏览器并启动健康检查，全部连接失败时返回错误
+*/
+func NewBrowserPool(wsUrls []string, extraHeaders map[string]interface{}) (*BrowserPool, error) {
+	pool := newBrowserPool()
+	for _, wsUrl := range wsUrls {
+		bro, err := connectBrowser(wsUrl, extraHeaders)
+		if err != nil {
+			log.Println(chalk.Red.Color("error: 远程浏览器连接失败, " + wsUrl + ", " + err.Error()))
+			bro.Close()
+			continue
+		}
+		pool.add(wsUrl, bro)
+	}
+	if pool.Size() == 0 {
+		return nil, errors.New("no remote browser available")
+	}
+	go pool.healthLoop(config.BrowserHealthInterval)
+	return pool, nil
+}
+
+func newBrowserPool() *BrowserPool {
+	return &BrowserPool{
+		probe: pingBrowser,
+		stop:  make(chan struct{}),
+	}
+}
+
+func (pool *BrowserPool) add(wsUrl string, bro *Browser) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	pool.endpoints = append(pool.endpoints, &browserEndpoint{URL: wsUrl, browser: bro})
+}
+
+/*
+*
+当前可用的浏览器数量
+*/
+func (pool *BrowserPool) Size() int {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	return len(pool.endpoints)
+}
+
+/*
+*
+获取负载最小的浏览器，使用完毕后需要调用 Release
+没有可用的浏览器时返回nil
+*/
+func (pool *BrowserPool) Acquire() *Browser {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	var selected *browserEndpoint
+	for _, endpoint := range pool.endpoints {
+		if (*endpoint.browser.Ctx).Err() != nil {
+			continue
+		}
+		if selected == nil || endpoint.active < selected.active {
+			selected = endpoint
+		}
+	}
+	if selected == nil {
+		return nil
+	}
+	selected.active++
+	return selected.browser
+}
+
+/*
+*
+归还 Acquire 获取的浏览器
+*/
+func (pool *BrowserPool) Release(bro *Browser) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	for _, endpoint := range pool.endpoints {
+		if endpoint.browser == bro && endpoint.active > 0 {
+			endpoint.active--
+			return
+		}
+	}
+}
+
+func (pool *BrowserPool) healthLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-pool.stop:
+			return
+		case <-ticker.C:
+			pool.CheckHealth()
+		}
+	}
+}
+
+/*
+*
+检查所有浏览器是否存活，移除连续失败达到上限或连接已断开的浏览器
+*/
+func (pool *BrowserPool) CheckHealth() {
+	pool.lock.Lock()
+	endpoints := make([]*browserEndpoint, len(pool.endpoints))
+	copy(endpoints, pool.endpoints)
+	pool.lock.Unlock()
+
+	var removed []*browserEndpoint
+	for _, endpoint := range endpoints {
+		err := pool.probe(endpoint.browser)
+		pool.lock.Lock()
+		if err == nil {
+			endpoint.failures = 0
+		} else {
+			endpoint.failures++
+			if endpoint.failures >= config.BrowserMaxFailures || (*endpoint.browser.Ctx).Err() != nil {
+				removed = append(removed, endpoint)
+				pool.remove(endpoint)
+			}
+		}
+		pool.lock.Unlock()
+	}
+	for _, endpoint := range removed {
+		log.Println(chalk.Yellow.Color("远程浏览器无响应，已移除: " + endpoint.URL))
+		endpoint.browser.Close()
+	}
+}
+
+func (pool *BrowserPool) remove(target *browserEndpoint) {
+	for i, endpoint := range pool.endpoints {
+		if endpoint == target {
+			pool.endpoints = append(pool.endpoints[:i], pool.endpoints[i+1:]...)
+			return
+		}
+	}
+}
+
+/*
+*
+停止健康检查并关闭所有浏览器
+*/
+func (pool *BrowserPool) Close() {
+	pool.stopOnce.Do(func() {
+		close(pool.stop)
+	})
+	pool.lock.Lock()
+	endpoints := pool.endpoints
+	pool.endpoints = nil
+	pool.lock.Unlock()
+	for _, endpoint := range endpoints {
+		endpoint.browser.Close()
+	}
+}
+
+/*
+*
+通过 Browser.getVersion 检查浏览器连接
+*/
+func pingBrowser(bro *Browser) error {
+	c := chromedp.FromContext(*bro.Ctx)
+	if c == nil || c.Browser == nil {
+		return errors.New("browser not connected")
+	}
+	tCtx, cancel := context.WithTimeout(*bro.Ctx, config.BrowserHealthTimeout)
+	defer cancel()
+	_, _, _, _, _, err := browser.GetVersion().Do(cdp.WithExecutor(tCtx, c.Browser))
+	return err
+}
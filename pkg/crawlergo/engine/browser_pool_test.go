@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeBrowser() *Browser {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Browser{Ctx: &ctx, Cancel: &cancel, remote: true}
+}
+
+func TestBrowserClose_remote(t *testing.T) {
+	bro := fakeBrowser()
+	tabCtx, _ := bro.NewTab(time.Minute)
+	// 远程浏览器只关闭标签页并断开连接，不发送 Browser.close
+	bro.Close()
+	assert.Error(t, (*tabCtx).Err())
+	assert.Error(t, (*bro.Ctx).Err())
+}
+
+func TestBrowserPoolAcquire(t *testing.T) {
+	pool := newBrowserPool()
+	a, b := fakeBrowser(), fakeBrowser()
+	pool.add("ws://a", a)
+	pool.add("ws://b", b)
+
+	first := pool.Acquire()
+	second := pool.Acquire()
+	assert.NotSame(t, first, second, "tabs are spread across browsers")
+	third := pool.Acquire()
+	pool.Release(first)
+	pool.Release(third)
+	assert.Same(t, first, pool.Acquire(), "least loaded browser is chosen")
+
+	// a browser whose connection is gone is skipped
+	(*b.Cancel)()
+	for i := 0; i < 3; i++ {
+		assert.Same(t, a, pool.Acquire())
+	}
+	(*a.Cancel)()
+	assert.Nil(t, pool.Acquire())
+}
+
+func TestBrowserPoolCheckHealth(t *testing.T) {
+	pool := newBrowserPool()
+	healthy, broken := fakeBrowser(), fakeBrowser()
+	pool.add("ws://healthy", healthy)
+	pool.add("ws://broken", broken)
+	pool.probe = func(bro *Browser) error {
+		if bro == broken {
+			return errors.New("timeout")
+		}
+		return nil
+	}
+
+	for i := 1; i < config.BrowserMaxFailures; i++ {
+		pool.CheckHealth()
+		assert.Equal(t, 2, pool.Size())
+	}
+	pool.CheckHealth()
+	assert.Equal(t, 1, pool.Size())
+	assert.Error(t, (*broken.Ctx).Err(), "removed browser is closed")
+	assert.Same(t, healthy, pool.Acquire())
+
+	pool.Close()
+	assert.Equal(t, 0, pool.Size())
+	assert.Error(t, (*healthy.Ctx).Err())
+}
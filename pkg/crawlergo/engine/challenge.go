@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"fmt"
+	"katanacrawlgo/pkg/challenge"
+	"katanacrawlgo/pkg/crawlergo/js"
+	"log"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/ttacon/chalk"
+)
+
+/*
+*
+记录导航响应的状态码和响应头，用于挑战页面识别
+*/
+func (tab *Tab) recordNavResponse(v *network.EventResponseReceived) {
+	headers := map[string]string{}
+	for key, value := range v.Response.Headers {
+		headers[key] = fmt.Sprint(value)
+	}
+	tab.lock.Lock()
+	tab.navStatus = int(v.Response.Status)
+	tab.navHeaders = headers
+	tab.lock.Unlock()
+}
+
+/*
+*
+识别导航的页面是否为验证码或JS挑战页面，结果记录在 Challenge 中
+挑战页面中的请求属于防护系统而不是目标站点，全部丢弃
+*/
+func (tab *Tab) detectChallenge() bool {
+	var html string
+	_ = tab.evaluateResult(js.DocumentHTMLJS, &html)
+
+	tab.lock.Lock()
+	result := challenge.Detect(tab.navStatus, tab.navHeaders, html)
+	if result != nil {
+		tab.Challenge = result
+		tab.ResultList = nil
+	}
+	tab.lock.Unlock()
+
+	if result == nil {
+		return false
+	}
+	log.Println(chalk.Yellow.Color(fmt.Sprintf("识别到%s挑战页面: %s", result.Provider, tab.NavigateReq.URL.String())))
+	return true
+}
@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"katanacrawlgo/pkg/formfill"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMatchFieldText(t *testing.T) {
+	tab := &Tab{config: TabConfig{
+		CustomFormValues:        map[string]string{"default": "admin", "phone": "13800000000"},
+		CustomFormKeywordValues: map[string]string{"coupon": "FREE"},
+	}}
+	f := &FillForm{tab: tab}
+
+	assert.Equal(t, "FREE", f.GetMatchFieldText(formfill.Field{Type: "text", Name: "coupon_code"}), "custom keywords come first")
+	assert.Equal(t, "13800000000", f.GetMatchFieldText(formfill.Field{Type: "tel", Name: "contact"}), "custom values override rules of the same name")
+	assert.Equal(t, "Crawl3r@2024", f.GetMatchFieldText(formfill.Field{Type: "password", Name: "pwd"}))
+	assert.Equal(t, "10001", f.GetMatchFieldText(formfill.Field{Type: "text", Name: "f", Autocomplete: "postal-code"}))
+	assert.Equal(t, "admin", f.GetMatchFieldText(formfill.Field{Type: "textarea"}))
+	assert.Equal(t, "admin", f.GetMatchInputText("loginname"))
+
+	rules, err := formfill.Parse([]byte("rules: [{name: ticket, priority: 200, match: ticket, value: T-1}]"))
+	require.NoError(t, err)
+	tab.config.FormRules = rules
+	assert.Equal(t, "T-1", f.GetMatchInputText("ticket"))
+
+	tab.sinkCanary = "CrawlergoAbCdEfGh"
+	assert.Equal(t, "CrawlergoAbCdEfGh", f.GetMatchInputText("remark"), "sink telemetry fills the canary instead of the default")
+	assert.Equal(t, "CrawlergoAbCdEfGh", f.GetMatchInputText("loginname"), "free text rules are filled with the canary")
+	assert.Equal(t, "T-1", f.GetMatchInputText("ticket"), "rule values are kept")
+	assert.Equal(t, "13800000000", f.GetMatchFieldText(formfill.Field{Type: "tel", Name: "contact"}))
+}
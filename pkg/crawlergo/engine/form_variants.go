@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/js"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/formfill"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/ttacon/chalk"
+)
+
+type formChoices struct {
+	Index   int               `json:"index"`
+	Choices []formfill.Choice `json:"choices"`
+}
+
+/*
+*
+按下拉框和单选组的不同取值多次提交表单，每个表单最多提交 FormVariants 次
+提交期间收集到的请求记录对应的取值，作为不同的变体保留
+*/
+func (tab *Tab) submitFormVariants() {
+	var res string
+	if err := tab.evaluateResult(js.FormChoicesJS, &res); err != nil || res == "" {
+		return
+	}
+	var forms []formChoices
+	if err := json.Unmarshal([]byte(res), &forms); err != nil {
+		log.Println(chalk.Red.Color("error: " + err.Error()))
+		return
+	}
+	defer tab.setFormVariant("")
+
+	for _, form := range forms {
+		for _, variant := range formfill.Variants(form.Choices, tab.config.FormVariants) {
+			values, _ := json.Marshal(variant.Values)
+			tab.setFormVariant(variant.Label)
+			var submitted bool
+			if err := tab.evaluateResult(fmt.Sprintf(js.FormVariantSubmitJS, tab.formFrameName, form.Index, values), &submitted); err != nil || !submitted {
+				break
+			}
+			time.Sleep(config.FormVariantDelay)
+		}
+	}
+}
+
+/*
+*
+设置当前提交的取值，之后收集到的表单请求归属于该取值
+*/
+func (tab *Tab) setFormVariant(label string) {
+	tab.lock.Lock()
+	tab.formVariant = label
+	tab.lock.Unlock()
+}
+
+// 提交表单变体的脚本函数名，出现在由提交触发的请求的发起者调用栈中
+const formVariantSubmitFunction = "crawlergo_form_variant_submit"
+
+/*
+*
+按选项组合提交表单期间，请求所属的取值，不属于当前提交的请求返回空
+*/
+func (tab *Tab) formVariantOf(req model.Request, frameID cdp.FrameID) string {
+	tab.lock.Lock()
+	variant := tab.formVariant
+	tab.lock.Unlock()
+	if variant == "" {
+		return ""
+	}
+	inFormFrame := false
+	if frameID != "" && !tab.IsTopFrame(frameID.String()) {
+		frame := tab.getFrame(frameID)
+		inFormFrame = frame != nil && frame.Name == tab.formFrameName
+	}
+	if !isFormVariantRequest(req, inFormFrame) {
+		return ""
+	}
+	return variant
+}
+
+/*
+*
+表单提交产生的请求：表单提交使用的隐藏frame中的导航，以及由提交脚本发起的导航、XHR、fetch请求
+同一时间内页面中其它脚本发出的请求不属于表单提交
+*/
+func isFormVariantRequest(req model.Request, inFormFrame bool) bool {
+	switch network.ResourceType(req.ResourceType) {
+	case network.ResourceTypeDocument:
+		return inFormFrame || initiatedByFormSubmit(req.Initiator)
+	case network.ResourceTypeXHR, network.ResourceTypeFetch:
+		return initiatedByFormSubmit(req.Initiator)
+	}
+	return false
+}
+
+/*
+*
+请求是否由提交表单变体的脚本发起，包括提交时触发的 submit、click 事件处理函数中同步发出的请求
+*/
+func initiatedByFormSubmit(initiator *model.Initiator) bool {
+	if initiator == nil {
+		return false
+	}
+	for _, frame := range initiator.Stack {
+		if strings.HasPrefix(frame, formVariantSubmitFunction+"@") {
+			return true
+		}
+	}
+	return false
+}
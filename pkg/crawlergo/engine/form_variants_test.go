@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormVariantOf(t *testing.T) {
+	tab := &Tab{TopFrameId: "top", formFrameName: "crawlergoFrame"}
+	tab.frames.Store(cdp.FrameID("form"), &cdp.Frame{ID: "form", ParentID: "top", Name: "crawlergoFrame"})
+	tab.frames.Store(cdp.FrameID("ads"), &cdp.Frame{ID: "ads", ParentID: "top", Name: "ads"})
+	newRequest := func(resourceType network.ResourceType, initiator *model.Initiator) model.Request {
+		url, err := model.GetUrl("https://example.com/search?type=book")
+		require.NoError(t, err)
+		req := model.GetRequest(config.GET, url)
+		req.ResourceType = string(resourceType)
+		req.Initiator = initiator
+		return req
+	}
+	submitInitiator := &model.Initiator{Type: "script", Stack: []string{
+		"onSubmit@https://example.com/app.js:10:5", formVariantSubmitFunction + "@:20:3",
+	}}
+	timerInitiator := &model.Initiator{Type: "script", Stack: []string{"poll@https://example.com/app.js:30:5"}}
+
+	document := newRequest(network.ResourceTypeDocument, nil)
+	assert.Empty(t, tab.formVariantOf(document, "form"), "no variant is being submitted")
+
+	tab.setFormVariant("type=book")
+	// 隐藏frame中的导航和提交脚本发起的请求属于当前取值
+	assert.Equal(t, "type=book", tab.formVariantOf(document, "form"))
+	assert.Equal(t, "type=book", tab.formVariantOf(newRequest(network.ResourceTypeXHR, submitInitiator), "top"))
+	assert.Equal(t, "type=book", tab.formVariantOf(newRequest(network.ResourceTypeFetch, submitInitiator), "top"))
+	// 同一时间内其它frame的导航和其它脚本的请求不属于表单提交
+	assert.Empty(t, tab.formVariantOf(document, "ads"))
+	assert.Empty(t, tab.formVariantOf(document, "top"))
+	assert.Empty(t, tab.formVariantOf(newRequest(network.ResourceTypeXHR, timerInitiator), "top"))
+	assert.Empty(t, tab.formVariantOf(newRequest(network.ResourceTypeFetch, nil), "top"))
+	assert.Empty(t, tab.formVariantOf(newRequest(network.ResourceTypeImage, submitInitiator), "form"))
+}
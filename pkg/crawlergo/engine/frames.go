@@ -0,0 +1,427 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/js"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/ttacon/chalk"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+/*
+*
+页面中的子frame
+同进程的frame在其默认执行上下文中执行JS，跨进程的frame（OOPIF）附加到对应的target后使用单独的会话
+*/
+type frameHandle struct {
+	ID        cdp.FrameID
+	URL       string
+	OOPIF     bool
+	intercept bool // 跨进程frame的会话已开启请求拦截，可以在其中交互
+	ctx       context.Context
+	contextID runtime.ExecutionContextID
+}
+
+/*
+*
+在frame中执行JS，res 不为空时解析返回值
+*/
+func (frame *frameHandle) evaluate(expression string, res interface{}) error {
+	tCtx, cancel := context.WithTimeout(frame.ctx, time.Second*5)
+	defer cancel()
+	params := runtime.Evaluate(expression).WithReturnByValue(true)
+	if frame.contextID != 0 {
+		params = params.WithContextID(frame.contextID)
+	}
+	result, exception, err := params.Do(tCtx)
+	if err != nil {
+		return err
+	}
+	if exception != nil {
+		return exception
+	}
+	if res == nil || result == nil || len(result.Value) == 0 {
+		return nil
+	}
+	return json.Unmarshal(result.Value, res)
+}
+
+/*
+*
+展开frame树，父frame在子frame之前
+*/
+func FlattenFrameTree(tree *page.FrameTree) []*cdp.Frame {
+	if tree == nil || tree.Frame == nil {
+		return nil
+	}
+	frames := []*cdp.Frame{tree.Frame}
+	for _, child := range tree.ChildFrames {
+		frames = append(frames, FlattenFrameTree(child)...)
+	}
+	return frames
+}
+
+/*
+*
+判断frame是否在爬取范围内
+与导航地址同域名或同根域名的frame，以及继承父页面的 about:blank、about:srcdoc
+*/
+func FrameInScope(frameURL string, navURL *model.URL) bool {
+	if frameURL == "about:blank" || frameURL == "about:srcdoc" {
+		return true
+	}
+	url, err := model.GetUrl(frameURL)
+	if err != nil || (url.Scheme != "http" && url.Scheme != "https") {
+		return false
+	}
+	if url.Hostname() == navURL.Hostname() {
+		return true
+	}
+	return url.RootDomain() != "" && url.RootDomain() == navURL.RootDomain()
+}
+
+/*
+*
+记录frame的默认执行上下文
+*/
+func (tab *Tab) storeFrameContext(description *runtime.ExecutionContextDescription) {
+	var auxData struct {
+		IsDefault bool        `json:"isDefault"`
+		FrameID   cdp.FrameID `json:"frameId"`
+	}
+	if json.Unmarshal(description.AuxData, &auxData) != nil || !auxData.IsDefault || auxData.FrameID == "" {
+		return
+	}
+	tab.frameContexts.Store(auxData.FrameID, description.ID)
+}
+
+/*
+*
+删除已销毁的执行上下文，contextID 为0时全部删除
+*/
+func (tab *Tab) deleteFrameContext(contextID runtime.ExecutionContextID) {
+	tab.frameContexts.Range(func(key, value interface{}) bool {
+		if contextID == 0 || value.(runtime.ExecutionContextID) == contextID {
+			tab.frameContexts.Delete(key)
+		}
+		return true
+	})
+}
+
+/*
+*
+重新获取页面的frame树
+*/
+func (tab *Tab) refreshFrames() []*cdp.Frame {
+	ctx := tab.GetExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+	tree, err := page.GetFrameTree().Do(tCtx)
+	if err != nil {
+		return nil
+	}
+	frames := FlattenFrameTree(tree)
+	for _, frame := range frames {
+		tab.frames.Store(frame.ID, frame)
+	}
+	return frames
+}
+
+func (tab *Tab) getFrame(frameID cdp.FrameID) *cdp.Frame {
+	if frameID == "" {
+		return nil
+	}
+	if value, ok := tab.frames.Load(frameID); ok {
+		return value.(*cdp.Frame)
+	}
+	tab.refreshFrames()
+	if value, ok := tab.frames.Load(frameID); ok {
+		return value.(*cdp.Frame)
+	}
+	return nil
+}
+
+/*
+*
+请求所属的子frame地址，顶层页面返回空
+表单提交使用的隐藏frame不作为来源，请求归属于表单所在的frame
+*/
+func (tab *Tab) frameLabel(frameID cdp.FrameID) string {
+	frame := tab.getFrame(frameID)
+	for frame != nil && frame.Name == tab.formFrameName {
+		frame = tab.getFrame(frame.ParentID)
+	}
+	if frame == nil || frame.ParentID == "" || tab.IsTopFrame(frame.ID.String()) {
+		return ""
+	}
+	return frame.URL
+}
+
+/*
+*
+执行上下文所属的子frame地址
+*/
+func (tab *Tab) contextFrameLabel(contextID runtime.ExecutionContextID) string {
+	label := ""
+	tab.frameContexts.Range(func(key, value interface{}) bool {
+		if value.(runtime.ExecutionContextID) == contextID {
+			label = tab.frameLabel(key.(cdp.FrameID))
+			return false
+		}
+		return true
+	})
+	return label
+}
+
+/*
+*
+标记子frame中发出的请求，子frame自身的导航请求来源为 Frame，此时返回 true
+*/
+func (tab *Tab) labelFrameRequest(req *model.Request, v *fetch.EventRequestPaused) bool {
+	frame := tab.getFrame(v.FrameID)
+	if frame == nil || frame.ParentID == "" {
+		return false
+	}
+	if v.ResourceType == network.ResourceTypeDocument && frame.Name != tab.formFrameName {
+		req.Frame = req.URL.String()
+		return true
+	}
+	req.Frame = tab.frameLabel(v.FrameID)
+	return false
+}
+
+/*
+*
+列出爬取范围内的子frame，最多 FrameMaxCount 个
+*/
+func (tab *Tab) listFrames() []*frameHandle {
+	var handles []*frameHandle
+	for _, frame := range tab.refreshFrames() {
+		if frame.ParentID == "" || frame.Name == tab.formFrameName || !FrameInScope(frame.URL, tab.NavigateReq.URL) {
+			continue
+		}
+		contextID, ok := tab.frameContexts.Load(frame.ID)
+		if !ok {
+			continue
+		}
+		handles = append(handles, &frameHandle{
+			ID:        frame.ID,
+			URL:       frame.URL,
+			ctx:       tab.GetExecutor(),
+			contextID: contextID.(runtime.ExecutionContextID),
+		})
+	}
+	tab.frameTargets.Range(func(key, value interface{}) bool {
+		if len(handles) >= config.FrameMaxCount {
+			return false
+		}
+		if handle := tab.attachFrameTarget(value.(*target.Info)); handle != nil {
+			handles = append(handles, handle)
+		}
+		return true
+	})
+	if len(handles) > config.FrameMaxCount {
+		handles = handles[:config.FrameMaxCount]
+	}
+	return handles
+}
+
+/*
+*
+跨进程frame的连接，同一个target只附加一次
+*/
+type frameAttach struct {
+	once   sync.Once
+	handle *frameHandle
+}
+
+/*
+*
+附加到跨进程的frame，frame的target出现时即调用，并发调用时等待同一次附加完成
+*/
+func (tab *Tab) attachFrameTarget(info *target.Info) *frameHandle {
+	value, _ := tab.frameSessions.LoadOrStore(info.TargetID, &frameAttach{})
+	attach := value.(*frameAttach)
+	attach.once.Do(func() {
+		attach.handle = tab.connectFrameTarget(info)
+	})
+	return attach.handle
+}
+
+/*
+*
+连接跨进程的frame，注册回调并拦截其中发出的请求
+跨进程frame使用单独的会话，需要在该会话中开启请求拦截，与当前标签页使用相同的过滤和安全模式
+frame在启动时等待调试器的情况下，开启请求拦截后再恢复执行
+*/
+func (tab *Tab) connectFrameTarget(info *target.Info) *frameHandle {
+	if info.URL != "" && !FrameInScope(info.URL, tab.NavigateReq.URL) {
+		return nil
+	}
+
+	fCtx, cancel := chromedp.NewContext(*tab.Ctx, chromedp.WithTargetID(info.TargetID))
+	tab.lock.Lock()
+	tab.frameCancels = append(tab.frameCancels, cancel)
+	tab.lock.Unlock()
+	if err := chromedp.Run(fCtx); err != nil {
+		log.Println(chalk.Red.Color("error: 附加跨进程frame失败, " + err.Error()))
+		return nil
+	}
+	ctx := cdp.WithExecutor(fCtx, chromedp.FromContext(fCtx).Target)
+	tCtx, tCancel := context.WithTimeout(ctx, time.Second*2)
+	defer tCancel()
+	defer func() {
+		_ = runtime.RunIfWaitingForDebugger().Do(tCtx)
+	}()
+	tree, err := page.GetFrameTree().Do(tCtx)
+	if err != nil {
+		return nil
+	}
+	// 刚出现的frame可能还没有提交导航
+	frameURL := tree.Frame.URL
+	if frameURL == "" {
+		frameURL = info.URL
+	}
+	if !FrameInScope(frameURL, tab.NavigateReq.URL) {
+		return nil
+	}
+	_ = runtime.AddBinding("addLink").Do(tCtx)
+	_ = runtime.AddBinding("Test").Do(tCtx)
+	if tab.config.Identity != nil {
+		_ = identityEmulation(tab.config.Identity).Do(tCtx)
+	}
+
+	frame := &frameHandle{ID: tree.Frame.ID, URL: frameURL, OOPIF: true, ctx: ctx}
+	chromedp.ListenTarget(fCtx, func(v interface{}) {
+		switch v := v.(type) {
+		case *network.EventRequestWillBeSent:
+			if v.Initiator != nil {
+				tab.recordInitiator(v.RequestID.String(), ConvertInitiator(v.Initiator))
+			}
+		case *fetch.EventRequestPaused:
+			tab.WG.Add(1)
+			go func() {
+				defer tab.WG.Done()
+				tab.interceptRequest(ctx, v, frame)
+			}()
+		case *runtime.EventBindingCalled:
+			go tab.handleFrameBinding(frame, v)
+		}
+	})
+	// 无法拦截请求时不在该frame中交互，只收集链接
+	if err := fetch.Enable().Do(tCtx); err != nil {
+		log.Println(chalk.Red.Color("error: 跨进程frame开启请求拦截失败, " + err.Error()))
+	} else {
+		frame.intercept = true
+	}
+	return frame
+}
+
+/*
+*
+断开与跨进程frame的会话
+*/
+func (tab *Tab) closeFrameSessions() {
+	tab.lock.Lock()
+	cancels := tab.frameCancels
+	tab.frameCancels = nil
+	tab.lock.Unlock()
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
+
+/*
+*
+处理跨进程frame中的回调
+*/
+func (tab *Tab) handleFrameBinding(frame *frameHandle, event *runtime.EventBindingCalled) {
+	var payload bindingCallPayload
+	_ = json.Unmarshal([]byte(event.Payload), &payload)
+	if payload.Name == "addLink" && len(payload.Args) > 1 {
+		tab.addFrameResultUrl(frame, payload.Args[0], payload.Args[1])
+	}
+	_ = frame.evaluate(fmt.Sprintf(js.DeliverResultJS, payload.Name, payload.Seq, "s"), nil)
+}
+
+/*
+*
+添加frame中收集到的URL，相对地址按frame自身的地址解析
+*/
+func (tab *Tab) addFrameResultUrl(frame *frameHandle, _url string, source string) {
+	if frameURL, err := model.GetUrl(frame.URL); err == nil {
+		if url, err := model.GetUrl(_url, *frameURL); err == nil {
+			_url = url.String()
+		}
+	}
+	tab.addResultUrl(config.GET, _url, source, model.Options{}, frame.URL)
+}
+
+/*
+*
+在爬取范围内的子frame中执行安全模式、填充并提交表单、触发事件
+*/
+func (tab *Tab) crawlFrames() {
+	interval := tab.config.EventTriggerInterval.Seconds() * 1000
+	for _, frame := range tab.listFrames() {
+		if frame.OOPIF && !frame.intercept {
+			continue
+		}
+		if frame.OOPIF {
+			// 跨进程frame没有注入初始化脚本，补充身份配置、事件触发依赖的函数和回调
+			if tab.config.Identity != nil {
+				_ = frame.evaluate(tab.config.Identity.InitScript(), nil)
+			}
+			_ = frame.evaluate(js.TabInitJS, nil)
+		}
+		tab.applySafeModeWith(frame.URL, frame.evaluate)
+		f := FillForm{tab: tab}
+		f.fillWith(frame.evaluate, false)
+		// 顶层页面中的隐藏frame对跨进程frame不可见，在子frame中新建提交使用的frame
+		_ = frame.evaluate(fmt.Sprintf(js.NewFrameTemplate, tab.formFrameName, tab.formFrameName), nil)
+		_ = frame.evaluate(fmt.Sprintf(js.FormSubmitJS, tab.formFrameName, false), nil)
+		_ = frame.evaluate(fmt.Sprintf(js.TriggerInlineEventJS, interval), nil)
+		_ = frame.evaluate(fmt.Sprintf(js.TriggerDom2EventJS, interval), nil)
+		_ = frame.evaluate(fmt.Sprintf(js.TriggerJavascriptProtocol, interval, interval), nil)
+	}
+}
+
+/*
+*
+收集爬取范围内子frame中的链接和注释中的链接
+*/
+func (tab *Tab) collectFrameLinks() {
+	urlRegex := regexp.MustCompile(config.URLRegex)
+	for _, frame := range tab.listFrames() {
+		var result struct {
+			Links    []string `json:"links"`
+			Comments []string `json:"comments"`
+		}
+		if err := frame.evaluate(js.FrameCollectJS, &result); err != nil {
+			continue
+		}
+		for _, link := range result.Links {
+			tab.addFrameResultUrl(frame, link, config.FromDOM)
+		}
+		for _, comment := range result.Comments {
+			for _, url := range urlRegex.FindAllString(comment, -1) {
+				tab.addFrameResultUrl(frame, url, config.FromComment)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"katanacrawlgo/pkg/crawlergo/model"
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/target"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlattenFrameTree(t *testing.T) {
+	tree := &page.FrameTree{
+		Frame: &cdp.Frame{ID: "top"},
+		ChildFrames: []*page.FrameTree{
+			{
+				Frame:       &cdp.Frame{ID: "a", ParentID: "top"},
+				ChildFrames: []*page.FrameTree{{Frame: &cdp.Frame{ID: "a1", ParentID: "a"}}},
+			},
+			{Frame: &cdp.Frame{ID: "b", ParentID: "top"}},
+		},
+	}
+	var ids []cdp.FrameID
+	for _, frame := range FlattenFrameTree(tree) {
+		ids = append(ids, frame.ID)
+	}
+	assert.Equal(t, []cdp.FrameID{"top", "a", "a1", "b"}, ids)
+	assert.Nil(t, FlattenFrameTree(nil))
+}
+
+func TestFrameInScope(t *testing.T) {
+	navURL, err := model.GetUrl("https://www.example.com/index.html")
+	require.NoError(t, err)
+
+	assert.True(t, FrameInScope("https://www.example.com/frame.html", navURL))
+	assert.True(t, FrameInScope("http://static.example.com/widget", navURL))
+	assert.True(t, FrameInScope("about:blank", navURL))
+	assert.True(t, FrameInScope("about:srcdoc", navURL))
+	assert.False(t, FrameInScope("https://ads.tracker.net/frame", navURL))
+	assert.False(t, FrameInScope("data:text/html,<p>x</p>", navURL))
+	assert.False(t, FrameInScope("chrome-error://chromewebdata/", navURL))
+}
+
+func TestAttachFrameTargetOutOfScope(t *testing.T) {
+	navURL, err := model.GetUrl("https://www.example.com/index.html")
+	require.NoError(t, err)
+	tab := &Tab{NavigateReq: model.Request{URL: navURL}}
+
+	// 范围外的frame不附加，结果被记录，之后列出frame时不再尝试
+	info := &target.Info{TargetID: "ads", Type: "iframe", URL: "https://ads.tracker.net/frame"}
+	assert.Nil(t, tab.attachFrameTarget(info))
+	value, ok := tab.frameSessions.Load(info.TargetID)
+	require.True(t, ok)
+	assert.Nil(t, value.(*frameAttach).handle)
+	assert.Nil(t, tab.attachFrameTarget(info))
+}
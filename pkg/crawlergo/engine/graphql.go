@@ -0,0 +1,21 @@
+package engine
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/graphql"
+)
+
+/*
+*
+JS中 Apollo、urql、graphql-request 等客户端配置的GraphQL端点
+只知道地址没有请求体，使用 __typename 查询作为代表请求
+*/
+func (tab *Tab) addGraphQLEndpoints(content string) {
+	for _, endpoint := range graphql.DetectInJS(content) {
+		tab.AddResultUrlWithOptions(config.POST, endpoint, config.FromGraphQL, model.Options{
+			Headers:  map[string]interface{}{"Content-Type": graphql.ContentType},
+			PostData: graphql.ProbeBody,
+		})
+	}
+}
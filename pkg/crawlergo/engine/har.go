@@ -0,0 +1,468 @@
+package engine
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+// HAR 1.2 格式定义 http://www.softwareishard.com/blog/har-12-spec/
+type Har struct {
+	Log *HarLog `json:"log"`
+}
+
+type HarLog struct {
+	Version string      `json:"version"`
+	Creator HarCreator  `json:"creator"`
+	Pages   []*HarPage  `json:"pages"`
+	Entries []*HarEntry `json:"entries"`
+}
+
+type HarCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HarPage struct {
+	StartedDateTime string         `json:"startedDateTime"`
+	ID              string         `json:"id"`
+	Title           string         `json:"title"`
+	PageTimings     HarPageTimings `json:"pageTimings"`
+}
+
+type HarPageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+type HarEntry struct {
+	Pageref         string      `json:"pageref,omitempty"`
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HarRequest  `json:"request"`
+	Response        HarResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HarTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Comment         string      `json:"comment,omitempty"`
+	ResourceType    string      `json:"_resourceType,omitempty"`
+	Blocked         bool        `json:"_blocked,omitempty"`
+	BlockedReason   string      `json:"_blockedReason,omitempty"`
+}
+
+type HarRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HarNameValue `json:"cookies"`
+	Headers     []HarNameValue `json:"headers"`
+	QueryString []HarNameValue `json:"queryString"`
+	PostData    *HarPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HarPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type HarResponse struct {
+	Status      int64          `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HarNameValue `json:"cookies"`
+	Headers     []HarNameValue `json:"headers"`
+	Content     HarContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HarContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type HarNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HarTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+type harPending struct {
+	entry     *HarEntry
+	startTime float64 // 请求发出的单调时间 单位秒
+	timing    *network.ResourceTiming
+}
+
+/*
+*
+记录单个Tab的网络事件，生成HAR数据
+*/
+type HarRecorder struct {
+	lock      sync.Mutex
+	page      *HarPage
+	startTime float64
+	entries   []*HarEntry
+	pending   map[network.RequestID]*harPending
+	byID      map[network.RequestID]*HarEntry // 重定向时同一ID对应多个条目，记录最后一跳
+	blocked   map[network.RequestID]string
+}
+
+func NewHarRecorder(pageID string, title string) *HarRecorder {
+	return &HarRecorder{
+		page: &HarPage{
+			ID:          pageID,
+			Title:       title,
+			PageTimings: HarPageTimings{OnContentLoad: -1, OnLoad: -1},
+		},
+		pending: map[network.RequestID]*harPending{},
+		byID:    map[network.RequestID]*HarEntry{},
+		blocked: map[network.RequestID]string{},
+	}
+}
+
+/*
+*
+请求发出，若携带重定向响应则先结束上一跳
+*/
+func (r *HarRecorder) OnRequestWillBeSent(v *network.EventRequestWillBeSent) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := monotonicSeconds(v.Timestamp)
+	if v.RedirectResponse != nil {
+		if p, ok := r.pending[v.RequestID]; ok {
+			p.setResponse(v.RedirectResponse)
+			p.entry.Response.RedirectURL = v.Request.URL
+			p.finish(now, int64(v.RedirectResponse.EncodedDataLength))
+			delete(r.pending, v.RequestID)
+		}
+	}
+
+	started := time.Now()
+	if v.WallTime != nil {
+		started = v.WallTime.Time()
+	}
+	if r.page.StartedDateTime == "" {
+		r.page.StartedDateTime = formatHarTime(started)
+		r.startTime = now
+	}
+	entry := &HarEntry{
+		Pageref:         r.page.ID,
+		StartedDateTime: formatHarTime(started),
+		Request:         buildHarRequest(v.Request),
+		Response:        HarResponse{Cookies: []HarNameValue{}, Headers: []HarNameValue{}, HeadersSize: -1, BodySize: -1},
+		Timings:         HarTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+		ResourceType:    string(v.Type),
+	}
+	r.entries = append(r.entries, entry)
+	r.byID[v.RequestID] = entry
+	r.pending[v.RequestID] = &harPending{entry: entry, startTime: now}
+}
+
+func (r *HarRecorder) OnResponseReceived(v *network.EventResponseReceived) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if p, ok := r.pending[v.RequestID]; ok {
+		p.setResponse(v.Response)
+	}
+}
+
+func (r *HarRecorder) OnLoadingFinished(v *network.EventLoadingFinished) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if p, ok := r.pending[v.RequestID]; ok {
+		p.finish(monotonicSeconds(v.Timestamp), int64(v.EncodedDataLength))
+		delete(r.pending, v.RequestID)
+	}
+}
+
+func (r *HarRecorder) OnLoadingFailed(v *network.EventLoadingFailed) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if p, ok := r.pending[v.RequestID]; ok {
+		p.entry.Comment = v.ErrorText
+		if v.BlockedReason != "" {
+			p.entry.Blocked = true
+			p.entry.BlockedReason = string(v.BlockedReason)
+		}
+		p.finish(monotonicSeconds(v.Timestamp), 0)
+		delete(r.pending, v.RequestID)
+	}
+}
+
+/*
+*
+页面 DOMContentLoaded 与 load 事件的时间，相对于第一个请求
+*/
+func (r *HarRecorder) OnPageEvent(name string, timestamp *cdp.MonotonicTime) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.page.StartedDateTime == "" {
+		return
+	}
+	elapsed := (monotonicSeconds(timestamp) - r.startTime) * 1000
+	switch name {
+	case "DOMContentLoaded":
+		r.page.PageTimings.OnContentLoad = elapsed
+	case "load":
+		r.page.PageTimings.OnLoad = elapsed
+	}
+}
+
+/*
+*
+标记被爬虫主动拦截的请求，如静态资源
+拦截可能早于 requestWillBeSent 事件到达，生成HAR时统一处理
+*/
+func (r *HarRecorder) MarkBlocked(requestID network.RequestID, reason string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.blocked[requestID] = reason
+}
+
+/*
+*
+生成当前记录的HAR数据，未完成的请求同样输出
+*/
+func (r *HarRecorder) Log() *HarLog {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for requestID, reason := range r.blocked {
+		if entry, ok := r.byID[requestID]; ok {
+			entry.Blocked = true
+			entry.BlockedReason = reason
+		}
+	}
+	harLog := NewHarLog()
+	if r.page.StartedDateTime != "" {
+		harLog.Pages = append(harLog.Pages, r.page)
+	}
+	harLog.Entries = append(harLog.Entries, r.entries...)
+	return harLog
+}
+
+/*
+*
+新建空的HAR日志
+*/
+func NewHarLog() *HarLog {
+	return &HarLog{
+		Version: "1.2",
+		Creator: HarCreator{Name: "crawlergo", Version: "1.0"},
+		Pages:   []*HarPage{},
+		Entries: []*HarEntry{},
+	}
+}
+
+/*
+*
+合并多个HAR日志，用于按目标输出
+*/
+func MergeHarLog(dst *HarLog, src *HarLog) {
+	dst.Pages = append(dst.Pages, src.Pages...)
+	dst.Entries = append(dst.Entries, src.Entries...)
+}
+
+func (p *harPending) setResponse(resp *network.Response) {
+	entry := p.entry
+	entry.Response.Status = resp.Status
+	entry.Response.StatusText = resp.StatusText
+	entry.Response.HTTPVersion = harHTTPVersion(resp.Protocol)
+	entry.Response.Headers = harHeaders(resp.Headers)
+	entry.Response.Cookies = harSetCookies(resp.Headers)
+	entry.Response.Content = HarContent{MimeType: resp.MimeType}
+	if location, ok := harHeaderValue(resp.Headers, "Location"); ok {
+		entry.Response.RedirectURL = location
+	}
+	// 实际发送的请求头比 requestWillBeSent 中的更完整
+	if len(resp.RequestHeaders) > 0 {
+		entry.Request.Headers = harHeaders(resp.RequestHeaders)
+		entry.Request.Cookies = harRequestCookies(resp.RequestHeaders)
+	}
+	entry.Request.HTTPVersion = entry.Response.HTTPVersion
+	entry.ServerIPAddress = strings.Trim(resp.RemoteIPAddress, "[]")
+	p.timing = resp.Timing
+}
+
+func (p *harPending) finish(endTime float64, encodedLength int64) {
+	entry := p.entry
+	if encodedLength > 0 {
+		entry.Response.BodySize = encodedLength
+		entry.Response.Content.Size = encodedLength
+	}
+	entry.Timings, entry.Time = BuildHarTimings(p.timing, p.startTime, endTime)
+}
+
+/*
+*
+根据 ResourceTiming 计算 HAR 的各阶段耗时，单位毫秒，不适用的阶段为 -1
+没有 timing 信息时（如被拦截的请求），全部耗时计入 wait
+*/
+func BuildHarTimings(timing *network.ResourceTiming, startTime float64, endTime float64) (HarTimings, float64) {
+	timings := HarTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+	if timing == nil {
+		if endTime > startTime && startTime > 0 {
+			timings.Wait = (endTime - startTime) * 1000
+		}
+		return timings, timings.Wait
+	}
+	for _, start := range []float64{timing.DNSStart, timing.ConnectStart, timing.SendStart} {
+		if start >= 0 {
+			timings.Blocked = start
+			break
+		}
+	}
+	if timing.DNSStart >= 0 {
+		timings.DNS = timing.DNSEnd - timing.DNSStart
+	}
+	if timing.ConnectStart >= 0 {
+		timings.Connect = timing.ConnectEnd - timing.ConnectStart
+	}
+	if timing.SslStart >= 0 {
+		timings.SSL = timing.SslEnd - timing.SslStart
+	}
+	timings.Send = timing.SendEnd - timing.SendStart
+	timings.Wait = timing.ReceiveHeadersEnd - timing.SendEnd
+	if endTime > 0 {
+		timings.Receive = (endTime-timing.RequestTime)*1000 - timing.ReceiveHeadersEnd
+	}
+	if timings.Receive < 0 {
+		timings.Receive = 0
+	}
+	total := 0.0
+	for _, t := range []float64{timings.Blocked, timings.DNS, timings.Connect, timings.Send, timings.Wait, timings.Receive} {
+		if t > 0 {
+			total += t
+		}
+	}
+	return timings, total
+}
+
+func buildHarRequest(req *network.Request) HarRequest {
+	harReq := HarRequest{
+		Method:      req.Method,
+		URL:         req.URL + req.URLFragment,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     harRequestCookies(req.Headers),
+		Headers:     harHeaders(req.Headers),
+		QueryString: []HarNameValue{},
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	if u, err := url.Parse(req.URL); err == nil {
+		for key, values := range u.Query() {
+			for _, value := range values {
+				harReq.QueryString = append(harReq.QueryString, HarNameValue{Name: key, Value: value})
+			}
+		}
+		sortHarNameValues(harReq.QueryString)
+	}
+	if req.HasPostData || req.PostData != "" {
+		mimeType, _ := harHeaderValue(req.Headers, "Content-Type")
+		harReq.PostData = &HarPostData{MimeType: mimeType, Text: req.PostData}
+		harReq.BodySize = int64(len(req.PostData))
+	}
+	return harReq
+}
+
+func harHeaders(headers network.Headers) []HarNameValue {
+	result := []HarNameValue{}
+	for key, value := range headers {
+		// 同名的多个头部以换行分隔
+		for _, v := range strings.Split(fmt.Sprint(value), "\n") {
+			result = append(result, HarNameValue{Name: key, Value: v})
+		}
+	}
+	sortHarNameValues(result)
+	return result
+}
+
+func harHeaderValue(headers network.Headers, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return fmt.Sprint(value), true
+		}
+	}
+	return "", false
+}
+
+func harRequestCookies(headers network.Headers) []HarNameValue {
+	result := []HarNameValue{}
+	cookie, ok := harHeaderValue(headers, "Cookie")
+	if !ok {
+		return result
+	}
+	for _, pair := range strings.Split(cookie, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if name != "" {
+			result = append(result, HarNameValue{Name: name, Value: value})
+		}
+	}
+	return result
+}
+
+func harSetCookies(headers network.Headers) []HarNameValue {
+	result := []HarNameValue{}
+	setCookie, ok := harHeaderValue(headers, "Set-Cookie")
+	if !ok {
+		return result
+	}
+	for _, line := range strings.Split(setCookie, "\n") {
+		pair, _, _ := strings.Cut(line, ";")
+		name, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if name != "" {
+			result = append(result, HarNameValue{Name: name, Value: value})
+		}
+	}
+	return result
+}
+
+func harHTTPVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "":
+		return "HTTP/1.1"
+	case "h2":
+		return "HTTP/2"
+	case "h3", "h3-29", "quic":
+		return "HTTP/3"
+	default:
+		return strings.ToUpper(protocol)
+	}
+}
+
+func sortHarNameValues(values []HarNameValue) {
+	sort.SliceStable(values, func(i, j int) bool {
+		if values[i].Name == values[j].Name {
+			return values[i].Value < values[j].Value
+		}
+		return values[i].Name < values[j].Name
+	})
+}
+
+func monotonicSeconds(t *cdp.MonotonicTime) float64 {
+	if t == nil {
+		return 0
+	}
+	return t.Time().Sub(*cdp.MonotonicTimeEpoch).Seconds()
+}
+
+func formatHarTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
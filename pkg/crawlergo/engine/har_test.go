@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func monotonicAt(seconds float64) *cdp.MonotonicTime {
+	t := cdp.MonotonicTime(cdp.MonotonicTimeEpoch.Add(time.Duration(seconds * float64(time.Second))))
+	return &t
+}
+
+func TestHarRecorderRedirectAndBlocked(t *testing.T) {
+	recorder := NewHarRecorder("page_1", "http://test.com/")
+	recorder.OnRequestWillBeSent(&network.EventRequestWillBeSent{
+		RequestID: "1",
+		Request:   &network.Request{Method: "GET", URL: "http://test.com/login?next=/home", Headers: network.Headers{"Cookie": "a=1; b=2"}},
+		Timestamp: monotonicAt(10),
+		Type:      network.ResourceTypeDocument,
+	})
+	recorder.OnRequestWillBeSent(&network.EventRequestWillBeSent{
+		RequestID: "1",
+		Request:   &network.Request{Method: "GET", URL: "http://test.com/home"},
+		Timestamp: monotonicAt(10.2),
+		RedirectResponse: &network.Response{
+			Status:  302,
+			Headers: network.Headers{"Location": "/home", "Set-Cookie": "sid=abc; Path=/"},
+		},
+		Type: network.ResourceTypeDocument,
+	})
+	recorder.OnResponseReceived(&network.EventResponseReceived{
+		RequestID: "1",
+		Response:  &network.Response{Status: 200, StatusText: "OK", MimeType: "text/html", Protocol: "h2"},
+	})
+	recorder.OnLoadingFinished(&network.EventLoadingFinished{RequestID: "1", Timestamp: monotonicAt(10.5), EncodedDataLength: 512})
+
+	recorder.MarkBlocked("2", "static resource")
+	recorder.OnRequestWillBeSent(&network.EventRequestWillBeSent{
+		RequestID: "2",
+		Request:   &network.Request{Method: "GET", URL: "http://test.com/logo.png"},
+		Timestamp: monotonicAt(10.3),
+		Type:      network.ResourceTypeImage,
+	})
+	recorder.OnLoadingFailed(&network.EventLoadingFailed{RequestID: "2", Timestamp: monotonicAt(10.3), ErrorText: "net::ERR_BLOCKED_BY_CLIENT"})
+
+	harLog := recorder.Log()
+	assert.Equal(t, "1.2", harLog.Version)
+	assert.Len(t, harLog.Pages, 1)
+	assert.Len(t, harLog.Entries, 3)
+
+	first := harLog.Entries[0]
+	assert.Equal(t, int64(302), first.Response.Status)
+	assert.Equal(t, "http://test.com/home", first.Response.RedirectURL)
+	assert.Equal(t, []HarNameValue{{Name: "next", Value: "/home"}}, first.Request.QueryString)
+	assert.Len(t, first.Request.Cookies, 2)
+	assert.Equal(t, []HarNameValue{{Name: "sid", Value: "abc"}}, first.Response.Cookies)
+	assert.InDelta(t, 200, first.Time, 1)
+
+	second := harLog.Entries[1]
+	assert.Equal(t, int64(200), second.Response.Status)
+	assert.Equal(t, "HTTP/2", second.Response.HTTPVersion)
+	assert.Equal(t, int64(512), second.Response.BodySize)
+	assert.False(t, second.Blocked)
+
+	blocked := harLog.Entries[2]
+	assert.True(t, blocked.Blocked)
+	assert.Equal(t, "static resource", blocked.BlockedReason)
+	assert.Equal(t, "net::ERR_BLOCKED_BY_CLIENT", blocked.Comment)
+}
+
+func TestBuildHarTimings(t *testing.T) {
+	timing := &network.ResourceTiming{
+		RequestTime:       100,
+		DNSStart:          1,
+		DNSEnd:            3,
+		ConnectStart:      3,
+		ConnectEnd:        10,
+		SslStart:          5,
+		SslEnd:            10,
+		SendStart:         10,
+		SendEnd:           11,
+		ReceiveHeadersEnd: 50,
+	}
+	timings, total := BuildHarTimings(timing, 100, 100.06)
+	assert.Equal(t, float64(1), timings.Blocked)
+	assert.Equal(t, float64(2), timings.DNS)
+	assert.Equal(t, float64(7), timings.Connect)
+	assert.Equal(t, float64(5), timings.SSL)
+	assert.Equal(t, float64(1), timings.Send)
+	assert.Equal(t, float64(39), timings.Wait)
+	assert.InDelta(t, 10, timings.Receive, 0.001)
+	assert.InDelta(t, 60, total, 0.001)
+
+	// 复用连接时没有 dns connect 阶段
+	timing = &network.ResourceTiming{RequestTime: 100, DNSStart: -1, DNSEnd: -1, ConnectStart: -1, ConnectEnd: -1, SslStart: -1, SslEnd: -1, SendStart: 2, SendEnd: 3, ReceiveHeadersEnd: 20}
+	timings, _ = BuildHarTimings(timing, 100, 100.02)
+	assert.Equal(t, float64(2), timings.Blocked)
+	assert.Equal(t, float64(-1), timings.DNS)
+	assert.Equal(t, float64(-1), timings.Connect)
+	assert.Equal(t, float64(-1), timings.SSL)
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"context"
+	"katanacrawlgo/pkg/identity"
+	"strings"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+/*
+*
+将身份配置文件的请求头补充到自定义请求头中，用户设置的同名请求头优先
+自定义了 User-Agent 时返回使用该UA的配置文件副本，使 navigator.userAgent 与请求头保持一致
+Sec-CH-UA 系列请求头由浏览器根据 UserAgentMetadata 生成，这里不重复设置
+*/
+func MergeIdentityHeaders(headers map[string]interface{}, profile *identity.Profile) *identity.Profile {
+	if profile == nil {
+		return nil
+	}
+	merged := *profile
+	for _, name := range []string{"User-Agent", "Accept-Language"} {
+		found := false
+		for key, value := range headers {
+			if !strings.EqualFold(key, name) {
+				continue
+			}
+			found = true
+			if ua, ok := value.(string); ok && name == "User-Agent" && ua != "" {
+				merged.UserAgent = ua
+			}
+		}
+		if !found {
+			headers[name] = profile.Headers()[name]
+		}
+	}
+	return &merged
+}
+
+/*
+*
+身份配置文件对应的模拟设置：UA与客户端提示、窗口大小、语言和时区
+*/
+func identityEmulation(profile *identity.Profile) chromedp.Tasks {
+	metadata := &emulation.UserAgentMetadata{
+		Platform:        profile.PlatformName,
+		PlatformVersion: profile.PlatformVersion,
+		Architecture:    profile.Architecture,
+		Model:           profile.Model,
+		Mobile:          profile.Mobile,
+		Bitness:         profile.Bitness,
+	}
+	for _, brand := range profile.Brands {
+		metadata.Brands = append(metadata.Brands, &emulation.UserAgentBrandVersion{Brand: brand.Name, Version: brand.Version})
+	}
+	for _, brand := range profile.FullVersionList() {
+		metadata.FullVersionList = append(metadata.FullVersionList, &emulation.UserAgentBrandVersion{Brand: brand.Name, Version: brand.Version})
+	}
+	viewport := profile.Viewport
+	return chromedp.Tasks{
+		emulation.SetUserAgentOverride(profile.UserAgent).
+			WithAcceptLanguage(profile.AcceptLanguage).
+			WithPlatform(profile.Platform).
+			WithUserAgentMetadata(metadata),
+		emulation.SetDeviceMetricsOverride(int64(viewport.Width), int64(viewport.Height), viewport.DeviceScaleFactor, profile.Mobile).
+			WithScreenWidth(int64(viewport.Width)).
+			WithScreenHeight(int64(viewport.Height)),
+		// 部分浏览器版本不支持，忽略错误
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			_ = emulation.SetLocaleOverride().WithLocale(profile.Locale).Do(ctx)
+			_ = emulation.SetTimezoneOverride(profile.Timezone).Do(ctx)
+			return nil
+		}),
+	}
+}
+
+/*
+*
+应用当前标签页的身份配置，需要在导航之前执行
+*/
+func (tab *Tab) applyIdentity() chromedp.Action {
+	profile := tab.config.Identity
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if profile == nil {
+			return nil
+		}
+		if err := identityEmulation(profile).Do(ctx); err != nil {
+			return err
+		}
+		_, err := page.AddScriptToEvaluateOnNewDocument(profile.InitScript()).Do(ctx)
+		return err
+	})
+}
@@ -0,0 +1,26 @@
+package engine
+
+import (
+	"katanacrawlgo/pkg/identity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeIdentityHeaders(t *testing.T) {
+	profile, _ := identity.Get("chrome-linux")
+
+	headers := map[string]interface{}{"Cookie": "a=1"}
+	merged := MergeIdentityHeaders(headers, profile)
+	assert.Equal(t, profile.UserAgent, headers["User-Agent"])
+	assert.Equal(t, "en-GB,en;q=0.9", headers["Accept-Language"])
+	assert.Equal(t, profile.UserAgent, merged.UserAgent)
+
+	headers = map[string]interface{}{"user-agent": "custom/1.0", "Accept-Language": "fr"}
+	merged = MergeIdentityHeaders(headers, profile)
+	assert.Len(t, headers, 2)
+	assert.Equal(t, "custom/1.0", merged.UserAgent)
+	assert.NotEqual(t, "custom/1.0", profile.UserAgent)
+
+	assert.Nil(t, MergeIdentityHeaders(headers, nil))
+}
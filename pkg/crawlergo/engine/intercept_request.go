@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/base64"
+	"fmt"
 	"io"
 	"katanacrawlgo/pkg/crawlergo/config"
 	"katanacrawlgo/pkg/crawlergo/model"
@@ -28,7 +29,23 @@ import (
 */
 func (tab *Tab) InterceptRequest(v *fetch.EventRequestPaused) {
 	defer tab.WG.Done()
-	ctx := tab.GetExecutor()
+	tab.interceptRequest(tab.GetExecutor(), v, nil)
+}
+
+/*
+*
+在指定的会话中处理请求，frame 不为空时请求来自跨进程frame的会话，不会是当前页面的导航请求
+*/
+func (tab *Tab) interceptRequest(ctx context.Context, v *fetch.EventRequestPaused, frame *frameHandle) {
+	// 响应阶段暂停的文档请求，请求阶段已经处理过
+	if v.ResponseStatusCode != 0 || v.ResponseErrorReason != "" {
+		if frame == nil {
+			tab.interceptResponse(ctx, v)
+		} else {
+			_ = fetch.ContinueRequest(v.RequestID).Do(ctx)
+		}
+		return
+	}
 	_req := v.Request
 	// 拦截到的URL格式一定正常 不处理错误
 	url, err := model.GetUrl(_req.URL, *tab.NavigateReq.URL)
@@ -38,42 +55,165 @@ func (tab *Tab) InterceptRequest(v *fetch.EventRequestPaused) {
 	}
 	_option := model.Options{
 		Headers:  _req.Headers,
-		PostData: _req.PostData,
+		PostData: tab.getPostData(ctx, v),
 	}
 	req := model.GetRequest(_req.Method, url, _option)
+	req.ResourceType = string(v.ResourceType)
+	networkID := v.NetworkID.String()
+	req.Initiator = tab.loadInitiator(networkID)
+	if frame == nil {
+		req.Variant = tab.formVariantOf(req, v.FrameID)
+	}
+	frameNavigation := false
+	if frame != nil {
+		// 跨进程frame自身的导航，frame中其它请求归属于该frame
+		frameNavigation = v.ResourceType == network.ResourceTypeDocument && v.FrameID == frame.ID
+		req.Frame = frame.URL
+		if frameNavigation {
+			req.Frame = req.URL.String()
+		}
+	} else if tab.config.FrameCrawl && !tab.IsTopFrame(v.FrameID.String()) {
+		frameNavigation = tab.labelFrameRequest(&req, v)
+	}
+
+	// 状态探索重新加载页面，重放原始请求且不加入结果
+	if frame == nil && tab.isRestoreNavigation(v, &req) {
+		tCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+		defer cancel()
+		_ = tab.overrideNavigateReq(v, &req).Do(tCtx)
+		return
+	}
 
 	if IsIgnoredByKeywordMatch(req, tab.config.IgnoreKeywords) {
+		tab.markHarBlocked(v, "ignore keyword")
 		_ = fetch.FailRequest(v.RequestID, network.ErrorReasonBlockedByClient).Do(ctx)
-		req.Source = config.FromXHR
-		tab.AddResultRequest(req)
+		req.Source = GetSourceByResourceType(v.ResourceType)
+		tab.addNetworkResult(networkID, req)
 		return
 	}
 
+	if tab.config.SafePolicy != nil {
+		if decision := tab.config.SafePolicy.ClassifyRequest(req.Method, req.URL.String(), req.PostData); !decision.Allowed() {
+			tab.handleUnsafeRequest(ctx, v, req, decision)
+			return
+		}
+	}
+
 	tab.HandleHostBinding(&req)
 
 	// 静态资源 全部阻断
 	// https://katanacrawlgo/issues/106
 	if config.StaticSuffixSet.Contains(url.FileExt()) {
+		tab.markHarBlocked(v, "static resource")
 		_ = fetch.FailRequest(v.RequestID, network.ErrorReasonBlockedByClient).Do(ctx)
 		req.Source = config.FromStaticRes
-		tab.AddResultRequest(req)
+		tab.addNetworkResult(networkID, req)
 		return
 	}
 
 	// 处理导航请求
-	if tab.IsNavigatorRequest(v.NetworkID.String()) {
+	if frame == nil && tab.IsNavigatorRequest(v.NetworkID.String()) {
 		tab.NavNetworkID = v.NetworkID.String()
 		tab.HandleNavigationReq(&req, v)
 		req.Source = config.FromNavigation
-		tab.AddResultRequest(req)
+		tab.addNetworkResult(networkID, req)
 		return
 	}
 
-	req.Source = config.FromXHR
-	tab.AddResultRequest(req)
+	req.Source = GetSourceByResourceType(v.ResourceType)
+	// 子frame的导航
+	if frameNavigation {
+		req.Source = config.FromFrame
+	}
+	tab.addNetworkResult(networkID, req)
 	_ = fetch.ContinueRequest(v.RequestID).Do(ctx)
 }
 
+/*
+*
+在HAR中标记被爬虫拦截的请求
+*/
+func (tab *Tab) markHarBlocked(v *fetch.EventRequestPaused, reason string) {
+	if tab.Har != nil && v.NetworkID != "" {
+		tab.Har.MarkBlocked(v.NetworkID, reason)
+	}
+}
+
+/*
+*
+获取完整的请求体
+请求体过大时 postData 字段会被省略，此时从 postDataEntries 拼接或者主动获取
+*/
+func (tab *Tab) getPostData(ctx context.Context, v *fetch.EventRequestPaused) string {
+	_req := v.Request
+	if _req.PostData != "" || !_req.HasPostData {
+		return _req.PostData
+	}
+	var builder strings.Builder
+	for _, entry := range _req.PostDataEntries {
+		data, err := base64.StdEncoding.DecodeString(entry.Bytes)
+		if err != nil {
+			continue
+		}
+		builder.Write(data)
+	}
+	if builder.Len() > 0 {
+		return builder.String()
+	}
+	if v.NetworkID == "" {
+		return ""
+	}
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+	postData, err := network.GetRequestPostData(v.NetworkID).Do(tCtx)
+	if err != nil {
+		return ""
+	}
+	return postData
+}
+
+/*
+*
+根据浏览器记录的资源类型确定请求来源
+*/
+func GetSourceByResourceType(resourceType network.ResourceType) string {
+	switch resourceType {
+	case network.ResourceTypeFetch:
+		return config.FromFetch
+	case network.ResourceTypeEventSource:
+		return config.FromEventSource
+	case network.ResourceTypeWebSocket:
+		return config.FromWebSocket
+	default:
+		return config.FromXHR
+	}
+}
+
+/*
+*
+转换请求发起者，保留JS调用栈的每一帧 function@url:line:column
+*/
+func ConvertInitiator(initiator *network.Initiator) *model.Initiator {
+	result := &model.Initiator{
+		Type: string(initiator.Type),
+		URL:  initiator.URL,
+	}
+	for stack := initiator.Stack; stack != nil; stack = stack.Parent {
+		for _, frame := range stack.CallFrames {
+			functionName := frame.FunctionName
+			if functionName == "" {
+				functionName = "(anonymous)"
+			}
+			result.Stack = append(result.Stack, fmt.Sprintf("%s@%s:%d:%d", functionName, frame.URL, frame.LineNumber+1, frame.ColumnNumber+1))
+			// 脚本发起的请求没有URL，取调用栈顶部所在的脚本
+			if result.URL == "" {
+				result.URL = frame.URL
+			}
+		}
+	}
+	return result
+}
+
 /*
 *
 判断是否为导航请求
@@ -110,13 +250,13 @@ func (tab *Tab) HandleNavigationReq(req *model.Request, v *fetch.EventRequestPau
 	overrideReq := fetch.ContinueRequest(v.RequestID).WithURL(req.URL.String())
 
 	// 处理后端重定向请求
-	if tab.FoundRedirection && tab.IsTopFrame(v.FrameID.String()) {
+	// 浏览器继续跟随重定向，重定向链由 Network.requestWillBeSent 事件记录，最终页面在响应阶段替换为伪造的页面
+	if (tab.FoundRedirection || v.RedirectedRequestID != "") && tab.IsTopFrame(v.FrameID.String()) {
 		log.Println(chalk.Green.Color("重定向请求: " + req.URL.String()))
-		body := base64.StdEncoding.EncodeToString([]byte(`<html><body>Crawlergo</body></html>`))
-		param := fetch.FulfillRequest(v.RequestID, 200).WithBody(body)
-		err := param.Do(ctx)
-		if err != nil {
-			log.Println(chalk.Red.Color("error: " + err.Error()))
+		if v.RedirectedRequestID != "" && tab.serverRedirectHops() <= config.MaxRedirectHops {
+			_ = fetch.ContinueRequest(v.RequestID).Do(tCtx)
+		} else {
+			tab.fulfillRedirection(ctx, v)
 		}
 		navReq.RedirectionFlag = true
 		navReq.Source = config.FromNavigation
@@ -141,20 +281,41 @@ func (tab *Tab) HandleNavigationReq(req *model.Request, v *fetch.EventRequestPau
 		}
 		// 主导航请求
 	} else if tab.IsTopFrame(v.FrameID.String()) && req.URL.NavigationUrl() == navReq.URL.NavigationUrl() {
-		// 手动设置POST信息
-		if navReq.Method == config.POST || navReq.Method == config.PUT {
-			overrideReq = overrideReq.WithPostData(navReq.PostData)
-		}
-		overrideReq = overrideReq.WithMethod(navReq.Method)
-		overrideReq = overrideReq.WithHeaders(MergeHeaders(navReq.Headers, req.Headers))
-		_ = overrideReq.Do(tCtx)
+		_ = tab.overrideNavigateReq(v, req).Do(tCtx)
 		// 子frame的导航
 	} else if !tab.IsTopFrame(v.FrameID.String()) {
 		_ = overrideReq.Do(tCtx)
 		// 前端跳转 返回204
 	} else {
 		_ = fetch.FulfillRequest(v.RequestID, 204).Do(ctx)
+		tab.lock.Lock()
+		interacting := tab.interacting
+		tab.lock.Unlock()
+		// 页面加载过程中由脚本发起的跳转
+		if !interacting && req.Initiator != nil && req.Initiator.Type == "script" {
+			tab.addRedirectHop(model.RedirectHop{
+				From:     tab.NavigateReq.URL.String(),
+				Location: req.URL.String(),
+				Type:     model.RedirectJS,
+			})
+		}
+	}
+}
+
+/*
+*
+使用导航请求原始的方法、请求体和请求头继续请求
+*/
+func (tab *Tab) overrideNavigateReq(v *fetch.EventRequestPaused, req *model.Request) *fetch.ContinueRequestParams {
+	navReq := tab.NavigateReq
+	overrideReq := fetch.ContinueRequest(v.RequestID).WithURL(req.URL.String())
+	// 手动设置POST信息
+	if navReq.Method == config.POST || navReq.Method == config.PUT {
+		overrideReq = overrideReq.WithPostData(navReq.PostData)
 	}
+	overrideReq = overrideReq.WithMethod(navReq.Method)
+	overrideReq = overrideReq.WithHeaders(MergeHeaders(navReq.Headers, req.Headers))
+	return overrideReq
 }
 
 /*
@@ -191,9 +352,28 @@ func (tab *Tab) IsTopFrame(FrameID string) bool {
 	return FrameID == tab.TopFrameId
 }
 
+var suspectURLRegex = regexp.MustCompile(config.SuspectURLRegex)
+
+// JS文件常见的 MIME 类型，服务器返回的类型不统一
+var jsMimeTypes = map[string]bool{
+	"application/javascript": true, "text/javascript": true, "application/x-javascript": true,
+	"application/ecmascript": true, "text/ecmascript": true, "application/x-ecmascript": true, "text/jscript": true,
+}
+
 /*
 *
-解析响应内容中的URL 使用正则匹配
+判断响应是否为JS文件，按 MIME 类型或资源类型判断，模块脚本的资源类型同样为 Script
+*/
+func isJSResponse(v *network.EventResponseReceived) bool {
+	if v.Type == network.ResourceTypeScript {
+		return true
+	}
+	return v.Response != nil && jsMimeTypes[strings.ToLower(v.Response.MimeType)]
+}
+
+/*
+*
+解析响应内容中的URL，JS文件使用 jsluice 解析，HTML和JSON使用正则匹配
 */
 func (tab *Tab) ParseResponseURL(v *network.EventResponseReceived) {
 	defer tab.WG.Done()
@@ -203,9 +383,29 @@ func (tab *Tab) ParseResponseURL(v *network.EventResponseReceived) {
 		return
 	}
 	resStr := string(res)
+	if isJSResponse(v) {
+		if tab.config.RouteDiscovery {
+			tab.addBundleRoutes(resStr)
+		}
+		tab.parseJSEndpoints(v.Response.URL, resStr)
+		tab.addGraphQLEndpoints(resStr)
+		if tab.config.OpenAPIDiscovery {
+			tab.addOpenAPISpecs(v.Response.URL, resStr)
+		}
+		return
+	}
+	if tab.config.OpenAPIDiscovery {
+		tab.addOpenAPISpecs(v.Response.URL, resStr)
+	}
+	tab.parseURLByRegex(resStr)
+}
 
-	urlRegex := regexp.MustCompile(config.SuspectURLRegex)
-	urlList := urlRegex.FindAllString(resStr, -1)
+/*
+*
+使用正则匹配响应内容中的URL
+*/
+func (tab *Tab) parseURLByRegex(content string) {
+	urlList := suspectURLRegex.FindAllString(content, -1)
 	for _, url := range urlList {
 
 		url = url[1 : len(url)-1]
@@ -221,8 +421,11 @@ func (tab *Tab) ParseResponseURL(v *network.EventResponseReceived) {
 func (tab *Tab) HandleRedirectionResp(v *network.EventResponseReceivedExtraInfo) {
 	defer tab.WG.Done()
 	statusCode := tab.GetStatusCode(v.HeadersText)
-	// 导航请求，且返回重定向
-	if 300 <= statusCode && statusCode < 400 {
+	if statusCode == 0 {
+		statusCode = int(v.StatusCode)
+	}
+	// 导航请求，且返回重定向，重定向链在浏览器跟随跳转时记录
+	if isRedirectStatus(int64(statusCode)) {
 		tab.FoundRedirection = true
 	}
 }
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertInitiator(t *testing.T) {
+	initiator := ConvertInitiator(&network.Initiator{
+		Type: network.InitiatorTypeScript,
+		Stack: &runtime.StackTrace{
+			CallFrames: []*runtime.CallFrame{{URL: "https://example.com/app.js", LineNumber: 9, ColumnNumber: 4}},
+			Parent: &runtime.StackTrace{
+				CallFrames: []*runtime.CallFrame{{FunctionName: "load", URL: "https://example.com/main.js"}},
+			},
+		},
+	})
+	assert.Equal(t, "script", initiator.Type)
+	// 脚本发起的请求取调用栈顶部所在的脚本
+	assert.Equal(t, "https://example.com/app.js", initiator.URL)
+	assert.Equal(t, []string{"(anonymous)@https://example.com/app.js:10:5", "load@https://example.com/main.js:1:1"}, initiator.Stack)
+}
+
+func TestAddNetworkResult_initiator(t *testing.T) {
+	tab := &Tab{initiators: map[string]*model.Initiator{}, noInitiator: map[string]*model.Request{}}
+	newRequest := func(rawUrl string, resourceType network.ResourceType) model.Request {
+		url, err := model.GetUrl(rawUrl)
+		require.NoError(t, err)
+		req := model.GetRequest(config.POST, url, model.Options{Headers: map[string]interface{}{}, PostData: `{"id":1}`})
+		req.ResourceType = string(resourceType)
+		req.Source = GetSourceByResourceType(resourceType)
+		return req
+	}
+	scriptInitiator := &model.Initiator{Type: "script", URL: "https://example.com/app.js"}
+
+	// requestWillBeSent 先于拦截到达
+	tab.recordInitiator("1", scriptInitiator)
+	req := newRequest("https://example.com/api/fetch", network.ResourceTypeFetch)
+	req.Initiator = tab.loadInitiator("1")
+	tab.addNetworkResult("1", req)
+
+	// 拦截先于 requestWillBeSent 到达，发起者到达后补充到结果中
+	tab.addNetworkResult("2", newRequest("https://example.com/api/xhr", network.ResourceTypeXHR))
+	assert.Nil(t, tab.ResultList[1].Initiator)
+	tab.recordInitiator("2", scriptInitiator)
+
+	require.Len(t, tab.ResultList, 2)
+	for _, result := range tab.ResultList {
+		assert.Equal(t, scriptInitiator, result.Initiator)
+		assert.Equal(t, config.POST, result.Method)
+		assert.Equal(t, `{"id":1}`, result.PostData)
+	}
+	assert.Equal(t, "Fetch", tab.ResultList[0].ResourceType)
+	assert.Equal(t, config.FromFetch, tab.ResultList[0].Source)
+	assert.Equal(t, "XHR", tab.ResultList[1].ResourceType)
+	assert.Equal(t, config.FromXHR, tab.ResultList[1].Source)
+	// 使用过的记录被删除
+	assert.Empty(t, tab.initiators)
+	assert.Empty(t, tab.noInitiator)
+}
+
+func TestIsJSResponse(t *testing.T) {
+	for _, mimeType := range []string{"application/javascript", "text/javascript", "application/x-javascript", "application/ecmascript", "Text/JavaScript"} {
+		assert.True(t, isJSResponse(&network.EventResponseReceived{Type: network.ResourceTypeOther, Response: &network.Response{MimeType: mimeType}}), mimeType)
+	}
+	// 模块脚本或返回错误类型的脚本按资源类型判断
+	assert.True(t, isJSResponse(&network.EventResponseReceived{Type: network.ResourceTypeScript, Response: &network.Response{MimeType: "text/plain"}}))
+	assert.False(t, isJSResponse(&network.EventResponseReceived{Type: network.ResourceTypeDocument, Response: &network.Response{MimeType: "text/html"}}))
+	assert.False(t, isJSResponse(&network.EventResponseReceived{Type: network.ResourceTypeXHR, Response: &network.Response{MimeType: "application/json"}}))
+}
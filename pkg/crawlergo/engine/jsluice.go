@@ -0,0 +1,132 @@
+//go:build !(386 || windows)
+
+package engine
+
+import (
+	"encoding/json"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/katana/utils"
+	"net/url"
+	"strings"
+)
+
+/*
+*
+使用 jsluice 解析JS文件中的接口，保留从 fetch、$.ajax、axios 等调用中推断出的请求方法、参数和请求体
+常见的JS库文件不解析
+*/
+func (tab *Tab) parseJSEndpoints(jsURL string, content string) {
+	if u, err := url.Parse(jsURL); err == nil && utils.IsPathCommonJSLibraryFile(u.Path) {
+		return
+	}
+	for _, endpoint := range MergeJSEndpoints(utils.ExtractJsluiceEndpoints(content)) {
+		method, option := BuildJSEndpointOptions(endpoint)
+		tab.AddResultUrlWithOptions(method, BuildJSEndpointURL(endpoint), config.FromJSFile, option)
+	}
+}
+
+/*
+*
+jsluice 对同一个调用会同时返回字符串字面量等多个结果
+同一地址存在推断出请求方法的结果时，只保留这些结果
+*/
+func MergeJSEndpoints(endpoints []utils.JSLuiceEndpoint) []utils.JSLuiceEndpoint {
+	withMethod := map[string]bool{}
+	for _, endpoint := range endpoints {
+		if endpoint.Method != "" {
+			withMethod[endpoint.Endpoint] = true
+		}
+	}
+	var result []utils.JSLuiceEndpoint
+	unique := map[string]bool{}
+	for _, endpoint := range endpoints {
+		if endpoint.Method == "" && withMethod[endpoint.Endpoint] {
+			continue
+		}
+		key := endpoint.Method + " " + endpoint.Endpoint + " " + strings.Join(endpoint.QueryParams, ",") + " " + strings.Join(endpoint.BodyParams, ",")
+		if unique[key] {
+			continue
+		}
+		unique[key] = true
+		result = append(result, endpoint)
+	}
+	return result
+}
+
+/*
+*
+将 jsluice 推断出的URL参数加入地址，参数值使用默认填充内容，地址中已有的参数保持不变
+*/
+func BuildJSEndpointURL(endpoint utils.JSLuiceEndpoint) string {
+	if len(endpoint.QueryParams) == 0 {
+		return endpoint.Endpoint
+	}
+	base, fragment, hasFragment := strings.Cut(endpoint.Endpoint, "#")
+	_, rawQuery, _ := strings.Cut(base, "?")
+	exist, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return endpoint.Endpoint
+	}
+	var params []string
+	for _, param := range endpoint.QueryParams {
+		if _, ok := exist[param]; ok || param == "" {
+			continue
+		}
+		exist[param] = nil
+		params = append(params, url.QueryEscape(param)+"="+url.QueryEscape(config.DefaultInputText))
+	}
+	if len(params) == 0 {
+		return endpoint.Endpoint
+	}
+	switch {
+	case !strings.Contains(base, "?"):
+		base += "?"
+	case rawQuery != "" && !strings.HasSuffix(rawQuery, "&"):
+		base += "&"
+	}
+	base += strings.Join(params, "&")
+	if hasFragment {
+		base += "#" + fragment
+	}
+	return base
+}
+
+/*
+*
+根据 jsluice 的结果生成请求方法、请求头和请求体，参数值使用默认填充内容
+*/
+func BuildJSEndpointOptions(endpoint utils.JSLuiceEndpoint) (string, model.Options) {
+	method := strings.ToUpper(endpoint.Method)
+	if method == "" {
+		method = config.GET
+	}
+	option := model.Options{Headers: map[string]interface{}{}}
+	for key, value := range endpoint.Headers {
+		option.Headers[key] = value
+	}
+	if method == config.GET || method == config.HEAD || len(endpoint.BodyParams) == 0 {
+		return method, option
+	}
+
+	contentType := endpoint.ContentType
+	if contentType == "" {
+		contentType = config.URLENCODED
+	}
+	if strings.Contains(contentType, "json") {
+		body := map[string]string{}
+		for _, param := range endpoint.BodyParams {
+			body[param] = config.DefaultInputText
+		}
+		data, _ := json.Marshal(body)
+		option.PostData = string(data)
+	} else {
+		values := url.Values{}
+		for _, param := range endpoint.BodyParams {
+			values.Set(param, config.DefaultInputText)
+		}
+		option.PostData = values.Encode()
+	}
+	option.Headers["Content-Type"] = contentType
+	return method, option
+}
@@ -0,0 +1,11 @@
+//go:build windows || 386
+
+package engine
+
+/*
+*
+当前平台不支持 jsluice，使用正则解析JS文件中的URL
+*/
+func (tab *Tab) parseJSEndpoints(jsURL string, content string) {
+	tab.parseURLByRegex(content)
+}
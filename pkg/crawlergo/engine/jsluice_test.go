@@ -0,0 +1,72 @@
+//go:build !(386 || windows)
+
+package engine
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/katana/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeJSEndpoints(t *testing.T) {
+	endpoints := utils.ExtractJsluiceEndpoints(`$.post("/api/login", {user: u, pass: p}); var a = "/static/app.html";`)
+	merged := MergeJSEndpoints(endpoints)
+	assert.Len(t, merged, 2)
+	assert.Equal(t, "/api/login", merged[0].Endpoint)
+	assert.Equal(t, "POST", merged[0].Method)
+	assert.Equal(t, "/static/app.html", merged[1].Endpoint)
+}
+
+func TestBuildJSEndpointOptions(t *testing.T) {
+	method, option := BuildJSEndpointOptions(utils.JSLuiceEndpoint{Endpoint: "/api/list"})
+	assert.Equal(t, config.GET, method)
+	assert.Empty(t, option.PostData)
+
+	method, option = BuildJSEndpointOptions(utils.JSLuiceEndpoint{
+		Endpoint:   "/api/login",
+		Method:     "post",
+		BodyParams: []string{"user", "pass"},
+	})
+	assert.Equal(t, config.POST, method)
+	assert.Equal(t, "pass=admin&user=admin", option.PostData)
+	assert.Equal(t, config.URLENCODED, option.Headers["Content-Type"])
+
+	method, option = BuildJSEndpointOptions(utils.JSLuiceEndpoint{
+		Endpoint:    "/api/user",
+		Method:      "PUT",
+		BodyParams:  []string{"name"},
+		ContentType: "application/json",
+		Headers:     map[string]string{"X-Token": "t"},
+	})
+	assert.Equal(t, config.PUT, method)
+	assert.JSONEq(t, `{"name":"admin"}`, option.PostData)
+	assert.Equal(t, "application/json", option.Headers["Content-Type"])
+	assert.Equal(t, "t", option.Headers["X-Token"])
+}
+
+func TestBuildJSEndpointURL(t *testing.T) {
+	assert.Equal(t, "/api/list", BuildJSEndpointURL(utils.JSLuiceEndpoint{Endpoint: "/api/list"}))
+	assert.Equal(t, "/api/list?page=admin&size=admin", BuildJSEndpointURL(utils.JSLuiceEndpoint{
+		Endpoint:    "/api/list",
+		QueryParams: []string{"page", "size"},
+	}))
+	// 地址中已有的参数保持不变
+	assert.Equal(t, "/api/user?id=EXPR&lang=admin#/profile", BuildJSEndpointURL(utils.JSLuiceEndpoint{
+		Endpoint:    "/api/user?id=EXPR#/profile",
+		QueryParams: []string{"id", "lang"},
+	}))
+
+	// POST 请求的URL参数同样加入地址，请求体参数不受影响
+	endpoint := utils.JSLuiceEndpoint{
+		Endpoint:    "/api/login",
+		Method:      "POST",
+		QueryParams: []string{"next"},
+		BodyParams:  []string{"user"},
+	}
+	method, option := BuildJSEndpointOptions(endpoint)
+	assert.Equal(t, config.POST, method)
+	assert.Equal(t, "/api/login?next=admin", BuildJSEndpointURL(endpoint))
+	assert.Equal(t, "user=admin", option.PostData)
+}
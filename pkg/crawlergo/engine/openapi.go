@@ -0,0 +1,20 @@
+package engine
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/openapi"
+)
+
+/*
+*
+Swagger UI、ReDoc 页面及其初始化脚本中引用的OpenAPI文档地址，以及页面请求到的文档本身
+文档在任务结束后统一下载解析，展开为接口请求
+*/
+func (tab *Tab) addOpenAPISpecs(responseURL string, content string) {
+	for _, spec := range openapi.DetectSpecURLs(content) {
+		tab.AddResultUrl(config.GET, spec, config.FromOpenAPI)
+	}
+	if openapi.LooksLikeSpec([]byte(content)) {
+		tab.AddResultUrl(config.GET, responseURL, config.FromOpenAPI)
+	}
+}
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"context"
+	"encoding/base64"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/js"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ttacon/chalk"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+var metaRefreshURLRegex = regexp.MustCompile(`(?i)^\s*\d*(?:\.\d*)?\s*[;,]?\s*(?:url\s*=\s*)?(.*)$`)
+
+/*
+*
+记录重定向链中的一跳，忽略重复的跳转
+*/
+func (tab *Tab) addRedirectHop(hop model.RedirectHop) {
+	if hop.Location == "" {
+		return
+	}
+	tab.lock.Lock()
+	defer tab.lock.Unlock()
+	for _, exist := range tab.RedirectChain {
+		if exist.From == hop.From && exist.Location == hop.Location {
+			return
+		}
+	}
+	tab.RedirectChain = append(tab.RedirectChain, hop)
+}
+
+/*
+*
+将 Location 转换为绝对地址
+*/
+func (tab *Tab) resolveLocation(from string, location string) string {
+	if location == "" {
+		return ""
+	}
+	base, err := model.GetUrl(from)
+	if err != nil {
+		return location
+	}
+	u, err := model.GetUrl(location, *base)
+	if err != nil {
+		return location
+	}
+	return u.String()
+}
+
+/*
+*
+浏览器跟随导航请求的重定向时记录一跳，重定向链由 Network.requestWillBeSent 的 redirectResponse 得到，不在浏览器外重放请求
+*/
+func (tab *Tab) recordServerRedirect(v *network.EventRequestWillBeSent) {
+	tab.FoundRedirection = true
+	tab.addRedirectHop(model.RedirectHop{
+		StatusCode: int(v.RedirectResponse.Status),
+		From:       v.RedirectResponse.URL,
+		Location:   v.Request.URL,
+		Type:       model.RedirectServer,
+	})
+}
+
+/*
+*
+已记录的后端重定向次数
+*/
+func (tab *Tab) serverRedirectHops() int {
+	tab.lock.Lock()
+	defer tab.lock.Unlock()
+	hops := 0
+	for _, hop := range tab.RedirectChain {
+		if hop.Type == model.RedirectServer {
+			hops++
+		}
+	}
+	return hops
+}
+
+/*
+*
+响应阶段暂停的文档请求
+导航请求重定向后，最终页面的响应替换为伪造的页面，不在当前标签页中加载跳转后的页面，其它响应不修改
+*/
+func (tab *Tab) interceptResponse(ctx context.Context, v *fetch.EventRequestPaused) {
+	if tab.FoundRedirection && tab.IsTopFrame(v.FrameID.String()) && tab.IsNavigatorRequest(v.NetworkID.String()) &&
+		v.ResponseErrorReason == "" && !isRedirectStatus(v.ResponseStatusCode) {
+		tab.fulfillRedirection(ctx, v)
+		return
+	}
+	_ = fetch.ContinueRequest(v.RequestID).Do(ctx)
+}
+
+/*
+*
+使用伪造的页面结束重定向后的导航请求
+*/
+func (tab *Tab) fulfillRedirection(ctx context.Context, v *fetch.EventRequestPaused) {
+	body := base64.StdEncoding.EncodeToString([]byte(`<html><body>Crawlergo</body></html>`))
+	if err := fetch.FulfillRequest(v.RequestID, 200).WithBody(body).Do(ctx); err != nil {
+		log.Println(chalk.Red.Color("error: " + err.Error()))
+	}
+}
+
+func isRedirectStatus(statusCode int64) bool {
+	return statusCode >= 300 && statusCode < 400
+}
+
+/*
+*
+检测 meta refresh 跳转
+*/
+func (tab *Tab) detectMetaRefresh() {
+	ctx := tab.GetExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*1)
+	defer cancel()
+	var content string
+	if err := chromedp.Evaluate(js.MetaRefreshJS, &content).Do(tCtx); err != nil || content == "" {
+		return
+	}
+	location, ok := ParseMetaRefresh(content)
+	if !ok {
+		return
+	}
+	location = tab.resolveLocation(tab.NavigateReq.URL.String(), location)
+	tab.addRedirectHop(model.RedirectHop{
+		From:     tab.NavigateReq.URL.String(),
+		Location: location,
+		Type:     model.RedirectMeta,
+	})
+	tab.AddResultUrl(config.GET, location, config.FromNavigation)
+}
+
+/*
+*
+解析 meta refresh 的 content，如 "0; url=/home"、"5;URL='http://a.com'"
+只有刷新时间没有地址时返回 false
+*/
+func ParseMetaRefresh(content string) (string, bool) {
+	match := metaRefreshURLRegex.FindStringSubmatch(content)
+	if match == nil {
+		return "", false
+	}
+	location := strings.Trim(strings.TrimSpace(match[1]), `'"`)
+	if location == "" {
+		return "", false
+	}
+	return location, true
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetaRefresh(t *testing.T) {
+	tests := []struct {
+		content  string
+		location string
+		ok       bool
+	}{
+		{"0; url=/home", "/home", true},
+		{"5;URL='http://test.com/login'", "http://test.com/login", true},
+		{`3, url="next.html"`, "next.html", true},
+		{"0;/index.php", "/index.php", true},
+		{"30", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		location, ok := ParseMetaRefresh(tt.content)
+		assert.Equal(t, tt.ok, ok, tt.content)
+		assert.Equal(t, tt.location, location, tt.content)
+	}
+}
+
+func TestRecordServerRedirect(t *testing.T) {
+	navURL, err := model.GetUrl("http://test.com/login")
+	require.NoError(t, err)
+	tab := &Tab{NavigateReq: model.GetRequest(config.POST, navURL)}
+	hop := func(status int64, from, location string) *network.EventRequestWillBeSent {
+		return &network.EventRequestWillBeSent{
+			RequestID:        "1000.1",
+			Request:          &network.Request{URL: location},
+			RedirectResponse: &network.Response{URL: from, Status: status},
+		}
+	}
+	// 浏览器跟随重定向时每一跳触发一次 requestWillBeSent
+	tab.recordServerRedirect(hop(307, "http://test.com/login", "http://test.com/step1"))
+	tab.recordServerRedirect(hop(308, "http://test.com/step1", "http://test.com/step2"))
+	tab.recordServerRedirect(hop(302, "http://test.com/step2", "http://test.com/home"))
+	tab.recordServerRedirect(hop(302, "http://test.com/step2", "http://test.com/home"))
+
+	assert.True(t, tab.FoundRedirection)
+	assert.Equal(t, 3, tab.serverRedirectHops())
+	assert.Equal(t, []model.RedirectHop{
+		{StatusCode: 307, From: "http://test.com/login", Location: "http://test.com/step1", Type: model.RedirectServer},
+		{StatusCode: 308, From: "http://test.com/step1", Location: "http://test.com/step2", Type: model.RedirectServer},
+		{StatusCode: 302, From: "http://test.com/step2", Location: "http://test.com/home", Type: model.RedirectServer},
+	}, tab.RedirectChain)
+	assert.Empty(t, tab.ResultList, "hops are not requested outside the browser")
+
+	assert.True(t, isRedirectStatus(301))
+	assert.False(t, isRedirectStatus(200))
+	assert.False(t, isRedirectStatus(404))
+}
@@ -0,0 +1,244 @@
+package engine
+
+import (
+	"context"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/js"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/ttacon/chalk"
+)
+
+type routeDiscoveryResult struct {
+	Routes []string `json:"routes"`
+	Hash   bool     `json:"hash"`
+	Base   string   `json:"base"`
+}
+
+// 打包后的JS中的路由定义 如 {path:"/user/:id",component:...}、{name:"user",path:"/user/:id",component:...}
+// path 之后需要有路由对象的属性，避免匹配其它 {path:"/"} 形式的对象
+// name 在前时之后仍需要组件等属性，{name:"sid",path:"/",domain:...} 之类的 cookie 对象不是路由
+var routeRegex = regexp.MustCompile(`\{\s*(?:name\s*:\s*["'][^"']*["']\s*,\s*path\s*:\s*["']([^"'\s]+)["']\s*,\s*(?:component|components|loadChildren|loadComponent|children|element|redirect|redirectTo)\b|path\s*:\s*["']([^"'\s]+)["']\s*,\s*(?:component|components|loadChildren|loadComponent|children|element|redirect|redirectTo|name)\b)`)
+
+// 路由参数 :id :id? :id(\d+) :path* {id} [id] [...slug] [[...slug]]
+var colonParamRegex = regexp.MustCompile(`^:([A-Za-z_][A-Za-z0-9_]*)(?:\([^)]*\))?[?*+]?$`)
+var braceParamRegex = regexp.MustCompile(`^\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+var bracketParamRegex = regexp.MustCompile(`^\[{1,2}(?:\.\.\.)?([A-Za-z_][A-Za-z0-9_]*)\]{1,2}$`)
+
+/*
+*
+在运行时读取前端框架的路由表，并与JS文件中解析出的路由合并，全部作为导航请求加入结果
+*/
+func (tab *Tab) discoverRoutes() {
+	ctx := tab.GetExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+	var result routeDiscoveryResult
+	err := chromedp.Evaluate(js.RouteDiscoveryJS, &result).Do(tCtx)
+	if err != nil {
+		log.Println(chalk.Red.Color("error: 前端路由发现失败, " + err.Error()))
+	}
+
+	tab.lock.Lock()
+	routes := append(result.Routes, tab.bundleRoutes...)
+	tab.lock.Unlock()
+
+	navUrl := tab.NavigateReq.URL
+	base := RouteBase(result.Base, navUrl)
+	uniqueRoutes := map[string]bool{}
+	for _, route := range routes {
+		path, ok := ExpandRoute(route)
+		if !ok || uniqueRoutes[path] {
+			continue
+		}
+		uniqueRoutes[path] = true
+		if result.Hash {
+			tab.AddResultUrl(config.GET, navUrl.NoFragmentUrl()+"#"+path, config.FromRouter)
+		} else {
+			tab.AddResultUrl(config.GET, base+path, config.FromRouter)
+		}
+	}
+}
+
+/*
+*
+history 模式下路由的前缀，路由表没有设置 base 时使用当前页面的域名，相对的 base 相对于当前页面
+*/
+func RouteBase(base string, pageURL *model.URL) string {
+	if base != "" {
+		if u, err := model.GetUrl(base, *pageURL); err == nil {
+			return strings.TrimSuffix(u.Scheme+"://"+u.Host+u.Path, "/")
+		}
+	}
+	return pageURL.Scheme + "://" + pageURL.Host
+}
+
+/*
+*
+记录JS文件中解析出的路由，等待路由发现时统一处理
+*/
+func (tab *Tab) addBundleRoutes(content string) {
+	routes := ExtractRoutesFromJS(content)
+	if len(routes) == 0 {
+		return
+	}
+	tab.lock.Lock()
+	tab.bundleRoutes = append(tab.bundleRoutes, routes...)
+	tab.lock.Unlock()
+}
+
+// children 数组的开始，用于确定子路由所属的上级路由
+var childrenKeyRegex = regexp.MustCompile(`\bchildren\s*:\s*$`)
+
+type bundleRoute struct {
+	start int
+	path  string
+}
+
+// 路由表中的一层对象或数组，base 为其中相对路由的上级路径
+type routeScope struct {
+	base string
+	path string
+}
+
+/*
+*
+从JS文件内容中提取路由表定义的路径
+children 中的相对路由与上级路由的路径拼接，以 / 开头的子路由保持不变
+*/
+func ExtractRoutesFromJS(content string) []string {
+	var found []bundleRoute
+	for _, match := range routeRegex.FindAllStringSubmatchIndex(content, -1) {
+		// name 在前时为第一个分组，否则为第二个分组
+		start, end := match[2], match[3]
+		if start < 0 {
+			start, end = match[4], match[5]
+		}
+		found = append(found, bundleRoute{start: start, path: content[start:end]})
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	var routes []string
+	stack := []*routeScope{{base: "/"}}
+	next := 0
+	for i := 0; i < len(content) && next < len(found); i++ {
+		// 路由属于当前所在的对象
+		for next < len(found) && found[next].start <= i {
+			scope := stack[len(stack)-1]
+			scope.path = joinRoutePath(scope.base, found[next].path)
+			routes = append(routes, scope.path)
+			next++
+		}
+		switch content[i] {
+		case '"', '\'', '`':
+			i = skipJSString(content, i)
+		case '{':
+			stack = append(stack, &routeScope{base: stack[len(stack)-1].base})
+		case '[':
+			scope := stack[len(stack)-1]
+			base := scope.base
+			if scope.path != "" && childrenKeyRegex.MatchString(content[max(0, i-32):i]) {
+				base = scope.path
+			}
+			stack = append(stack, &routeScope{base: base})
+		case '}', ']':
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return routes
+}
+
+/*
+*
+拼接上级路由和子路由的路径
+*/
+func joinRoutePath(base string, path string) string {
+	if strings.HasPrefix(path, "/") {
+		return path
+	}
+	return strings.TrimSuffix(base, "/") + "/" + path
+}
+
+/*
+*
+跳过JS字符串字面量，返回结束引号的位置
+*/
+func skipJSString(content string, start int) int {
+	quote := content[start]
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		}
+	}
+	return len(content)
+}
+
+/*
+*
+将带参数的路由转换为可访问的路径，参数使用示例值填充
+通配路由 * ** 无法确定路径，返回 false
+*/
+func ExpandRoute(route string) (string, bool) {
+	route = strings.TrimSpace(route)
+	if route == "" || strings.Contains(route, "://") {
+		return "", false
+	}
+	var parts []string
+	for _, part := range strings.Split(strings.Trim(route, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if part == "*" || part == "**" || strings.HasPrefix(part, "(") {
+			return "", false
+		}
+		// 可选的 catch-all 参数直接省略
+		if strings.HasPrefix(part, "[[") {
+			continue
+		}
+		if match := colonParamRegex.FindStringSubmatch(part); match != nil {
+			parts = append(parts, routeParamSample(match[1]))
+		} else if match := braceParamRegex.FindStringSubmatch(part); match != nil {
+			parts = append(parts, routeParamSample(match[1]))
+		} else if match := bracketParamRegex.FindStringSubmatch(part); match != nil {
+			parts = append(parts, routeParamSample(match[1]))
+		} else if strings.ContainsAny(part, ":*?()[]{}") {
+			return "", false
+		} else {
+			parts = append(parts, part)
+		}
+	}
+	return "/" + strings.Join(parts, "/"), true
+}
+
+/*
+*
+根据参数名生成示例值
+*/
+func routeParamSample(name string) string {
+	lowerName := strings.ToLower(name)
+	switch {
+	case strings.Contains(lowerName, "uuid") || strings.Contains(lowerName, "guid"):
+		return "00000000-0000-4000-8000-000000000001"
+	case lowerName == "id" || strings.HasSuffix(lowerName, "id") || strings.Contains(lowerName, "page") ||
+		strings.Contains(lowerName, "num") || strings.Contains(lowerName, "count") || strings.Contains(lowerName, "year"):
+		return "1"
+	case strings.Contains(lowerName, "date"):
+		return "2023-01-01"
+	case strings.Contains(lowerName, "lang") || strings.Contains(lowerName, "locale"):
+		return "en"
+	default:
+		return "crawlergo"
+	}
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"katanacrawlgo/pkg/crawlergo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandRoute(t *testing.T) {
+	cases := []struct {
+		route string
+		path  string
+		ok    bool
+	}{
+		{"/about", "/about", true},
+		{"users/:id", "/users/1", true},
+		{"/users/:userId(\\d+)/posts/:slug?", "/users/1/posts/crawlergo", true},
+		{"/orders/{uuid}", "/orders/00000000-0000-4000-8000-000000000001", true},
+		{"/blog/[slug]", "/blog/crawlergo", true},
+		{"/docs/[...path]", "/docs/crawlergo", true},
+		{"/shop/[[...filters]]", "/shop", true},
+		{"**", "", false},
+		{"/files/*", "", false},
+		{"https://example.com/", "", false},
+	}
+	for _, c := range cases {
+		path, ok := ExpandRoute(c.route)
+		assert.Equal(t, c.ok, ok, c.route)
+		assert.Equal(t, c.path, path, c.route)
+	}
+}
+
+func TestExtractRoutesFromJS(t *testing.T) {
+	content := `const routes=[{path:"/",component:Home},{path:"/user/:id",component:User,children:[{path:"settings",component:Settings}]},{path:"admin",loadChildren:()=>import("./admin")}];var cfg={path:"config.json"}`
+	routes := ExtractRoutesFromJS(content)
+	assert.ElementsMatch(t, []string{"/", "/user/:id", "/user/:id/settings", "/admin"}, routes)
+
+	// 多层子路由，以 / 开头的子路由和字符串中的括号不影响层级
+	content = `[{path:"/shop",component:Shop,children:[{path:"cart",component:Cart,meta:{title:"Cart ]"}},{path:"orders",component:Orders,children:[{path:":orderId",component:Order}]},{path:"/login",component:Login}]},{path:"help",component:Help}]`
+	routes = ExtractRoutesFromJS(content)
+	assert.Equal(t, []string{"/shop", "/shop/cart", "/shop/orders", "/shop/orders/:orderId", "/login", "/help"}, routes)
+
+	// name 在 path 之前，以及 Vue Router 的 redirect
+	content = `[{name:"home",path:"/",component:Home},{path:"/old",redirect:"/new"}]`
+	assert.Equal(t, []string{"/", "/old"}, ExtractRoutesFromJS(content))
+
+	// 没有路由属性的 {path:"/"} 对象不是路由
+	content = `var cookie={path:"/",domain:".test.com"};fetch(u,{path:"/api/internal",method:"POST"});var menu=[{path:"/reports"}];document.cookie=serialize({name:"sid",path:"/",domain:".test.com"})`
+	assert.Empty(t, ExtractRoutesFromJS(content))
+}
+
+func TestRouteBase(t *testing.T) {
+	pageURL, err := model.GetUrl("https://example.com/app/index.html?from=1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", RouteBase("", pageURL))
+	assert.Equal(t, "https://example.com/portal", RouteBase("/portal/", pageURL))
+	assert.Equal(t, "https://example.com/app/admin", RouteBase("admin/", pageURL))
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/js"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/crawlergo/safemode"
+	"log"
+
+	"github.com/ttacon/chalk"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+)
+
+type safeModeElement struct {
+	ID string `json:"id"`
+	safemode.Element
+}
+
+/*
+*
+对页面中尚未分类的按钮、链接、表单进行安全分类，危险元素禁止点击和提交
+可以多次调用，只处理新出现的元素
+*/
+func (tab *Tab) applySafeMode() {
+	tab.applySafeModeWith(tab.NavigateReq.URL.String(), tab.evaluateResult)
+}
+
+/*
+*
+使用指定的执行方式进行安全分类，子frame中同样需要禁止危险元素
+*/
+func (tab *Tab) applySafeModeWith(pageURL string, evaluate func(expression string, res interface{}) error) {
+	policy := tab.config.SafePolicy
+	if policy == nil {
+		return
+	}
+	var elements []safeModeElement
+	if err := evaluate(js.SafeModeCollectJS, &elements); err != nil {
+		log.Println(chalk.Red.Color("error: 安全模式获取页面元素失败, " + err.Error()))
+		return
+	}
+	var blockedIDs []string
+	for _, element := range elements {
+		decision := policy.ClassifyElement(element.Element)
+		if decision.Allowed() {
+			continue
+		}
+		blockedIDs = append(blockedIDs, element.ID)
+		policy.Record("element", fmt.Sprintf("<%s> %s", element.Tag, element.Text), pageURL, decision)
+	}
+	if len(blockedIDs) == 0 {
+		return
+	}
+	data, _ := json.Marshal(blockedIDs)
+	_ = evaluate(fmt.Sprintf(js.SafeModeBlockJS, string(data)), nil)
+}
+
+/*
+*
+拦截安全模式判定为危险的请求，伪造响应或直接失败，请求仍然加入结果
+*/
+func (tab *Tab) handleUnsafeRequest(ctx context.Context, v *fetch.EventRequestPaused, req model.Request, decision safemode.Decision) {
+	policy := tab.config.SafePolicy
+	policy.Record("request", req.Method+" "+req.URL.String(), tab.NavigateReq.URL.String(), decision)
+	tab.markHarBlocked(v, "safe mode: "+decision.Rule)
+	if decision.Action == safemode.ActionFake {
+		body := base64.StdEncoding.EncodeToString([]byte(policy.FakeBody))
+		headers := []*fetch.HeaderEntry{{Name: "Content-Type", Value: "application/json"}}
+		_ = fetch.FulfillRequest(v.RequestID, int64(policy.FakeStatus)).WithResponseHeaders(headers).WithBody(body).Do(ctx)
+	} else {
+		_ = fetch.FailRequest(v.RequestID, network.ErrorReasonBlockedByClient).Do(ctx)
+	}
+	if tab.IsNavigatorRequest(v.NetworkID.String()) {
+		req.Source = config.FromNavigation
+	} else {
+		req.Source = GetSourceByResourceType(v.ResourceType)
+	}
+	tab.addNetworkResult(v.NetworkID.String(), req)
+}
@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/js"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ttacon/chalk"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+var loadMoreTextRegex = regexp.MustCompile("(?i)" + js.LoadMoreTextPattern)
+
+/*
+*
+滚动页面触发懒加载，之后点击加载更多和分页控件，有点击时再次滚动
+DOM监听仍然生效，新出现的节点中的链接会被收集
+*/
+func (tab *Tab) scrollPage() {
+	if !scrollEnabled(tab.config.ScrollStepSize, tab.config.ScrollMaxSteps) {
+		return
+	}
+	interval := tab.config.ScrollInterval
+	scrollJS := fmt.Sprintf(js.ScrollPageJS, tab.config.ScrollStepSize, tab.config.ScrollMaxSteps, interval.Milliseconds())
+	scrollTimeout := evaluateTimeout(tab.config.ScrollMaxSteps, interval)
+
+	var steps int
+	if err := tab.EvaluateAwait(scrollJS, scrollTimeout, &steps); err != nil {
+		log.Println(chalk.Red.Color("error: 页面滚动失败, " + err.Error()))
+		return
+	}
+
+	var clicks int
+	clickJS := fmt.Sprintf(js.ClickLoadMoreJS, config.LoadMoreMaxClicks, interval.Milliseconds(), js.LoadMoreTextPattern, config.LoadMoreMaxTextLength)
+	if err := tab.EvaluateAwait(clickJS, evaluateTimeout(config.LoadMoreMaxClicks, interval), &clicks); err != nil {
+		log.Println(chalk.Red.Color("error: 点击加载更多失败, " + err.Error()))
+		return
+	}
+	if clicks > 0 {
+		_ = tab.EvaluateAwait(scrollJS, scrollTimeout, &steps)
+	}
+}
+
+/*
+*
+步长不大于0时会向上滚动或原地不动，不滚动
+*/
+func scrollEnabled(stepSize int, maxSteps int) bool {
+	return stepSize > 0 && maxSteps > 0
+}
+
+/*
+*
+每轮操作后等待 interval，多等待一轮和2秒用于JS执行本身
+*/
+func evaluateTimeout(rounds int, interval time.Duration) time.Duration {
+	return time.Duration(rounds+1)*interval + time.Second*2
+}
+
+/*
+*
+与 ClickLoadMoreJS 中按文本查找分页控件的规则一致
+*/
+func isLoadMoreText(text string) bool {
+	text = strings.TrimSpace(text)
+	length := utf8.RuneCountInString(text)
+	return length > 0 && length <= config.LoadMoreMaxTextLength && loadMoreTextRegex.MatchString(text)
+}
+
+/*
+*
+执行JS并等待Promise完成，获取返回值
+*/
+func (tab *Tab) EvaluateAwait(expression string, timeout time.Duration, res interface{}) error {
+	ctx := tab.GetExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return chromedp.Evaluate(expression, res, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(true)
+	}).Do(tCtx)
+}
@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrollEnabled(t *testing.T) {
+	assert.True(t, scrollEnabled(config.ScrollStepSize, config.ScrollMaxSteps))
+	// 最大滚动次数为0或负数时不滚动
+	assert.False(t, scrollEnabled(config.ScrollStepSize, 0))
+	assert.False(t, scrollEnabled(config.ScrollStepSize, -1))
+	// 负数步长会向上滚动
+	assert.False(t, scrollEnabled(-800, config.ScrollMaxSteps))
+	assert.False(t, scrollEnabled(0, config.ScrollMaxSteps))
+}
+
+func TestEvaluateTimeout(t *testing.T) {
+	assert.Equal(t, 21*300*time.Millisecond+2*time.Second, evaluateTimeout(20, 300*time.Millisecond))
+	assert.Equal(t, 2*time.Second+100*time.Millisecond, evaluateTimeout(0, 100*time.Millisecond))
+	assert.Equal(t, 2*time.Second, evaluateTimeout(config.LoadMoreMaxClicks, 0))
+}
+
+func TestIsLoadMoreText(t *testing.T) {
+	for _, text := range []string{"Load more", "  SHOW ALL ", "View more results", "more", "Next", "next page »", "›", "加载更多", "点击查看更多评论", "下一页", "更多"} {
+		assert.True(t, isLoadMoreText(text), text)
+	}
+	for _, text := range []string{"", "Learn more", "Read more about us", "Download", "Nextcloud", "更多产品介绍", "Load more " + strings.Repeat("x", config.LoadMoreMaxTextLength)} {
+		assert.False(t, isLoadMoreText(text), text)
+	}
+}
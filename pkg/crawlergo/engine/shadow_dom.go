@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"regexp"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+)
+
+var shadowLinkAttrs = []string{"src", "href", "data-url", "data-href"}
+
+/*
+*
+收集开放的 shadow root 中的链接和注释
+普通DOM中的链接由选择器查询收集，这里只处理 shadow DOM 内的节点，iframe 的文档由 frame 爬取处理
+*/
+func CollectShadowLinks(node *cdp.Node) (links []string, comments []string) {
+	var walk func(node *cdp.Node, inShadow bool)
+	walk = func(node *cdp.Node, inShadow bool) {
+		if inShadow {
+			switch node.NodeType {
+			case cdp.NodeTypeElement:
+				for _, attrName := range shadowLinkAttrs {
+					if value := node.AttributeValue(attrName); value != "" {
+						links = append(links, value)
+					}
+				}
+				if node.LocalName == "object" {
+					if value := node.AttributeValue("data"); value != "" {
+						links = append(links, value)
+					}
+				}
+			case cdp.NodeTypeComment:
+				comments = append(comments, node.NodeValue)
+			}
+		}
+		for _, child := range node.Children {
+			walk(child, inShadow)
+		}
+		for _, shadowRoot := range node.ShadowRoots {
+			if shadowRoot.ShadowRootType == cdp.ShadowRootTypeOpen {
+				walk(shadowRoot, true)
+			}
+		}
+	}
+	if node != nil {
+		walk(node, false)
+	}
+	return links, comments
+}
+
+/*
+*
+通过穿透 shadow root 的完整文档收集 shadow DOM 中的链接
+DOM.getDocument 会使之前获取的节点ID失效，需要在其它节点操作完成之后调用
+*/
+func (tab *Tab) collectShadowLinks() {
+	ctx := tab.GetExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+	root, err := dom.GetDocument().WithDepth(-1).WithPierce(true).Do(tCtx)
+	if err != nil {
+		return
+	}
+	links, comments := CollectShadowLinks(root)
+	for _, link := range links {
+		tab.AddResultUrl(config.GET, link, config.FromDOM)
+	}
+	urlRegex := regexp.MustCompile(config.URLRegex)
+	for _, comment := range comments {
+		for _, url := range urlRegex.FindAllString(comment, -1) {
+			tab.AddResultUrl(config.GET, url, config.FromComment)
+		}
+	}
+}
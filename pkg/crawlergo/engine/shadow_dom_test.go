@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectShadowLinks(t *testing.T) {
+	element := func(name string, attrs ...string) *cdp.Node {
+		return &cdp.Node{NodeType: cdp.NodeTypeElement, LocalName: name, NodeName: name, Attributes: attrs}
+	}
+	inner := element("my-item")
+	inner.ShadowRoots = []*cdp.Node{{
+		NodeType:       cdp.NodeTypeDocumentFragment,
+		ShadowRootType: cdp.ShadowRootTypeOpen,
+		Children:       []*cdp.Node{element("a", "href", "/nested")},
+	}}
+	app := element("my-app")
+	app.Children = []*cdp.Node{element("a", "href", "/slotted")}
+	app.ShadowRoots = []*cdp.Node{{
+		NodeType:       cdp.NodeTypeDocumentFragment,
+		ShadowRootType: cdp.ShadowRootTypeOpen,
+		Children: []*cdp.Node{
+			element("a", "href", "/shadow"),
+			element("object", "data", "/object.swf"),
+			element("div", "data-url", "/api/items"),
+			{NodeType: cdp.NodeTypeComment, NodeValue: " TODO /admin/debug "},
+			element("slot"),
+			inner,
+		},
+	}}
+	closed := element("closed-widget")
+	closed.ShadowRoots = []*cdp.Node{{
+		NodeType:       cdp.NodeTypeDocumentFragment,
+		ShadowRootType: cdp.ShadowRootTypeClosed,
+		Children:       []*cdp.Node{element("a", "href", "/closed")},
+	}}
+	body := element("body")
+	body.Children = []*cdp.Node{element("a", "href", "/light"), app, closed}
+	document := &cdp.Node{NodeType: cdp.NodeTypeDocument, Children: []*cdp.Node{body}}
+
+	links, comments := CollectShadowLinks(document)
+	assert.Equal(t, []string{"/shadow", "/object.swf", "/api/items", "/nested"}, links)
+	assert.Equal(t, []string{" TODO /admin/debug "}, comments)
+
+	links, comments = CollectShadowLinks(nil)
+	assert.Empty(t, links)
+	assert.Empty(t, comments)
+}
@@ -0,0 +1,252 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/js"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"log"
+	"time"
+
+	"github.com/ttacon/chalk"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+/*
+*
+状态探索中的一次交互
+*/
+type StateAction struct {
+	Selector string `json:"selector"`
+	Label    string `json:"label"`
+}
+
+func (action StateAction) String() string {
+	if action.Label == "" {
+		return "click " + action.Selector
+	}
+	return fmt.Sprintf("click %s (%s)", action.Selector, action.Label)
+}
+
+/*
+*
+DOM状态，Sequence 为从页面初始加载到达该状态的交互序列
+*/
+type DomState struct {
+	Hash     string
+	Sequence []StateAction
+}
+
+type StateEdge struct {
+	From   string
+	To     string
+	Action StateAction
+}
+
+/*
+*
+DOM状态图，按广度优先的顺序返回待探索的状态
+*/
+type StateGraph struct {
+	Root   string
+	States map[string]*DomState
+	Edges  []StateEdge
+	queue  []*DomState
+}
+
+func NewStateGraph(rootHash string) *StateGraph {
+	root := &DomState{Hash: rootHash}
+	return &StateGraph{
+		Root:   rootHash,
+		States: map[string]*DomState{rootHash: root},
+		queue:  []*DomState{root},
+	}
+}
+
+/*
+*
+记录一次交互产生的状态转移，返回是否到达了新的状态
+*/
+func (g *StateGraph) AddTransition(from string, action StateAction, to string) bool {
+	fromState, ok := g.States[from]
+	if !ok || from == to {
+		return false
+	}
+	g.Edges = append(g.Edges, StateEdge{From: from, To: to, Action: action})
+	if _, ok := g.States[to]; ok {
+		return false
+	}
+	sequence := make([]StateAction, 0, len(fromState.Sequence)+1)
+	sequence = append(sequence, fromState.Sequence...)
+	sequence = append(sequence, action)
+	state := &DomState{Hash: to, Sequence: sequence}
+	g.States[to] = state
+	g.queue = append(g.queue, state)
+	return true
+}
+
+/*
+*
+取出下一个待探索的状态
+*/
+func (g *StateGraph) Next() (*DomState, bool) {
+	if len(g.queue) == 0 {
+		return nil, false
+	}
+	state := g.queue[0]
+	g.queue = g.queue[1:]
+	return state, true
+}
+
+/*
+*
+广度优先探索页面的DOM状态，每次交互后计算DOM哈希，发现新状态则加入队列
+回到某个状态时重新加载页面并重放交互序列，交互次数受 StateMaxActions 限制
+*/
+func (tab *Tab) exploreStates() {
+	rootHash, err := tab.domStateHash()
+	if err != nil {
+		log.Println(chalk.Red.Color("error: 获取DOM状态失败, " + err.Error()))
+		return
+	}
+	graph := NewStateGraph(rootHash)
+	current := rootHash
+	actions := 0
+
+	for actions < tab.config.StateMaxActions {
+		state, ok := graph.Next()
+		if !ok {
+			break
+		}
+		if len(state.Sequence) >= tab.config.StateMaxDepth {
+			continue
+		}
+		if current != state.Hash {
+			if current = tab.restoreState(state); current != state.Hash {
+				continue
+			}
+		}
+		tab.applySafeMode()
+		for _, action := range tab.stateClickables() {
+			if actions >= tab.config.StateMaxActions {
+				break
+			}
+			if current != state.Hash {
+				if current = tab.restoreState(state); current != state.Hash {
+					break
+				}
+			}
+			tab.setEventSequence(append(append([]StateAction{}, state.Sequence...), action))
+			actions++
+			if !tab.clickState(action) {
+				continue
+			}
+			hash, err := tab.domStateHash()
+			if err != nil {
+				break
+			}
+			graph.AddTransition(state.Hash, action, hash)
+			current = hash
+		}
+	}
+	tab.setEventSequence(nil)
+	log.Println(chalk.Green.Color(fmt.Sprintf("状态探索完成: %s 交互 %d 次, 发现状态 %d 个",
+		tab.NavigateReq.URL.String(), actions, len(graph.States))))
+}
+
+/*
+*
+重新加载页面并重放交互序列，返回重放之后的DOM哈希
+*/
+func (tab *Tab) restoreState(state *DomState) string {
+	ctx := tab.GetExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, tab.config.DomContentLoadedTimeout)
+	defer cancel()
+	tab.setRestoring(true)
+	err := chromedp.Navigate(tab.NavigateReq.URL.String()).Do(tCtx)
+	tab.setRestoring(false)
+	if err != nil {
+		log.Println(chalk.Red.Color("error: 状态探索重新加载页面失败, " + err.Error()))
+		return ""
+	}
+	// 新页面需要重新监听DOM变化
+	tab.Evaluate(js.ObserverJS)
+	tab.setEventSequence(state.Sequence)
+	for _, action := range state.Sequence {
+		if !tab.clickState(action) {
+			return ""
+		}
+	}
+	hash, _ := tab.domStateHash()
+	return hash
+}
+
+func (tab *Tab) setRestoring(restoring bool) {
+	tab.lock.Lock()
+	tab.restoring = restoring
+	tab.lock.Unlock()
+}
+
+/*
+*
+是否为重新加载页面时顶层frame的导航请求
+*/
+func (tab *Tab) isRestoreNavigation(v *fetch.EventRequestPaused, req *model.Request) bool {
+	tab.lock.Lock()
+	restoring := tab.restoring
+	tab.lock.Unlock()
+	return restoring && v.ResourceType == network.ResourceTypeDocument && tab.IsTopFrame(v.FrameID.String()) &&
+		req.URL.NavigationUrl() == tab.NavigateReq.URL.NavigationUrl()
+}
+
+func (tab *Tab) domStateHash() (string, error) {
+	ctx := tab.GetExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+	var hash string
+	err := chromedp.Evaluate(js.DomStateHashJS, &hash).Do(tCtx)
+	return hash, err
+}
+
+func (tab *Tab) stateClickables() []StateAction {
+	ctx := tab.GetExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+	var actions []StateAction
+	_ = chromedp.Evaluate(fmt.Sprintf(js.StateClickableJS, config.StateMaxClickables), &actions).Do(tCtx)
+	return actions
+}
+
+/*
+*
+点击元素并等待页面响应
+*/
+func (tab *Tab) clickState(action StateAction) bool {
+	ctx := tab.GetExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+	defer cancel()
+	var clicked bool
+	if err := chromedp.Evaluate(fmt.Sprintf(js.StateClickJS, action.Selector), &clicked).Do(tCtx); err != nil || !clicked {
+		return false
+	}
+	time.Sleep(config.StateActionDelay)
+	return true
+}
+
+/*
+*
+设置当前的交互序列，之后收集到的请求归属于该序列
+*/
+func (tab *Tab) setEventSequence(sequence []StateAction) {
+	var eventSequence []string
+	for _, action := range sequence {
+		eventSequence = append(eventSequence, action.String())
+	}
+	tab.lock.Lock()
+	tab.eventSequence = eventSequence
+	tab.lock.Unlock()
+}
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateGraphBreadthFirst(t *testing.T) {
+	menu := StateAction{Selector: "#menu", Label: "Menu"}
+	tab := StateAction{Selector: "#menu > li:nth-of-type(2)", Label: "Settings"}
+	closeBtn := StateAction{Selector: "#close"}
+
+	graph := NewStateGraph("root")
+	root, ok := graph.Next()
+	assert.True(t, ok)
+	assert.Equal(t, "root", root.Hash)
+	assert.Empty(t, root.Sequence)
+
+	assert.True(t, graph.AddTransition("root", menu, "menu-open"))
+	// 没有改变DOM的交互不产生状态
+	assert.False(t, graph.AddTransition("root", closeBtn, "root"))
+
+	state, ok := graph.Next()
+	assert.True(t, ok)
+	assert.Equal(t, "menu-open", state.Hash)
+	assert.Equal(t, []StateAction{menu}, state.Sequence)
+
+	assert.True(t, graph.AddTransition("menu-open", tab, "settings"))
+	// 回到已知状态只记录边
+	assert.False(t, graph.AddTransition("menu-open", closeBtn, "root"))
+
+	state, ok = graph.Next()
+	assert.True(t, ok)
+	assert.Equal(t, []StateAction{menu, tab}, state.Sequence)
+	assert.Len(t, graph.States, 3)
+	assert.Len(t, graph.Edges, 3)
+
+	_, ok = graph.Next()
+	assert.False(t, ok)
+	assert.False(t, graph.AddTransition("unknown", menu, "x"))
+}
+
+func TestStateActionString(t *testing.T) {
+	assert.Equal(t, "click #menu (Menu)", StateAction{Selector: "#menu", Label: "Menu"}.String())
+	assert.Equal(t, "click #close", StateAction{Selector: "#close"}.String())
+}
+
+func TestIsRestoreNavigation(t *testing.T) {
+	navURL, err := model.GetUrl("https://example.com/search#results")
+	require.NoError(t, err)
+	tab := &Tab{TopFrameId: "top", NavigateReq: model.GetRequest(config.POST, navURL, model.Options{PostData: "q=test"})}
+	reload, err := model.GetUrl("https://example.com/search")
+	require.NoError(t, err)
+	req := model.GetRequest(config.GET, reload)
+	document := &fetch.EventRequestPaused{ResourceType: network.ResourceTypeDocument, FrameID: cdp.FrameID("top")}
+
+	assert.False(t, tab.isRestoreNavigation(document, &req))
+	tab.setRestoring(true)
+	assert.True(t, tab.isRestoreNavigation(document, &req))
+	// 重新加载时的子frame导航和XHR不是重放的导航请求
+	assert.False(t, tab.isRestoreNavigation(&fetch.EventRequestPaused{ResourceType: network.ResourceTypeDocument, FrameID: cdp.FrameID("child")}, &req))
+	assert.False(t, tab.isRestoreNavigation(&fetch.EventRequestPaused{ResourceType: network.ResourceTypeXHR, FrameID: cdp.FrameID("top")}, &req))
+
+	override := tab.overrideNavigateReq(document, &req)
+	assert.Equal(t, config.POST, override.Method)
+	assert.Equal(t, "q=test", override.PostData)
+}
@@ -5,9 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"katanacrawlgo/pkg/challenge"
 	"katanacrawlgo/pkg/crawlergo/config"
 	"katanacrawlgo/pkg/crawlergo/js"
 	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/crawlergo/safemode"
+	"katanacrawlgo/pkg/crawlergo/tools"
+	"katanacrawlgo/pkg/formfill"
+	"katanacrawlgo/pkg/identity"
 	"log"
 	"regexp"
 	"strings"
@@ -17,11 +22,13 @@ import (
 	"github.com/ttacon/chalk"
 
 	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/debugger"
 	"github.com/chromedp/cdproto/dom"
 	"github.com/chromedp/cdproto/fetch"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/cdproto/target"
 	"github.com/chromedp/chromedp"
 	"github.com/gogf/gf/encoding/gcharset"
 )
@@ -39,9 +46,33 @@ type Tab struct {
 	PageBindings     map[string]interface{}
 	FoundRedirection bool
 	DocBodyNodeId    cdp.NodeID
+	Har              *HarRecorder        // 开启HAR记录时不为空
+	RedirectChain    []model.RedirectHop // 导航经过的重定向链
+	Findings         []*model.Finding    // 开启运行时检测时的异常、控制台错误和sink命中
+	Challenge        *challenge.Result   // 导航的页面是验证码或JS挑战页面时不为空
 	config           TabConfig
 
-	lock sync.Mutex
+	lock          sync.Mutex
+	initiators    map[string]*model.Initiator // 先于拦截到达的请求发起者 network RequestID -> *model.Initiator
+	noInitiator   map[string]*model.Request   // 拦截时发起者尚未到达的结果 network RequestID -> *model.Request
+	bundleRoutes  []string                    // JS文件中解析出的前端路由
+	eventSequence []string                    // 状态探索中当前的交互序列
+	interacting   bool                        // 已经开始点击、提交等交互，之后的前端跳转不再视为重定向
+	restoring     bool                        // 状态探索正在重新加载页面
+	remoteBrowser bool                        // 标签页位于远程浏览器中，无法使用本地文件
+	formFrameName string                      // 表单提交使用的隐藏frame名称
+	sinkMarkers   []string                    // sink检测使用的填充标记和URL参数值
+	sinkCanary    string                      // 每个标签页唯一的填充标记，代替默认填充值填入文本框
+	findingKeys   map[string]bool             // 已记录的发现，用于去重
+	navStatus     int                         // 导航响应的状态码
+	navHeaders    map[string]string           // 导航响应的响应头
+	formVariant   string                      // 按选项组合提交表单时，当前提交的取值
+
+	frames        sync.Map // 页面中的frame cdp.FrameID -> *cdp.Frame
+	frameContexts sync.Map // 同进程frame的默认执行上下文 cdp.FrameID -> runtime.ExecutionContextID
+	frameTargets  sync.Map // 自动附加的跨进程frame target.SessionID -> *target.Info
+	frameSessions sync.Map // 已连接的跨进程frame target.ID -> *frameAttach
+	frameCancels  []context.CancelFunc
 
 	WG            sync.WaitGroup //当前Tab页的等待同步计数
 	collectLinkWG sync.WaitGroup
@@ -63,6 +94,21 @@ type TabConfig struct {
 	Proxy                   string
 	CustomFormValues        map[string]string
 	CustomFormKeywordValues map[string]string
+	RouteDiscovery          bool              // 读取前端框架路由表发现SPA路由
+	RecordHar               bool              // 记录网络事件生成HAR
+	ScrollStepSize          int               // 每次滚动的像素
+	ScrollMaxSteps          int               // 最大滚动次数，为0则不滚动
+	ScrollInterval          time.Duration     // 每次滚动后的等待时间
+	StateExplore            bool              // 探索多次交互才能到达的DOM状态
+	StateMaxActions         int               // 状态探索的最大交互次数
+	StateMaxDepth           int               // 交互序列的最大长度
+	SafePolicy              *safemode.Policy  // 安全模式策略，为空则不启用
+	FrameCrawl              bool              // 收集iframe中的链接，并在同域的frame中填充表单、触发事件
+	Identity                *identity.Profile // 浏览器身份配置文件，为空则不模拟
+	SinkTelemetry           bool              // 记录JS异常、控制台错误，以及填充值和URL参数到达危险sink
+	OpenAPIDiscovery        bool              // 识别Swagger UI页面引用的OpenAPI文档
+	FormRules               *formfill.Rules   // 表单填充规则，为空则使用内置规则
+	FormVariants            int               // 每个表单按不同下拉框和单选组取值提交的最大次数，为0则不枚举
 }
 
 type bindingCallPayload struct {
@@ -76,15 +122,33 @@ func NewTab(browser *Browser, navigateReq model.Request, config TabConfig) *Tab
 	tab.ExtraHeaders = map[string]interface{}{}
 	var DOMContentLoadedRun = false
 	tab.Ctx, tab.Cancel = browser.NewTab(config.TabRunTimeout)
+	tab.remoteBrowser = browser.remote
 	for key, value := range browser.ExtraHeaders {
 		navigateReq.Headers[key] = value
 		if key != "Host" {
 			tab.ExtraHeaders[key] = value
 		}
 	}
+	config.Identity = MergeIdentityHeaders(tab.ExtraHeaders, config.Identity)
+	if config.Identity != nil {
+		if navigateReq.Headers == nil {
+			navigateReq.Headers = map[string]interface{}{}
+		}
+		MergeIdentityHeaders(navigateReq.Headers, config.Identity)
+	}
 	tab.NavigateReq = navigateReq
 	tab.config = config
 	tab.DocBodyNodeId = 0
+	tab.formFrameName = tools.RandSeq(8)
+	tab.initiators = map[string]*model.Initiator{}
+	tab.noInitiator = map[string]*model.Request{}
+	if config.SinkTelemetry {
+		tab.sinkCanary = "Crawlergo" + tools.RandSeq(8)
+		tab.sinkMarkers = SinkMarkers(navigateReq.URL, []string{tab.sinkCanary})
+	}
+	if config.RecordHar {
+		tab.Har = NewHarRecorder("page_"+navigateReq.UniqueId(), navigateReq.URL.String())
+	}
 
 	// 设置请求拦截监听
 	chromedp.ListenTarget(*tab.Ctx, func(v interface{}) {
@@ -95,6 +159,16 @@ func NewTab(browser *Browser, navigateReq model.Request, config TabConfig) *Tab
 				tab.LoaderID = string(v.LoaderID)
 				tab.TopFrameId = string(v.FrameID)
 			}
+			if v.Initiator != nil {
+				tab.recordInitiator(v.RequestID.String(), ConvertInitiator(v.Initiator))
+			}
+			// 导航请求的后端重定向
+			if v.RedirectResponse != nil && tab.IsNavigatorRequest(v.RequestID.String()) {
+				tab.recordServerRedirect(v)
+			}
+			if tab.Har != nil {
+				tab.Har.OnRequestWillBeSent(v)
+			}
 
 		// 请求发出时暂停 即 请求拦截
 		case *fetch.EventRequestPaused:
@@ -105,14 +179,27 @@ func NewTab(browser *Browser, navigateReq model.Request, config TabConfig) *Tab
 		// 解析HTML文档中的URL
 		// 查找当前页面的编码
 		case *network.EventResponseReceived:
-			if v.Response.MimeType == "application/javascript" || v.Response.MimeType == "text/html" || v.Response.MimeType == "application/json" {
+			if tab.Har != nil {
+				tab.Har.OnResponseReceived(v)
+			}
+			if isJSResponse(v) || v.Response.MimeType == "text/html" || v.Response.MimeType == "application/json" {
 				tab.WG.Add(1)
 				go tab.ParseResponseURL(v)
 			}
 			if v.RequestID.String() == tab.NavNetworkID {
+				tab.recordNavResponse(v)
 				tab.WG.Add(1)
 				go tab.GetContentCharset(v)
 			}
+		// 请求结束 记录HAR耗时
+		case *network.EventLoadingFinished:
+			if tab.Har != nil {
+				tab.Har.OnLoadingFinished(v)
+			}
+		case *network.EventLoadingFailed:
+			if tab.Har != nil {
+				tab.Har.OnLoadingFailed(v)
+			}
 		// 处理后端重定向 3XX
 		case *network.EventResponseReceivedExtraInfo:
 			if v.RequestID.String() == tab.NavNetworkID {
@@ -128,6 +215,9 @@ func NewTab(browser *Browser, navigateReq model.Request, config TabConfig) *Tab
 		// 开始执行表单填充 和 执行DOM节点观察函数
 		// 只执行一次
 		case *page.EventDomContentEventFired:
+			if tab.Har != nil {
+				tab.Har.OnPageEvent("DOMContentLoaded", v.Timestamp)
+			}
 			if DOMContentLoadedRun {
 				return
 			}
@@ -136,6 +226,9 @@ func NewTab(browser *Browser, navigateReq model.Request, config TabConfig) *Tab
 			go tab.AfterDOMRun()
 		// Loaded
 		case *page.EventLoadEventFired:
+			if tab.Har != nil {
+				tab.Har.OnPageEvent("load", v.Timestamp)
+			}
 			if DOMContentLoadedRun {
 				return
 			}
@@ -143,11 +236,57 @@ func NewTab(browser *Browser, navigateReq model.Request, config TabConfig) *Tab
 			tab.WG.Add(1)
 			go tab.AfterDOMRun()
 
+		// 记录frame以及frame的执行上下文，用于frame中的链接收集和交互
+		case *page.EventFrameNavigated:
+			tab.frames.Store(v.Frame.ID, v.Frame)
+		case *page.EventFrameDetached:
+			tab.frames.Delete(v.FrameID)
+			tab.frameContexts.Delete(v.FrameID)
+		case *runtime.EventExecutionContextCreated:
+			tab.storeFrameContext(v.Context)
+		case *runtime.EventExecutionContextDestroyed:
+			tab.deleteFrameContext(v.ExecutionContextID)
+		case *runtime.EventExecutionContextsCleared:
+			tab.deleteFrameContext(0)
+		case *target.EventAttachedToTarget:
+			if v.TargetInfo.Type == "iframe" {
+				tab.frameTargets.Store(v.SessionID, v.TargetInfo)
+				// frame出现时立即附加并开启请求拦截，不遗漏frame加载时发出的请求
+				if tab.config.FrameCrawl {
+					tab.WG.Add(1)
+					go func() {
+						defer tab.WG.Done()
+						tab.attachFrameTarget(v.TargetInfo)
+					}()
+				}
+			}
+		case *target.EventDetachedFromTarget:
+			tab.frameTargets.Delete(v.SessionID)
+
 		// close Dialog
 		case *page.EventJavascriptDialogOpening:
 			tab.WG.Add(1)
 			go tab.dismissDialog()
 
+		// 运行时检测：异常、控制台错误和脚本发起的导航
+		case *runtime.EventExceptionThrown:
+			if tab.config.SinkTelemetry {
+				tab.handleException(v)
+			}
+		case *runtime.EventConsoleAPICalled:
+			if tab.config.SinkTelemetry {
+				tab.handleConsoleAPI(v)
+			}
+		case *page.EventFrameRequestedNavigation:
+			if tab.config.SinkTelemetry {
+				tab.handleRequestedNavigation(v)
+			}
+		case *debugger.EventScriptParsed:
+			if tab.config.SinkTelemetry && isEvalScript(v) {
+				tab.WG.Add(1)
+				go tab.handleScriptParsed(v)
+			}
+
 		// handle expose function
 		case *runtime.EventBindingCalled:
 			tab.WG.Add(1)
@@ -168,11 +307,17 @@ func (tab *Tab) Start() {
 			// 开启网络层API
 			network.Enable(),
 			// 开启请求拦截API
-			fetch.Enable().WithHandleAuthRequests(true),
+			// 文档同时在响应阶段拦截，用于替换重定向后的最终页面
+			fetch.Enable().WithHandleAuthRequests(true).WithPatterns([]*fetch.RequestPattern{
+				{URLPattern: "*", RequestStage: fetch.RequestStageRequest},
+				{URLPattern: "*", ResourceType: network.ResourceTypeDocument, RequestStage: fetch.RequestStageResponse},
+			}),
 			// 添加回调函数绑定
 			// XSS-Scan 使用的回调
 			runtime.AddBinding("addLink"),
 			runtime.AddBinding("Test"),
+			// 模拟身份配置文件，指纹脚本需要先于初始化JS执行
+			tab.applyIdentity(),
 			// 初始化执行JS
 			chromedp.ActionFunc(func(ctx context.Context) error {
 				var err error
@@ -182,6 +327,9 @@ func (tab *Tab) Start() {
 				}
 				return nil
 			}),
+			// sink挂钩需要在页面脚本之前执行
+			tab.injectSinkHooks(),
+			tab.enableEvalDetection(),
 			network.SetExtraHTTPHeaders(tab.ExtraHeaders),
 			// 执行导航
 			chromedp.Navigate(tab.NavigateReq.URL.String()),
@@ -206,14 +354,30 @@ func (tab *Tab) Start() {
 	//	log.Println("任务执行等待超时")
 	//}
 	<-waitDone()
+	// 挑战页面不再收集链接
+	if tab.detectChallenge() {
+		tab.closeFrameSessions()
+		return
+	}
 	// 等待收集所有链接
 	tab.collectLinkWG.Add(3)
 	go tab.collectLinks()
 	tab.collectLinkWG.Wait()
+	if tab.config.FrameCrawl {
+		tab.collectFrameLinks()
+		tab.closeFrameSessions()
+	}
 
-	// 识别页面编码 并编码所有URL
+	// 识别页面编码
 	if tab.config.EncodeURLWithCharset {
 		tab.DetectCharset()
+	}
+
+	// 最后收集 shadow DOM 中的链接，之后不再使用节点ID
+	tab.collectShadowLinks()
+
+	// 编码所有URL
+	if tab.config.EncodeURLWithCharset {
 		tab.EncodeAllURLWithCharset()
 	}
 
@@ -237,6 +401,18 @@ func RunWithTimeOut(ctx *context.Context, timeout time.Duration, tasks chromedp.
 添加收集到的URL到结果列表，需要处理Host绑定
 */
 func (tab *Tab) AddResultUrl(method string, _url string, source string) {
+	tab.AddResultUrlWithOptions(method, _url, source, model.Options{})
+}
+
+/*
+*
+添加收集到的URL到结果列表，可以指定请求头和请求体
+*/
+func (tab *Tab) AddResultUrlWithOptions(method string, _url string, source string, options model.Options) {
+	tab.addResultUrl(method, _url, source, options, "")
+}
+
+func (tab *Tab) addResultUrl(method string, _url string, source string, options model.Options, frame string) {
 	navUrl := tab.NavigateReq.URL
 	url, err := model.GetUrl(_url, *navUrl)
 	if err != nil {
@@ -244,7 +420,10 @@ func (tab *Tab) AddResultUrl(method string, _url string, source string) {
 	}
 	option := model.Options{
 		Headers:  map[string]interface{}{},
-		PostData: "",
+		PostData: options.PostData,
+	}
+	for key, value := range options.Headers {
+		option.Headers[key] = value
 	}
 	referer := navUrl.String()
 
@@ -268,8 +447,10 @@ func (tab *Tab) AddResultUrl(method string, _url string, source string) {
 	}
 	req := model.GetRequest(method, url, option)
 	req.Source = source
+	req.Frame = frame
 
 	tab.lock.Lock()
+	req.EventSequence = tab.eventSequence
 	tab.ResultList = append(tab.ResultList, &req)
 	tab.lock.Unlock()
 }
@@ -279,14 +460,63 @@ func (tab *Tab) AddResultUrl(method string, _url string, source string) {
 添加请求到结果列表，拦截请求时处理了Host绑定，此处无需处理
 */
 func (tab *Tab) AddResultRequest(req model.Request) {
+	tab.addNetworkResult("", req)
+}
+
+/*
+*
+添加拦截到的请求，networkID 为浏览器的 network RequestID
+requestWillBeSent 可能晚于 requestPaused 到达，此时先记录结果，发起者到达后再补充
+*/
+func (tab *Tab) addNetworkResult(networkID string, req model.Request) {
 	for key, value := range tab.ExtraHeaders {
 		req.Headers[key] = value
 	}
 	tab.lock.Lock()
-	tab.ResultList = append(tab.ResultList, &req)
+	req.EventSequence = tab.eventSequence
+	result := &req
+	if networkID != "" {
+		if initiator, ok := tab.initiators[networkID]; ok {
+			delete(tab.initiators, networkID)
+			if result.Initiator == nil {
+				result.Initiator = initiator
+			}
+		} else if result.Initiator == nil {
+			tab.noInitiator[networkID] = result
+		}
+	}
+	tab.ResultList = append(tab.ResultList, result)
 	tab.lock.Unlock()
 }
 
+/*
+*
+记录 requestWillBeSent 中的请求发起者，请求已经拦截时直接补充到结果中
+*/
+func (tab *Tab) recordInitiator(networkID string, initiator *model.Initiator) {
+	tab.lock.Lock()
+	defer tab.lock.Unlock()
+	if result, ok := tab.noInitiator[networkID]; ok {
+		delete(tab.noInitiator, networkID)
+		result.Initiator = initiator
+		if result.Variant == "" && tab.formVariant != "" && isFormVariantRequest(*result, false) {
+			result.Variant = tab.formVariant
+		}
+		return
+	}
+	tab.initiators[networkID] = initiator
+}
+
+/*
+*
+拦截时获取已经到达的请求发起者
+*/
+func (tab *Tab) loadInitiator(networkID string) *model.Initiator {
+	tab.lock.Lock()
+	defer tab.lock.Unlock()
+	return tab.initiators[networkID]
+}
+
 /*
 *
 获取当前标签页CDP的执行上下文
@@ -313,16 +543,28 @@ func (tab *Tab) dismissDialog() {
 */
 func (tab *Tab) HandleBindingCalled(event *runtime.EventBindingCalled) {
 	defer tab.WG.Done()
+	if event.Name == sinkBindingName {
+		tab.handleSinkReport(event)
+		return
+	}
 	payload := []byte(event.Payload)
 	var bcPayload bindingCallPayload
 	_ = json.Unmarshal(payload, &bcPayload)
 	if bcPayload.Name == "addLink" && len(bcPayload.Args) > 1 {
-		tab.AddResultUrl(config.GET, bcPayload.Args[0], bcPayload.Args[1])
+		frame := ""
+		if tab.config.FrameCrawl {
+			frame = tab.contextFrameLabel(event.ExecutionContextID)
+		}
+		tab.addResultUrl(config.GET, bcPayload.Args[0], bcPayload.Args[1], model.Options{}, frame)
 	}
 	if bcPayload.Name == "Test" {
 		fmt.Println(bcPayload.Args)
 	}
-	tab.Evaluate(fmt.Sprintf(js.DeliverResultJS, bcPayload.Name, bcPayload.Seq, "s"))
+	// 回调结果交还给发起调用的执行上下文，子frame中的调用不能在顶层页面返回
+	ctx := tab.GetExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+	_, _, _ = runtime.Evaluate(fmt.Sprintf(js.DeliverResultJS, bcPayload.Name, bcPayload.Seq, "s")).WithContextID(event.ExecutionContextID).Do(tCtx)
 }
 
 /*
@@ -336,6 +578,21 @@ func (tab *Tab) Evaluate(expression string) {
 	runtime.Evaluate(expression).Do(tCtx)
 }
 
+/*
+*
+执行JS，res 不为空时解析返回值
+*/
+func (tab *Tab) evaluateResult(expression string, res interface{}) error {
+	if res == nil {
+		tab.Evaluate(expression)
+		return nil
+	}
+	ctx := tab.GetExecutor()
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+	return chromedp.Evaluate(expression, res).Do(tCtx)
+}
+
 /*
 *
 立即根据条件获取Nodes的ID，不等待
@@ -0,0 +1,325 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/js"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/chromedp/cdproto/debugger"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// sink 上报使用的绑定名称，注入的脚本取得引用后会从 window 上删除
+const sinkBindingName = "crawlergoReportSink"
+
+type sinkReport struct {
+	Sink     string `json:"sink"`
+	Marker   string `json:"marker"`
+	Value    string `json:"value"`
+	Location string `json:"location"`
+}
+
+/*
+*
+sink 检测使用的标记：每个标签页唯一的填充标记和页面URL中的参数值
+填充规则中的固定值、过短的参数值和纯字母的单词在页面中很常见，容易误报，不作为标记
+*/
+func SinkMarkers(pageURL *model.URL, canaries []string) []string {
+	var candidates []string
+	if pageURL != nil {
+		for _, values := range pageURL.Query() {
+			candidates = append(candidates, values...)
+		}
+		// 前端路由的参数在 fragment 中，如 #/search?q=xxx
+		fragment := pageURL.Fragment
+		if index := strings.Index(fragment, "?"); index > -1 {
+			fragment = fragment[index+1:]
+		}
+		if fragmentQuery, err := url.ParseQuery(fragment); err == nil {
+			for _, values := range fragmentQuery {
+				candidates = append(candidates, values...)
+			}
+		}
+	}
+	seen := map[string]bool{}
+	var markers []string
+	for _, canary := range canaries {
+		if canary != "" && !seen[canary] {
+			seen[canary] = true
+			markers = append(markers, canary)
+		}
+	}
+	for _, value := range candidates {
+		value = strings.TrimSpace(value)
+		if seen[value] || !isSinkMarker(value) {
+			continue
+		}
+		seen[value] = true
+		markers = append(markers, value)
+	}
+	sort.Strings(markers)
+	return markers
+}
+
+func isSinkMarker(value string) bool {
+	if len(value) < config.SinkMarkerMinLength {
+		return false
+	}
+	if strings.IndexFunc(value, func(r rune) bool { return !unicode.IsLetter(r) }) == -1 {
+		return false
+	}
+	numeric := strings.IndexFunc(value, func(r rune) bool { return !unicode.IsDigit(r) }) == -1
+	return !numeric || len(value) >= config.SinkMarkerMinLength+2
+}
+
+/*
+*
+在文档开始时注入 sink 挂钩脚本
+*/
+func (tab *Tab) injectSinkHooks() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if !tab.config.SinkTelemetry {
+			return nil
+		}
+		if err := runtime.AddBinding(sinkBindingName).Do(ctx); err != nil {
+			return err
+		}
+		markers, _ := json.Marshal(tab.sinkMarkers)
+		_, err := page.AddScriptToEvaluateOnNewDocument(fmt.Sprintf(js.SinkHookJS, string(markers), sinkBindingName,
+			config.MaxFindingsPerPage, config.FindingValueMaxLength)).Do(ctx)
+		return err
+	})
+}
+
+/*
+*
+开启调试器，通过 Debugger.scriptParsed 检测 eval 执行的代码
+eval 不能在页面中挂钩，替换后页面中直接调用的 eval 会变为间接调用，丢失局部作用域
+*/
+func (tab *Tab) enableEvalDetection() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if !tab.config.SinkTelemetry {
+			return nil
+		}
+		if _, err := debugger.Enable().Do(ctx); err != nil {
+			return err
+		}
+		// 页面中的 debugger 语句不暂停
+		return debugger.SetSkipAllPauses(true).Do(ctx)
+	})
+}
+
+/*
+*
+动态执行的代码没有URL，由页面脚本执行时调用栈顶部是页面中的位置
+爬虫通过 Runtime.evaluate 执行的脚本和注入的脚本，调用位置没有URL，不检测
+*/
+func isEvalScript(event *debugger.EventScriptParsed) bool {
+	if event.URL != "" || event.HasSourceURL || event.StackTrace == nil || len(event.StackTrace.CallFrames) == 0 {
+		return false
+	}
+	return event.StackTrace.CallFrames[0].URL != ""
+}
+
+/*
+*
+获取 eval 执行的代码，包含标记时记录为 eval sink
+*/
+func (tab *Tab) handleScriptParsed(event *debugger.EventScriptParsed) {
+	defer tab.WG.Done()
+	tCtx, cancel := context.WithTimeout(tab.GetExecutor(), time.Second*2)
+	defer cancel()
+	source, _, err := debugger.GetScriptSource(event.ScriptID).Do(tCtx)
+	if err != nil {
+		return
+	}
+	callFrame := event.StackTrace.CallFrames[0]
+	location := fmt.Sprintf("%s:%d:%d", callFrame.URL, callFrame.LineNumber+1, callFrame.ColumnNumber+1)
+	if finding := evalFinding(tab.sinkMarkers, source, location); finding != nil {
+		finding.Frame = tab.frameOfContext(event.ExecutionContextID)
+		tab.addFinding(finding)
+	}
+}
+
+/*
+*
+eval 执行的代码中包含标记时生成 sink 发现
+Function 构造的代码和 setTimeout 的字符串参数已由挂钩脚本上报，不重复记录
+*/
+func evalFinding(markers []string, source string, location string) *model.Finding {
+	if strings.HasPrefix(source, "(function anonymous(") {
+		return nil
+	}
+	for _, marker := range markers {
+		if !strings.Contains(source, marker) {
+			continue
+		}
+		return &model.Finding{
+			Type:     model.FindingSink,
+			Sink:     "eval",
+			Marker:   marker,
+			Value:    source,
+			Location: location,
+		}
+	}
+	return nil
+}
+
+/*
+*
+记录一条发现，同一页面内去重并限制数量
+*/
+func (tab *Tab) addFinding(finding *model.Finding) {
+	finding.Page = tab.NavigateReq.URL.String()
+	finding.Value = truncateFinding(finding.Value)
+	finding.Message = truncateFinding(finding.Message)
+	tab.lock.Lock()
+	defer tab.lock.Unlock()
+	if len(tab.Findings) >= config.MaxFindingsPerPage {
+		return
+	}
+	if tab.findingKeys == nil {
+		tab.findingKeys = map[string]bool{}
+	}
+	key := finding.Key()
+	if tab.findingKeys[key] {
+		return
+	}
+	tab.findingKeys[key] = true
+	tab.Findings = append(tab.Findings, finding)
+}
+
+func truncateFinding(value string) string {
+	if len(value) <= config.FindingValueMaxLength {
+		return value
+	}
+	runes := []rune(value)
+	if len(runes) <= config.FindingValueMaxLength {
+		return value
+	}
+	return string(runes[:config.FindingValueMaxLength])
+}
+
+func (tab *Tab) frameOfContext(contextID runtime.ExecutionContextID) string {
+	if !tab.config.FrameCrawl {
+		return ""
+	}
+	return tab.contextFrameLabel(contextID)
+}
+
+/*
+*
+处理注入脚本上报的 sink 调用
+*/
+func (tab *Tab) handleSinkReport(event *runtime.EventBindingCalled) {
+	var report sinkReport
+	if err := json.Unmarshal([]byte(event.Payload), &report); err != nil || report.Sink == "" {
+		return
+	}
+	tab.addFinding(&model.Finding{
+		Type:     model.FindingSink,
+		Frame:    tab.frameOfContext(event.ExecutionContextID),
+		Sink:     report.Sink,
+		Marker:   report.Marker,
+		Value:    report.Value,
+		Location: report.Location,
+	})
+}
+
+/*
+*
+页面中未捕获的异常
+*/
+func (tab *Tab) handleException(event *runtime.EventExceptionThrown) {
+	details := event.ExceptionDetails
+	if details == nil {
+		return
+	}
+	message := details.Text
+	if details.Exception != nil && details.Exception.Description != "" {
+		message = strings.SplitN(details.Exception.Description, "\n", 2)[0]
+	}
+	finding := &model.Finding{
+		Type:    model.FindingException,
+		Frame:   tab.frameOfContext(details.ExecutionContextID),
+		Message: message,
+	}
+	if details.URL != "" {
+		finding.Location = fmt.Sprintf("%s:%d:%d", details.URL, details.LineNumber+1, details.ColumnNumber+1)
+	}
+	tab.addFinding(finding)
+}
+
+/*
+*
+console.error 输出
+*/
+func (tab *Tab) handleConsoleAPI(event *runtime.EventConsoleAPICalled) {
+	if event.Type != runtime.APITypeError && event.Type != runtime.APITypeAssert {
+		return
+	}
+	var parts []string
+	for _, arg := range event.Args {
+		switch {
+		case arg.Value != nil:
+			var value interface{}
+			if err := json.Unmarshal(arg.Value, &value); err == nil {
+				parts = append(parts, fmt.Sprint(value))
+			} else {
+				parts = append(parts, string(arg.Value))
+			}
+		case arg.Description != "":
+			parts = append(parts, strings.SplitN(arg.Description, "\n", 2)[0])
+		default:
+			parts = append(parts, string(arg.Type))
+		}
+	}
+	finding := &model.Finding{
+		Type:    model.FindingConsole,
+		Frame:   tab.frameOfContext(event.ExecutionContextID),
+		Message: strings.Join(parts, " "),
+	}
+	if event.StackTrace != nil && len(event.StackTrace.CallFrames) > 0 {
+		callFrame := event.StackTrace.CallFrames[0]
+		finding.Location = fmt.Sprintf("%s:%d:%d", callFrame.URL, callFrame.LineNumber+1, callFrame.ColumnNumber+1)
+	}
+	tab.addFinding(finding)
+}
+
+/*
+*
+脚本发起的导航，URL中包含标记时视为 location sink
+location 是不可伪造的属性，无法在页面中挂钩，通过 Page.frameRequestedNavigation 检测
+*/
+func (tab *Tab) handleRequestedNavigation(event *page.EventFrameRequestedNavigation) {
+	if event.Reason != page.ClientNavigationReasonScriptInitiated && !strings.HasPrefix(strings.ToLower(event.URL), "javascript:") {
+		return
+	}
+	for _, marker := range tab.sinkMarkers {
+		if !strings.Contains(event.URL, marker) {
+			continue
+		}
+		frame := ""
+		if tab.config.FrameCrawl {
+			frame = tab.frameLabel(event.FrameID)
+		}
+		tab.addFinding(&model.Finding{
+			Type:   model.FindingSink,
+			Frame:  frame,
+			Sink:   "location",
+			Marker: marker,
+			Value:  event.URL,
+		})
+		return
+	}
+}
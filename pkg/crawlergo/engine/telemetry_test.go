@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/debugger"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSinkMarkers(t *testing.T) {
+	pageURL, err := model.GetUrl("https://example.com/search?q=crawler-test1&id=7&page=1234567890&format=json&view=download&lang=en#/detail?name=frag-value")
+	require.NoError(t, err)
+
+	// 填充标记原样使用，URL参数值中的短值、短数字和纯字母单词被排除
+	markers := SinkMarkers(pageURL, []string{"CrawlergoAbCdEfGh", "CrawlergoAbCdEfGh"})
+	assert.Equal(t, []string{"1234567890", "CrawlergoAbCdEfGh", "crawler-test1", "frag-value"}, markers)
+
+	assert.Empty(t, SinkMarkers(nil, nil))
+	pageURL, err = model.GetUrl("https://example.com/?a=true&b=list&c=12345678&d=complete")
+	require.NoError(t, err)
+	assert.Empty(t, SinkMarkers(pageURL, nil))
+}
+
+func TestAddFinding(t *testing.T) {
+	pageURL, _ := model.GetUrl("https://example.com/")
+	tab := &Tab{NavigateReq: model.Request{URL: pageURL}}
+
+	tab.addFinding(&model.Finding{Type: model.FindingSink, Sink: "innerHTML", Marker: "superadmin", Value: "<b>superadmin</b>"})
+	tab.addFinding(&model.Finding{Type: model.FindingSink, Sink: "innerHTML", Marker: "superadmin", Value: "<i>superadmin</i>"})
+	tab.addFinding(&model.Finding{Type: model.FindingConsole, Message: strings.Repeat("错", config.FindingValueMaxLength+10)})
+	require.Len(t, tab.Findings, 2)
+	assert.Equal(t, "https://example.com/", tab.Findings[0].Page)
+	assert.Equal(t, config.FindingValueMaxLength, len([]rune(tab.Findings[1].Message)))
+
+	for i := 0; i < config.MaxFindingsPerPage*2; i++ {
+		tab.addFinding(&model.Finding{Type: model.FindingException, Message: strings.Repeat("x", i+1)})
+	}
+	assert.Len(t, tab.Findings, config.MaxFindingsPerPage)
+}
+
+func TestEvalFinding(t *testing.T) {
+	pageFrame := &runtime.StackTrace{CallFrames: []*runtime.CallFrame{{URL: "https://example.com/app.js", LineNumber: 9}}}
+	evaluateFrame := &runtime.StackTrace{CallFrames: []*runtime.CallFrame{{URL: ""}}}
+	assert.True(t, isEvalScript(&debugger.EventScriptParsed{StackTrace: pageFrame}), "code evaluated by page scripts")
+	assert.False(t, isEvalScript(&debugger.EventScriptParsed{URL: "https://example.com/app.js", StackTrace: pageFrame}), "scripts loaded from a URL")
+	assert.False(t, isEvalScript(&debugger.EventScriptParsed{StackTrace: evaluateFrame}), "scripts run by the crawler")
+	assert.False(t, isEvalScript(&debugger.EventScriptParsed{}))
+
+	markers := []string{"CrawlergoAbCdEfGh"}
+	finding := evalFinding(markers, `var q = "CrawlergoAbCdEfGh"; render(q)`, "https://example.com/app.js:10:1")
+	require.NotNil(t, finding)
+	assert.Equal(t, model.FindingSink, finding.Type)
+	assert.Equal(t, "eval", finding.Sink)
+	assert.Equal(t, "CrawlergoAbCdEfGh", finding.Marker)
+	assert.Equal(t, "https://example.com/app.js:10:1", finding.Location)
+
+	assert.Nil(t, evalFinding(markers, "render(1)", ""))
+	assert.Nil(t, evalFinding(markers, "(function anonymous(\n) {\nCrawlergoAbCdEfGh\n})", ""), "Function is reported by the hooks")
+	assert.Nil(t, evalFinding(nil, "CrawlergoAbCdEfGh", ""))
+}
@@ -0,0 +1,127 @@
+package filter
+
+import (
+	"bufio"
+	"encoding/json"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"os"
+	"sync"
+	"time"
+)
+
+// ReasonKept 未被过滤的请求在统计中的原因
+const ReasonKept = "kept"
+
+// AuditRecord 审计日志中的一条过滤判定
+type AuditRecord struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	URL    string    `json:"url"`
+	Source string    `json:"source,omitempty"`
+	Decision
+	ID        string `json:"id,omitempty"`         // 去重环节和保留的请求的唯一ID
+	Key       string `json:"key,omitempty"`        // 保留的请求标记后的唯一ID
+	WinnerID  string `json:"winner_id,omitempty"`  // 完全重复时，之前相同 id 的记录
+	WinnerKey string `json:"winner_key,omitempty"` // 标记后重复时，之前保留下来相同 key 的记录
+}
+
+// Auditor 记录过滤链的每个判定，按原因统计，可选写入JSONL审计日志
+// 重复的请求通过 winner_id、winner_key 引用之前记录的 id、key，不在内存中保存保留下来的请求
+type Auditor struct {
+	lock   sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	counts map[string]int
+}
+
+/*
+*
+新建审计，path 为空时只统计不写入日志
+*/
+func NewAuditor(path string) (*Auditor, error) {
+	auditor := &Auditor{counts: map[string]int{}}
+	if path == "" {
+		return auditor, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	auditor.file = file
+	auditor.writer = bufio.NewWriter(file)
+	return auditor, nil
+}
+
+/*
+*
+记录一个判定
+*/
+func (a *Auditor) Record(req *model.Request, decision Decision) {
+	reason := decision.Reason
+	if !decision.Filtered {
+		reason = ReasonKept
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.counts[reason]++
+	if a.writer == nil {
+		return
+	}
+
+	record := AuditRecord{
+		Time:     time.Now(),
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Source:   req.Source,
+		Decision: decision,
+	}
+	if decision.Stage == StageDedupe || !decision.Filtered {
+		record.ID = req.UniqueId()
+		switch {
+		case !decision.Filtered:
+			record.Key = decision.Key
+		case decision.Reason == ReasonDuplicate:
+			record.WinnerID = record.ID
+		default:
+			record.WinnerKey = decision.Key
+		}
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	_, _ = a.writer.Write(append(data, '\n'))
+}
+
+/*
+*
+各原因的判定数量，未过滤的请求为 kept
+*/
+func (a *Auditor) Summary() map[string]int {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	summary := make(map[string]int, len(a.counts))
+	for reason, count := range a.counts {
+		summary[reason] = count
+	}
+	return summary
+}
+
+/*
+*
+写入剩余的日志并关闭文件
+*/
+func (a *Auditor) Close() error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.writer.Flush()
+	if closeErr := a.file.Close(); err == nil {
+		err = closeErr
+	}
+	a.file = nil
+	a.writer = nil
+	return err
+}
@@ -0,0 +1,72 @@
+package filter
+
+import (
+	"bufio"
+	"encoding/json"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditor, err := NewAuditor(path)
+	require.Nil(t, err)
+	base := NewSimpleFilter("test.nil.local.com")
+	chain := NewDefaultChain(base, nil, NewSmartFilter(base, false))
+	chain.Auditor = auditor
+
+	for _, rawUrl := range []string{
+		"http://test.nil.local.com/news?id=1",
+		"http://test.nil.local.com/news?id=2",
+		"http://test.nil.local.com/news?id=2",
+		"http://test.nil.local.com/style.css",
+		"http://other.local.com/",
+	} {
+		chain.DoFilter(newTestRequest(t, config.GET, rawUrl))
+	}
+	assert.Equal(t, map[string]int{
+		ReasonKept:            1,
+		ReasonMarkedDuplicate: 1,
+		ReasonDuplicate:       1,
+		StageStatic:           1,
+		StageScope:            1,
+	}, auditor.Summary())
+	require.Nil(t, chain.Close())
+
+	file, err := os.Open(path)
+	require.Nil(t, err)
+	defer file.Close()
+	var records []AuditRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record AuditRecord
+		require.Nil(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 5)
+	assert.False(t, records[0].Filtered)
+	assert.Equal(t, "/news?id={{number}}", records[0].Template)
+	assert.Equal(t, ReasonMarkedDuplicate, records[1].Reason)
+	assert.Equal(t, "/news?id={{number}}", records[1].Template)
+	assert.NotEmpty(t, records[0].Key)
+	assert.Equal(t, records[0].Key, records[1].WinnerKey, "marked duplicates reference the kept request by key")
+	assert.Equal(t, records[1].ID, records[2].WinnerID, "exact duplicates reference the earlier record by id")
+	assert.Empty(t, records[2].WinnerKey)
+	assert.Equal(t, StageStatic, records[3].Stage)
+	assert.Empty(t, records[3].ID)
+	assert.Empty(t, records[3].WinnerID)
+	assert.Equal(t, StageScope, records[4].Reason)
+}
+
+func TestAuditor_summaryOnly(t *testing.T) {
+	auditor, err := NewAuditor("")
+	require.Nil(t, err)
+	auditor.Record(newTestRequest(t, config.GET, "http://test.nil.local.com/"), Decision{})
+	assert.Equal(t, map[string]int{ReasonKept: 1}, auditor.Summary())
+	assert.Nil(t, auditor.Close())
+}
@@ -0,0 +1,150 @@
+package filter
+
+import (
+	"katanacrawlgo/pkg/crawlergo/model"
+)
+
+// 过滤链的环节名称
+const (
+	StageScope  = "scope"
+	StageStatic = "static"
+	StageRules  = "rules"
+	StageDedupe = "dedupe"
+)
+
+// 去重环节过滤请求的原因
+const (
+	ReasonDuplicate       = "duplicate"        // 与之前的请求完全相同
+	ReasonMarkedDuplicate = "marked_duplicate" // 参数值和路径标记后与之前的请求相同
+	ReasonOverCount       = "over_count"       // 超过重复统计阈值或全局标记后与之前的请求相同
+)
+
+// Decision 过滤链对请求的判定
+type Decision struct {
+	Filtered bool   `json:"filtered"`
+	Stage    string `json:"stage,omitempty"`    // 过滤请求的环节
+	Reason   string `json:"reason,omitempty"`   // 过滤原因，非去重环节为环节名称
+	Rule     string `json:"rule,omitempty"`     // 命中的规则名称
+	Template string `json:"template,omitempty"` // 标记后的路径和参数
+	Key      string `json:"-"`                  // 去重使用的唯一ID
+}
+
+// Stage 过滤链中的一个环节
+type Stage interface {
+	Name() string
+	// Check 需要过滤则返回 true，以及命中的规则名称
+	Check(req *model.Request) (bool, string)
+}
+
+// Deduplicator 过滤链最后的去重环节
+type Deduplicator interface {
+	Dedupe(req *model.Request) Decision
+	Stats() FilterStats
+	Close() error
+}
+
+// Chain 按顺序经过各个环节的过滤器：范围 → 静态资源 → 用户规则 → 去重
+type Chain struct {
+	Auditor *Auditor // 不为空时记录每个判定
+	stages  []Stage
+	dedupe  Deduplicator
+}
+
+/*
+*
+新建过滤链，请求依次经过 stages，均未过滤时由 dedupe 去重
+*/
+func NewChain(dedupe Deduplicator, stages ...Stage) *Chain {
+	return &Chain{stages: stages, dedupe: dedupe}
+}
+
+/*
+*
+默认的过滤链，范围和静态资源使用 base 的配置，rules 为空则不经过规则环节
+*/
+func NewDefaultChain(base *SimpleFilter, rules []*Rule, dedupe Deduplicator) *Chain {
+	stages := []Stage{ScopeStage(base), StaticStage(base)}
+	if len(rules) > 0 {
+		stages = append(stages, NewRuleStage(rules))
+	}
+	return NewChain(dedupe, stages...)
+}
+
+/*
+*
+判定请求是否需要过滤，以及作出判定的环节和规则
+*/
+func (c *Chain) Decide(req *model.Request) Decision {
+	var kept string
+	for _, stage := range c.stages {
+		filtered, rule := stage.Check(req)
+		if filtered {
+			return Decision{Filtered: true, Stage: stage.Name(), Reason: stage.Name(), Rule: rule}
+		}
+		if rule != "" {
+			kept = rule
+		}
+	}
+	decision := c.dedupe.Dedupe(req)
+	if !decision.Filtered {
+		decision.Rule = kept
+	}
+	return decision
+}
+
+/*
+*
+需要过滤则返回 true
+*/
+func (c *Chain) DoFilter(req *model.Request) bool {
+	decision := c.Decide(req)
+	if c.Auditor != nil {
+		c.Auditor.Record(req, decision)
+	}
+	return decision.Filtered
+}
+
+func (c *Chain) Stats() FilterStats {
+	return c.dedupe.Stats()
+}
+
+func (c *Chain) Close() error {
+	err := c.dedupe.Close()
+	if c.Auditor != nil {
+		if auditErr := c.Auditor.Close(); auditErr != nil {
+			return auditErr
+		}
+	}
+	return err
+}
+
+type stageFunc struct {
+	name  string
+	check func(req *model.Request) bool
+}
+
+func (s stageFunc) Name() string {
+	return s.name
+}
+
+func (s stageFunc) Check(req *model.Request) (bool, string) {
+	return s.check(req), ""
+}
+
+/*
+*
+只保留指定域名的请求，未指定域名时不过滤
+*/
+func ScopeStage(base *SimpleFilter) Stage {
+	return stageFunc{name: StageScope, check: func(req *model.Request) bool {
+		return base.HostLimit != "" && base.DomainFilter(req)
+	}}
+}
+
+/*
+*
+过滤静态资源
+*/
+func StaticStage(base *SimpleFilter) Stage {
+	return stageFunc{name: StageStatic, check: base.StaticFilter}
+}
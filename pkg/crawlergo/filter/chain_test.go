@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	model2 "katanacrawlgo/pkg/crawlergo/model"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRules = `
+rules:
+  - name: keep-api
+    regex: ^https?://[^/]+/api/
+    action: keep
+  - name: read-only
+    methods: [PUT, PATCH]
+  - name: tracking
+    params: ["^utm_", "^fbclid$"]
+  - name: admin-json
+    dsl: contains(path, "/admin/") && content_type == "application/json"
+`
+
+func newTestRequest(t *testing.T, method, rawUrl string, options ...model2.Options) *model2.Request {
+	url, err := model2.GetUrl(rawUrl)
+	require.Nil(t, err)
+	req := model2.GetRequest(method, url, options...)
+	return &req
+}
+
+func TestChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.Nil(t, os.WriteFile(path, []byte(testRules), 0644))
+	rules, err := LoadRules(path)
+	require.Nil(t, err)
+	require.Len(t, rules, 4)
+	assert.Equal(t, RuleDrop, rules[1].Action)
+
+	base := NewSimpleFilter("test.nil.local.com")
+	chain := NewDefaultChain(base, rules, NewSmartFilter(base, false))
+
+	for _, test := range []struct {
+		req      *model2.Request
+		decision Decision
+	}{
+		{newTestRequest(t, config.GET, "http://other.local.com/"), Decision{Filtered: true, Stage: StageScope, Reason: StageScope}},
+		{newTestRequest(t, config.GET, "http://test.nil.local.com/logo.png"), Decision{Filtered: true, Stage: StageStatic, Reason: StageStatic}},
+		{newTestRequest(t, config.GET, "http://test.nil.local.com/news?id=1&utm_source=x"), Decision{Filtered: true, Stage: StageRules, Reason: StageRules, Rule: "tracking"}},
+		{newTestRequest(t, "PUT", "http://test.nil.local.com/profile"), Decision{Filtered: true, Stage: StageRules, Reason: StageRules, Rule: "read-only"}},
+		{newTestRequest(t, "PUT", "http://test.nil.local.com/api/profile"), Decision{Rule: "keep-api"}},
+		{newTestRequest(t, config.POST, "http://test.nil.local.com/admin/users", model2.Options{
+			Headers:  map[string]interface{}{"Content-Type": "application/json"},
+			PostData: `{"name":"a"}`,
+		}), Decision{Filtered: true, Stage: StageRules, Reason: StageRules, Rule: "admin-json"}},
+		{newTestRequest(t, config.GET, "http://test.nil.local.com/news?id=1"), Decision{}},
+		{newTestRequest(t, config.GET, "http://test.nil.local.com/news?id=2"), Decision{Filtered: true, Stage: StageDedupe, Reason: ReasonMarkedDuplicate}},
+		{newTestRequest(t, config.GET, "http://test.nil.local.com/news?id=2"), Decision{Filtered: true, Stage: StageDedupe, Reason: ReasonDuplicate}},
+	} {
+		decision := chain.Decide(test.req)
+		decision.Key, decision.Template = "", ""
+		assert.Equal(t, test.decision, decision, test.req.URL.String())
+	}
+	assert.Equal(t, 3, chain.Stats().UniqueIds)
+	assert.Nil(t, chain.Close())
+}
+
+func TestLoadRules_invalid(t *testing.T) {
+	for _, content := range []string{
+		"rules:\n  - regex: admin\n",
+		"rules:\n  - name: a\n    action: allow\n",
+		"rules:\n  - name: a\n    regex: \"(\"\n",
+		"rules:\n  - name: a\n    dsl: \"contains(path\"\n",
+	} {
+		path := filepath.Join(t.TempDir(), "rules.yaml")
+		require.Nil(t, os.WriteFile(path, []byte(content), 0644))
+		_, err := LoadRules(path)
+		assert.NotNil(t, err, content)
+	}
+
+	rules, err := LoadRules("")
+	assert.Nil(t, err)
+	assert.Empty(t, rules)
+}
@@ -6,4 +6,14 @@ import (
 
 type FilterHandler interface {
 	DoFilter(req *model.Request) bool
+	Stats() FilterStats
+	Close() error
+}
+
+// FilterStats 过滤器去重状态的统计
+type FilterStats struct {
+	UniqueIds   int   `json:"unique_ids"`             // 原始请求的去重集合大小
+	MarkedIds   int   `json:"marked_ids,omitempty"`   // 标记后请求的去重集合大小
+	CounterKeys int   `json:"counter_keys,omitempty"` // 重复统计使用的键数量
+	MemoryBytes int64 `json:"memory_bytes"`           // 去重状态估算的内存占用
 }
@@ -0,0 +1,164 @@
+package filter
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 内置的智能去重配置
+const (
+	ProfileDefault    = "default"
+	ProfileAggressive = "aggressive" // 伪静态页面多的CMS，尽早合并相似的路径和参数
+	ProfileGentle     = "gentle"     // API，保留更多的路径和参数取值
+)
+
+// SmartFilterConfig 智能去重的阈值以及启用的标记
+type SmartFilterConfig struct {
+	MaxParentPathCount         int      `yaml:"max_parent_path_count" json:"max_parent_path_count"`
+	MaxParamKeySingleCount     int      `yaml:"max_param_key_single_count" json:"max_param_key_single_count"`
+	MaxParamKeySingleValues    int      `yaml:"max_param_key_single_values" json:"max_param_key_single_values"`
+	MaxParamKeyAllCount        int      `yaml:"max_param_key_all_count" json:"max_param_key_all_count"`
+	MaxPathParamEmptyCount     int      `yaml:"max_path_param_empty_count" json:"max_path_param_empty_count"`
+	MaxPathParamKeySymbolCount int      `yaml:"max_path_param_key_symbol_count" json:"max_path_param_key_symbol_count"`
+	DisabledMarks              []string `yaml:"disabled_marks" json:"disabled_marks,omitempty"` // 不使用的标记，如 number、time、uuid、slug
+
+	disabled map[string]bool
+}
+
+/*
+*
+默认配置，与之前固定的阈值相同
+*/
+func DefaultSmartFilterConfig() SmartFilterConfig {
+	return SmartFilterConfig{
+		MaxParentPathCount:         MaxParentPathCount,
+		MaxParamKeySingleCount:     MaxParamKeySingleCount,
+		MaxParamKeySingleValues:    MaxParamKeySingleValues,
+		MaxParamKeyAllCount:        MaxParamKeyAllCount,
+		MaxPathParamEmptyCount:     MaxPathParamEmptyCount,
+		MaxPathParamKeySymbolCount: MaxPathParamKeySymbolCount,
+	}
+}
+
+// SmartFilterProfiles 内置的配置
+var SmartFilterProfiles = map[string]SmartFilterConfig{
+	ProfileDefault: DefaultSmartFilterConfig(),
+	ProfileAggressive: {
+		MaxParentPathCount:         16,
+		MaxParamKeySingleCount:     4,
+		MaxParamKeySingleValues:    2,
+		MaxParamKeyAllCount:        5,
+		MaxPathParamEmptyCount:     5,
+		MaxPathParamKeySymbolCount: 3,
+	},
+	ProfileGentle: {
+		MaxParentPathCount:         128,
+		MaxParamKeySingleCount:     32,
+		MaxParamKeySingleValues:    8,
+		MaxParamKeyAllCount:        50,
+		MaxPathParamEmptyCount:     30,
+		MaxPathParamKeySymbolCount: 20,
+		// API的枚举值常为大写，符号分隔的值多为不同的资源
+		DisabledMarks: []string{markName(UpperMark), markName(MixSymbolMark)},
+	},
+}
+
+// 可以关闭的标记
+var configurableMarks = []string{
+	TooLongMark, NumberMark, ChineseMark, UpperMark, UrlEncodeMark, UnicodeMark, BoolMark, ListMark, TimeMark,
+	MixAlphaNumMark, MixSymbolMark, MixNumMark, NoLowerAlphaMark, MixStringMark, FixParamRepeatMark, FixPathMark,
+	UUIDMark, HashMark, Base64Mark, DateMark, SlugMark, EmailMark,
+}
+
+/*
+*
+标记的名称，即去掉两侧的花括号
+*/
+func markName(mark string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(mark, "{{"), "}}")
+}
+
+/*
+*
+加载智能去重配置，先使用内置配置 profile，再用YAML文件 path 中出现的字段覆盖
+*/
+func LoadSmartFilterConfig(profile string, path string) (SmartFilterConfig, error) {
+	if profile == "" {
+		profile = ProfileDefault
+	}
+	conf, ok := SmartFilterProfiles[profile]
+	if !ok {
+		var names []string
+		for name := range SmartFilterProfiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return SmartFilterConfig{}, fmt.Errorf("unknown smart filter profile %s, available: %s", profile, strings.Join(names, ", "))
+	}
+	conf.DisabledMarks = append([]string{}, conf.DisabledMarks...)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return SmartFilterConfig{}, err
+		}
+		if err := yaml.Unmarshal(data, &conf); err != nil {
+			return SmartFilterConfig{}, err
+		}
+	}
+	if err := conf.compile(); err != nil {
+		return SmartFilterConfig{}, err
+	}
+	return conf, nil
+}
+
+func (c *SmartFilterConfig) compile() error {
+	for name, value := range map[string]int{
+		"max_parent_path_count":           c.MaxParentPathCount,
+		"max_param_key_single_count":      c.MaxParamKeySingleCount,
+		"max_param_key_single_values":     c.MaxParamKeySingleValues,
+		"max_param_key_all_count":         c.MaxParamKeyAllCount,
+		"max_path_param_empty_count":      c.MaxPathParamEmptyCount,
+		"max_path_param_key_symbol_count": c.MaxPathParamKeySymbolCount,
+	} {
+		if value <= 0 {
+			return fmt.Errorf("smart filter %s must be positive", name)
+		}
+	}
+	c.disabled = map[string]bool{}
+	for _, name := range c.DisabledMarks {
+		known := false
+		for _, mark := range configurableMarks {
+			if markName(mark) == name {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown smart filter mark %s", name)
+		}
+		c.disabled[name] = true
+	}
+	return nil
+}
+
+/*
+*
+是否使用该标记
+*/
+func (c *SmartFilterConfig) enabled(mark string) bool {
+	return !c.disabled[markName(mark)]
+}
+
+// SmartFilterCounters 智能去重重复统计的快照，集合统计为不同取值的数量（达到阈值后不再增长）
+type SmartFilterCounters struct {
+	ParamKeyRepeat       map[string]int `json:"param_key_repeat"`        // 参数名组合 -> 出现次数
+	ParamKeySingleValues map[string]int `json:"param_key_single_values"` // 参数名组合+参数名 -> 不同取值数量
+	PathParamKeySymbol   map[string]int `json:"path_param_key_symbol"`   // 路径+参数名 -> 取值被标记的次数
+	ParamKeyAllValues    map[string]int `json:"param_key_all_values"`    // 参数名 -> 所有URL中不同取值数量
+	PathParamEmptyValues map[string]int `json:"path_param_empty_values"` // 路径 -> 空值参数名数量
+	ParentPathValues     map[string]int `json:"parent_path_values"`      // 上级目录 -> 本级目录数量
+	GlobalLocations      int            `json:"global_locations"`        // 全局标记的参数位置数量
+}
@@ -0,0 +1,83 @@
+package filter
+
+import (
+	"fmt"
+	"katanacrawlgo/pkg/crawlergo/config"
+	model2 "katanacrawlgo/pkg/crawlergo/model"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSmartFilterConfig(t *testing.T) {
+	conf, err := LoadSmartFilterConfig("", "")
+	assert.Nil(t, err)
+	assert.Equal(t, MaxParentPathCount, conf.MaxParentPathCount)
+	assert.Equal(t, MaxParamKeySingleValues, conf.MaxParamKeySingleValues)
+
+	conf, err = LoadSmartFilterConfig(ProfileGentle, "")
+	assert.Nil(t, err)
+	assert.False(t, conf.enabled(UpperMark))
+	assert.True(t, conf.enabled(NumberMark))
+
+	path := filepath.Join(t.TempDir(), "smart.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte("max_parent_path_count: 4\ndisabled_marks: [time]\n"), 0644))
+	conf, err = LoadSmartFilterConfig(ProfileAggressive, path)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, conf.MaxParentPathCount)
+	assert.Equal(t, 4, conf.MaxParamKeySingleCount)
+	assert.False(t, conf.enabled(TimeMark))
+	// 内置配置不受文件影响
+	assert.Equal(t, 16, SmartFilterProfiles[ProfileAggressive].MaxParentPathCount)
+
+	_, err = LoadSmartFilterConfig("fast", "")
+	assert.NotNil(t, err)
+
+	assert.Nil(t, os.WriteFile(path, []byte("disabled_marks: [color]\n"), 0644))
+	_, err = LoadSmartFilterConfig("", path)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, os.WriteFile(path, []byte("max_param_key_all_count: 0\n"), 0644))
+	_, err = LoadSmartFilterConfig("", path)
+	assert.NotNil(t, err)
+}
+
+func TestMarkPathWith_disabledMarks(t *testing.T) {
+	conf, err := LoadSmartFilterConfig(ProfileGentle, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "/api/{{upper}}/{{number}}", MarkPath("/api/USERS/12"))
+	assert.Equal(t, "/api/USERS/{{number}}", MarkPathWith("/api/USERS/12", conf))
+}
+
+func TestSmartFilter_profileThresholds(t *testing.T) {
+	newFilter := func(profile string) *SmartFilter {
+		conf, err := LoadSmartFilterConfig(profile, "")
+		assert.Nil(t, err)
+		return NewSmartFilterWithConfig(NewSimpleFilter(""), false, NewMemoryStore(), conf)
+	}
+	kept := map[string]int{}
+	filters := map[string]*SmartFilter{}
+	for _, profile := range []string{ProfileDefault, ProfileAggressive} {
+		filters[profile] = newFilter(profile)
+		for i := 0; i < 40; i++ {
+			url, err := model2.GetUrl(fmt.Sprintf("http://test.nil.local.com/docs/chapter-%c%c", 'a'+i%26, 'a'+i/26))
+			assert.Nil(t, err)
+			req := model2.GetRequest(config.GET, url)
+			if !filters[profile].DoFilter(&req) {
+				kept[profile]++
+			}
+		}
+	}
+	// 伪静态目录超过阈值后合并为一个
+	assert.Equal(t, MaxParentPathCount+1, kept[ProfileDefault])
+	assert.Equal(t, 16+1, kept[ProfileAggressive])
+
+	counters := filters[ProfileAggressive].Counters()
+	assert.Len(t, counters.ParentPathValues, 1)
+	for _, count := range counters.ParentPathValues {
+		assert.Equal(t, 16+1, count)
+	}
+	assert.Equal(t, 16, filters[ProfileAggressive].Config().MaxParentPathCount)
+}
@@ -0,0 +1,207 @@
+package filter
+
+import (
+	"fmt"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Knetic/govaluate"
+	"github.com/projectdiscovery/dsl"
+	"gopkg.in/yaml.v3"
+)
+
+// 命中规则后的处理方式
+const (
+	RuleDrop = "drop" // 过滤请求
+	RuleKeep = "keep" // 放行请求，不再匹配之后的规则，仍然经过去重
+)
+
+// Rule 用户配置的过滤规则，配置的条件全部命中时生效，没有条件则命中所有请求
+type Rule struct {
+	Name    string   `yaml:"name"`
+	Regex   string   `yaml:"regex"`   // 匹配完整URL的正则
+	Methods []string `yaml:"methods"` // 请求方法
+	Params  []string `yaml:"params"`  // 匹配参数名的正则，任意一个URL或请求体参数名命中即可
+	DSL     string   `yaml:"dsl"`     // 与katana输出匹配条件相同的DSL表达式
+	Action  string   `yaml:"action"`  // drop、keep，为空则为 drop
+
+	regex  *regexp.Regexp
+	params []*regexp.Regexp
+	dsl    *govaluate.EvaluableExpression
+}
+
+type ruleFile struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+/*
+*
+从YAML文件加载过滤规则，路径为空时没有规则
+*/
+func LoadRules(path string) ([]*Rule, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file ruleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	for _, rule := range file.Rules {
+		if err := rule.compile(); err != nil {
+			return nil, err
+		}
+	}
+	return file.Rules, nil
+}
+
+func (rule *Rule) compile() error {
+	if rule.Name == "" {
+		return fmt.Errorf("filter rule without name")
+	}
+	switch rule.Action {
+	case "":
+		rule.Action = RuleDrop
+	case RuleDrop, RuleKeep:
+	default:
+		return fmt.Errorf("filter rule %s: unknown action %s", rule.Name, rule.Action)
+	}
+	if rule.Regex != "" {
+		regex, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return fmt.Errorf("filter rule %s: %w", rule.Name, err)
+		}
+		rule.regex = regex
+	}
+	rule.params = nil
+	for _, param := range rule.Params {
+		regex, err := regexp.Compile(param)
+		if err != nil {
+			return fmt.Errorf("filter rule %s: %w", rule.Name, err)
+		}
+		rule.params = append(rule.params, regex)
+	}
+	if rule.DSL != "" {
+		expression, err := govaluate.NewEvaluableExpressionWithFunctions(rule.DSL, dsl.HelperFunctions())
+		if err != nil {
+			return fmt.Errorf("filter rule %s: %w", rule.Name, err)
+		}
+		rule.dsl = expression
+	}
+	return nil
+}
+
+/*
+*
+请求是否命中规则
+*/
+func (rule *Rule) Match(req *model.Request) bool {
+	if len(rule.Methods) > 0 && !containsFold(rule.Methods, req.Method) {
+		return false
+	}
+	if rule.regex != nil && !rule.regex.MatchString(req.URL.String()) {
+		return false
+	}
+	if len(rule.params) > 0 && !rule.matchParams(req) {
+		return false
+	}
+	if rule.dsl != nil {
+		// 表达式中引用了不存在的变量时视为未命中
+		result, err := rule.dsl.Evaluate(dslVariables(req))
+		if err != nil || result != true {
+			return false
+		}
+	}
+	return true
+}
+
+func (rule *Rule) matchParams(req *model.Request) bool {
+	for _, name := range paramNames(req) {
+		for _, regex := range rule.params {
+			if regex.MatchString(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+*
+URL参数和请求体参数的名称
+*/
+func paramNames(req *model.Request) []string {
+	var names []string
+	for name := range req.URL.QueryMap() {
+		names = append(names, name)
+	}
+	if req.PostData != "" {
+		for name := range req.PostDataMap() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+/*
+*
+DSL表达式中可以使用的变量
+*/
+func dslVariables(req *model.Request) map[string]interface{} {
+	contentType, _ := req.Headers["Content-Type"].(string)
+	return map[string]interface{}{
+		"url":           req.URL.String(),
+		"method":        req.Method,
+		"scheme":        req.URL.Scheme,
+		"host":          req.URL.Host,
+		"hostname":      req.URL.Hostname(),
+		"path":          req.URL.Path,
+		"query":         req.URL.RawQuery,
+		"fragment":      req.URL.Fragment,
+		"file_ext":      req.URL.FileExt(),
+		"body":          req.PostData,
+		"content_type":  contentType,
+		"source":        req.Source,
+		"resource_type": req.ResourceType,
+		"params":        strings.Join(paramNames(req), ","),
+	}
+}
+
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
+
+type ruleStage struct {
+	rules []*Rule
+}
+
+/*
+*
+用户规则环节，按顺序匹配，第一条命中的规则决定请求是否过滤
+*/
+func NewRuleStage(rules []*Rule) Stage {
+	return &ruleStage{rules: rules}
+}
+
+func (s *ruleStage) Name() string {
+	return StageRules
+}
+
+func (s *ruleStage) Check(req *model.Request) (bool, string) {
+	for _, rule := range s.rules {
+		if rule.Match(req) {
+			return rule.Action == RuleDrop, rule.Name
+		}
+	}
+	return false, ""
+}
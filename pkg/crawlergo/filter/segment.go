@@ -0,0 +1,94 @@
+package filter
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// REST资源实例常见的取值，路径片段、URL参数值和请求体参数值使用相同的标记
+const (
+	UUIDMark   = "{{uuid}}"
+	HashMark   = "{{hash}}"
+	Base64Mark = "{{base64}}"
+	DateMark   = "{{date}}"
+	SlugMark   = "{{slug}}"
+	EmailMark  = "{{email}}"
+)
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+var hexHashRegex = regexp.MustCompile(`^(?:[0-9a-f]{16,}|[0-9A-F]{16,})$`)
+var base64Regex = regexp.MustCompile(`^[A-Za-z0-9+/_-]{20,}={0,2}$`)
+var jwtRegex = regexp.MustCompile(`^eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*$`)
+var isoDateRegex = regexp.MustCompile(`^\d{4}-(?:0[1-9]|1[0-2])-(?:0[1-9]|[12]\d|3[01])(?:[T ]\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?)?$`)
+var slugRegex = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+){2,}$`)
+var emailRegex = regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}$`)
+
+// 按顺序匹配，UUID和日期需要在哈希、数字之前判断
+var segmentMarks = []string{UUIDMark, DateMark, EmailMark, HashMark, Base64Mark, SlugMark}
+
+/*
+*
+识别路径片段或参数值是否为REST资源实例的取值，返回对应的标记，未识别返回空
+已经标记过的值原样返回，配置中关闭的标记不参与识别
+*/
+func classifySegment(value string, conf *SmartFilterConfig) string {
+	for _, mark := range segmentMarks {
+		if !conf.enabled(mark) {
+			continue
+		}
+		if value == mark {
+			return mark
+		}
+		matched := false
+		switch mark {
+		case UUIDMark:
+			matched = uuidRegex.MatchString(value)
+		case DateMark:
+			matched = isoDateRegex.MatchString(value)
+		case EmailMark:
+			matched = emailRegex.MatchString(value)
+		case HashMark:
+			// 同时包含数字和字母，避免较长的单词或数字被识别为哈希
+			matched = hexHashRegex.MatchString(value) && OneNumberRegex.MatchString(value) && !onlyNumberRegex.MatchString(value)
+		case Base64Mark:
+			// 随机令牌同时包含大写、小写字母和多个数字，避免驼峰命名的路由被识别为令牌
+			matched = jwtRegex.MatchString(value) || (base64Regex.MatchString(value) &&
+				alphaUpperRegex.MatchString(value) && alphaLowerRegex.MatchString(value) && len(OneNumberRegex.FindAllString(value, -1)) >= 2)
+		case SlugMark:
+			// 由三个以上单词组成且带有编号的文章标题，如 12345-how-to-install-go
+			// 不带编号的如 reset-password、terms-and-conditions 一般是不同的路由
+			matched = len(value) >= 16 && slugRegex.MatchString(value) && alphaLowerRegex.MatchString(value) && OneNumberRegex.MatchString(value)
+		}
+		if matched {
+			return mark
+		}
+	}
+	return ""
+}
+
+/*
+*
+Query的Map对象会自动解码，预先标记时编码后的邮箱、令牌等会被当作urlencode处理
+所以先将解码后可以识别的参数值替换为对应的标记
+*/
+func classifyRawQuery(rawQuery string, conf *SmartFilterConfig) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	pairs := strings.Split(rawQuery, "&")
+	for index, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		if !found || value == "" {
+			continue
+		}
+		decoded, err := url.QueryUnescape(value)
+		if err != nil {
+			continue
+		}
+		if mark := classifySegment(decoded, conf); mark != "" {
+			pairs[index] = key + "=" + mark
+		}
+	}
+	return strings.Join(pairs, "&")
+}
@@ -0,0 +1,96 @@
+package filter
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	model2 "katanacrawlgo/pkg/crawlergo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifySegment(t *testing.T) {
+	conf := DefaultSmartFilterConfig()
+	for value, mark := range map[string]string{
+		"3f2a9c1e-8b7d-4e6f-a5c4-1d2e3f4a5b6c":     UUIDMark,
+		"d41d8cd98f00b204e9800998ecf8427e":         HashMark,
+		"DA39A3EE5E6B4B0D3255BFEF95601890AFD80709": HashMark,
+		"aGVsbG8gd29ybGQhIFRoaXMgaXM=":             Base64Mark,
+		"eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig": Base64Mark,
+		"2024-03-15":               DateMark,
+		"2024-03-15T08:30:00Z":     DateMark,
+		"12345-how-to-install-go":  SlugMark,
+		"install-go-1-21-on-linux": SlugMark,
+		"admin@example.com":        EmailMark,
+		UUIDMark:                   UUIDMark,
+		// 常见的路由和取值不应被识别
+		"users":          "",
+		"reset-password": "",
+		// 不带编号的多单词路由
+		"terms-and-conditions":       "",
+		"how-to-install-go-on-linux": "",
+		"frequently-asked-questions": "",
+		"getUserProfileData":         "",
+		"1234567890123456":           "",
+		"2024-13-45":                 "",
+		"deadbeefdeadbeefcafe":       "",
+	} {
+		assert.Equal(t, mark, classifySegment(value, &conf), value)
+	}
+
+	conf, err := LoadSmartFilterConfig("", "")
+	assert.Nil(t, err)
+	conf.disabled[markName(SlugMark)] = true
+	assert.Equal(t, "", classifySegment("12345-how-to-install-go", &conf))
+}
+
+func TestMarkPath_restSegments(t *testing.T) {
+	assert.Equal(t, "/users/{{uuid}}/orders", MarkPath("/users/3f2a9c1e-8b7d-4e6f-a5c4-1d2e3f4a5b6c/orders"))
+	assert.Equal(t, "/files/{{hash}}", MarkPath("/files/e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+	assert.Equal(t, "/archive/{{date}}/{{slug}}", MarkPath("/archive/2024-03-15/12345-how-to-install-go"))
+	assert.Equal(t, "/terms-and-conditions", MarkPath("/terms-and-conditions"))
+	assert.Equal(t, "/help/frequently-asked-questions", MarkPath("/help/frequently-asked-questions"))
+	assert.Equal(t, "/members/{{email}}/profile", MarkPath("/members/admin@example.com/profile"))
+	assert.Equal(t, "/api/v1/reset-password", MarkPath("/api/v1/reset-password"))
+}
+
+func TestDoFilter_restResources(t *testing.T) {
+	restFilter := NewSmartFilter(NewSimpleFilter(""), false)
+	newGet := func(rawUrl string) *model2.Request {
+		url, err := model2.GetUrl(rawUrl)
+		assert.Nil(t, err)
+		req := model2.GetRequest(config.GET, url)
+		return &req
+	}
+	newPost := func(body string) *model2.Request {
+		url, err := model2.GetUrl("http://test.nil.local.com/api/orders")
+		assert.Nil(t, err)
+		req := model2.GetRequest(config.POST, url, model2.Options{
+			Headers:  map[string]interface{}{"Content-Type": "application/json"},
+			PostData: body,
+		})
+		return &req
+	}
+
+	// 同一路由的不同资源实例合并为一个模板
+	assert.False(t, restFilter.DoFilter(newGet("http://test.nil.local.com/users/3f2a9c1e-8b7d-4e6f-a5c4-1d2e3f4a5b6c/orders")))
+	assert.True(t, restFilter.DoFilter(newGet("http://test.nil.local.com/users/9b1c2d3e-4f5a-4b6c-8d7e-0f1a2b3c4d5e/orders")))
+	assert.False(t, restFilter.DoFilter(newGet("http://test.nil.local.com/users/3f2a9c1e-8b7d-4e6f-a5c4-1d2e3f4a5b6c/invoices")))
+
+	// 不带编号的多单词路由是不同的页面
+	assert.False(t, restFilter.DoFilter(newGet("http://test.nil.local.com/terms-and-conditions")))
+	assert.False(t, restFilter.DoFilter(newGet("http://test.nil.local.com/privacy-policy-and-cookies")))
+
+	// URL参数值，编码后的邮箱与路径片段使用相同的标记
+	get := newGet("http://test.nil.local.com/search?owner=admin%40example.com&since=2024-03-15")
+	assert.False(t, restFilter.DoFilter(get))
+	assert.Equal(t, EmailMark, get.Filter.MarkedQueryMap["owner"])
+	assert.Equal(t, DateMark, get.Filter.MarkedQueryMap["since"])
+	assert.True(t, restFilter.DoFilter(newGet("http://test.nil.local.com/search?owner=root%40example.org&since=2023-12-01")))
+
+	// JSON请求体参数值
+	post := newPost(`{"order_id":"3f2a9c1e-8b7d-4e6f-a5c4-1d2e3f4a5b6c","checksum":"d41d8cd98f00b204e9800998ecf8427e"}`)
+	assert.False(t, restFilter.DoFilter(post))
+	assert.Equal(t, UUIDMark, post.Filter.MarkedPostDataMap["order_id"])
+	assert.Equal(t, HashMark, post.Filter.MarkedPostDataMap["checksum"])
+	assert.True(t, restFilter.DoFilter(newPost(`{"order_id":"9b1c2d3e-4f5a-4b6c-8d7e-0f1a2b3c4d5e","checksum":"9e107d9d372bb6826bd81d3542a419d6"}`)))
+}
@@ -9,18 +9,26 @@ import (
 )
 
 type SimpleFilter struct {
-	UniqueSet       mapset.Set
+	UniqueSet       UniqueStore
 	HostLimit       string
 	staticSuffixSet mapset.Set
 }
 
 func NewSimpleFilter(host string) *SimpleFilter {
+	return NewSimpleFilterWithStore(host, NewMemoryStore())
+}
+
+/*
+*
+使用指定的去重集合新建过滤器，集合可以保存在磁盘上或使用布隆过滤器
+*/
+func NewSimpleFilterWithStore(host string, store UniqueStore) *SimpleFilter {
 	staticSuffixSet := config.StaticSuffixSet.Clone()
 
 	for _, suffix := range []string{"js", "css", "json"} {
 		staticSuffixSet.Add(suffix)
 	}
-	s := &SimpleFilter{UniqueSet: mapset.NewSet(), staticSuffixSet: staticSuffixSet, HostLimit: host}
+	s := &SimpleFilter{UniqueSet: store, staticSuffixSet: staticSuffixSet, HostLimit: host}
 	return s
 }
 
@@ -30,7 +38,7 @@ func NewSimpleFilter(host string) *SimpleFilter {
 */
 func (s *SimpleFilter) DoFilter(req *model.Request) bool {
 	if s.UniqueSet == nil {
-		s.UniqueSet = mapset.NewSet()
+		s.UniqueSet = NewMemoryStore()
 	}
 	// 首先判断是否需要过滤域名
 	if s.HostLimit != "" && s.DomainFilter(req) {
@@ -53,14 +61,20 @@ func (s *SimpleFilter) DoFilter(req *model.Request) bool {
 */
 func (s *SimpleFilter) UniqueFilter(req *model.Request) bool {
 	if s.UniqueSet == nil {
-		s.UniqueSet = mapset.NewSet()
+		s.UniqueSet = NewMemoryStore()
 	}
-	if s.UniqueSet.Contains(req.UniqueId()) {
-		return true
-	} else {
-		s.UniqueSet.Add(req.UniqueId())
-		return false
+	return !s.UniqueSet.AddIfAbsent(req.UniqueId())
+}
+
+/*
+*
+过滤链中的去重环节
+*/
+func (s *SimpleFilter) Dedupe(req *model.Request) Decision {
+	if s.UniqueFilter(req) {
+		return Decision{Filtered: true, Stage: StageDedupe, Reason: ReasonDuplicate, Key: req.UniqueId()}
 	}
+	return Decision{Key: req.UniqueId()}
 }
 
 /*
@@ -69,7 +83,7 @@ func (s *SimpleFilter) UniqueFilter(req *model.Request) bool {
 */
 func (s *SimpleFilter) StaticFilter(req *model.Request) bool {
 	if s.UniqueSet == nil {
-		s.UniqueSet = mapset.NewSet()
+		s.UniqueSet = NewMemoryStore()
 	}
 	// 首先将slice转换成map
 
@@ -88,7 +102,7 @@ func (s *SimpleFilter) StaticFilter(req *model.Request) bool {
 */
 func (s *SimpleFilter) DomainFilter(req *model.Request) bool {
 	if s.UniqueSet == nil {
-		s.UniqueSet = mapset.NewSet()
+		s.UniqueSet = NewMemoryStore()
 	}
 	if req.URL.Host == s.HostLimit || req.URL.Hostname() == s.HostLimit {
 		return false
@@ -105,3 +119,28 @@ func (s *SimpleFilter) DomainFilter(req *model.Request) bool {
 	}
 	return true
 }
+
+/*
+*
+去重集合的统计
+*/
+func (s *SimpleFilter) Stats() FilterStats {
+	if s.UniqueSet == nil {
+		return FilterStats{}
+	}
+	return FilterStats{
+		UniqueIds:   s.UniqueSet.Len(),
+		MemoryBytes: s.UniqueSet.MemoryBytes(),
+	}
+}
+
+/*
+*
+释放去重集合，磁盘上的集合会被删除
+*/
+func (s *SimpleFilter) Close() error {
+	if s.UniqueSet == nil {
+		return nil
+	}
+	return s.UniqueSet.Close()
+}
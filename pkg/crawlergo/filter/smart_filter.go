@@ -1,15 +1,18 @@
 package filter
 
 import (
+	"fmt"
 	"go/types"
 	"katanacrawlgo/pkg/crawlergo/config"
 	model2 "katanacrawlgo/pkg/crawlergo/model"
 	"katanacrawlgo/pkg/crawlergo/tools"
+	"katanacrawlgo/pkg/graphql"
 	"log"
 	"regexp"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/ttacon/chalk"
 
@@ -20,21 +23,24 @@ type SmartFilter struct {
 	StrictMode bool
 	*SimpleFilter
 	filterLocationSet          mapset.Set // 非逻辑型参数的位置记录 全局统一标记过滤
-	filterParamKeyRepeatCount  sync.Map
-	filterParamKeySingleValues sync.Map // 所有参数名重复数量统计
-	filterPathParamKeySymbol   sync.Map // 某个path下的某个参数的值出现标记次数统计
-	filterParamKeyAllValues    sync.Map
-	filterPathParamEmptyValues sync.Map
-	filterParentPathValues     sync.Map
-	uniqueMarkedIds            mapset.Set // 标记后的唯一ID，用于去重
+	filterParamKeyRepeatCount  counterMap
+	filterParamKeySingleValues counterMap // 所有参数名重复数量统计
+	filterPathParamKeySymbol   counterMap // 某个path下的某个参数的值出现标记次数统计
+	filterParamKeyAllValues    counterMap
+	filterPathParamEmptyValues counterMap
+	filterParentPathValues     counterMap
+	uniqueMarkedIds            UniqueStore       // 标记后的唯一ID，用于去重
+	config                     SmartFilterConfig // 重复统计的阈值及启用的标记
 }
 
 const (
-	MaxParentPathCount         = 32 // 相对于上一级目录，本级path目录的数量修正最大值
-	MaxParamKeySingleCount     = 8  // 某个URL参数名重复修正最大值
-	MaxParamKeyAllCount        = 10 // 本轮所有URL中某个参数名的重复修正最大值
-	MaxPathParamEmptyCount     = 10 // 某个path下的参数值为空，参数名个数修正最大值
-	MaxPathParamKeySymbolCount = 5  // 某个Path下的某个参数的标记数量超过此值，则该参数被全局标记
+	MaxParentPathCount         = 32     // 相对于上一级目录，本级path目录的数量修正最大值
+	MaxParamKeySingleCount     = 8      // 某个URL参数名重复修正最大值
+	MaxParamKeyAllCount        = 10     // 本轮所有URL中某个参数名的重复修正最大值
+	MaxPathParamEmptyCount     = 10     // 某个path下的参数值为空，参数名个数修正最大值
+	MaxPathParamKeySymbolCount = 5      // 某个Path下的某个参数的标记数量超过此值，则该参数被全局标记
+	MaxParamKeySingleValues    = 3      // 某个URL参数名重复修正时，该参数不同值的数量超过此值才打标记
+	MaxCounterKeys             = 100000 // 每个重复统计中键（路径、参数名组合）的最大数量，超过后新的键不再统计
 )
 
 const (
@@ -76,15 +82,36 @@ var markedStringRegex = regexp.MustCompile(`^{{.+}}$`)
 var htmlReplaceRegex = regexp.MustCompile(`\.shtml|\.html|\.htm`)
 
 func NewSmartFilter(base *SimpleFilter, strictMode bool) *SmartFilter {
+	return NewSmartFilterWithStore(base, strictMode, NewMemoryStore())
+}
+
+/*
+*
+使用指定的集合保存标记后的唯一ID
+重复统计中的集合只需要判断是否超过阈值，元素数量达到阈值后不再增长
+*/
+func NewSmartFilterWithStore(base *SimpleFilter, strictMode bool, markedStore UniqueStore) *SmartFilter {
+	return NewSmartFilterWithConfig(base, strictMode, markedStore, DefaultSmartFilterConfig())
+}
+
+/*
+*
+使用指定的阈值及启用的标记，配置一般由 LoadSmartFilterConfig 加载
+*/
+func NewSmartFilterWithConfig(base *SimpleFilter, strictMode bool, markedStore UniqueStore, conf SmartFilterConfig) *SmartFilter {
+	if conf.disabled == nil {
+		_ = conf.compile()
+	}
 	s := &SmartFilter{}
+	s.config = conf
 	s.filterLocationSet = mapset.NewSet()
-	s.filterParamKeyRepeatCount = sync.Map{}
-	s.filterParamKeySingleValues = sync.Map{}
-	s.filterPathParamKeySymbol = sync.Map{}
-	s.filterParamKeyAllValues = sync.Map{}
-	s.filterPathParamEmptyValues = sync.Map{}
-	s.filterParentPathValues = sync.Map{}
-	s.uniqueMarkedIds = mapset.NewSet()
+	s.filterParamKeyRepeatCount = counterMap{limit: MaxCounterKeys}
+	s.filterParamKeySingleValues = counterMap{limit: MaxCounterKeys}
+	s.filterPathParamKeySymbol = counterMap{limit: MaxCounterKeys}
+	s.filterParamKeyAllValues = counterMap{limit: MaxCounterKeys}
+	s.filterPathParamEmptyValues = counterMap{limit: MaxCounterKeys}
+	s.filterParentPathValues = counterMap{limit: MaxCounterKeys}
+	s.uniqueMarkedIds = markedStore
 	s.SimpleFilter = base
 	s.StrictMode = strictMode
 	return s
@@ -102,7 +129,25 @@ func (s *SmartFilter) DoFilter(req *model2.Request) bool {
 	if s.SimpleFilter.DoFilter(req) {
 		return true
 	}
+	return s.markedFilter(req).Filtered
+}
 
+/*
+*
+过滤链中的去重环节，范围和静态资源由其它环节处理
+*/
+func (s *SmartFilter) Dedupe(req *model2.Request) Decision {
+	if decision := s.SimpleFilter.Dedupe(req); decision.Filtered {
+		return decision
+	}
+	return s.markedFilter(req)
+}
+
+/*
+*
+对标记后的请求去重，判定中记录标记后的唯一ID和模板
+*/
+func (s *SmartFilter) markedFilter(req *model2.Request) Decision {
 	req.Filter.FragmentID = s.calcFragmentID(req.URL.Fragment)
 
 	// 标记
@@ -118,7 +163,7 @@ func (s *SmartFilter) DoFilter(req *model2.Request) bool {
 	// 对标记后的请求进行去重
 	uniqueId := req.Filter.UniqueId
 	if s.uniqueMarkedIds.Contains(uniqueId) {
-		return true
+		return Decision{Filtered: true, Stage: StageDedupe, Reason: ReasonMarkedDuplicate, Key: uniqueId, Template: markedTemplate(req)}
 	}
 
 	// 全局数值型参数标记
@@ -144,12 +189,41 @@ func (s *SmartFilter) DoFilter(req *model2.Request) bool {
 	// 新的ID再次去重
 	newUniqueId := req.Filter.UniqueId
 	if s.uniqueMarkedIds.Contains(newUniqueId) {
-		return true
+		return Decision{Filtered: true, Stage: StageDedupe, Reason: ReasonOverCount, Key: newUniqueId, Template: markedTemplate(req)}
 	}
 
 	// 添加到结果集中
-	s.uniqueMarkedIds.Add(newUniqueId)
-	return false
+	if !s.uniqueMarkedIds.AddIfAbsent(newUniqueId) {
+		return Decision{Filtered: true, Stage: StageDedupe, Reason: ReasonMarkedDuplicate, Key: newUniqueId, Template: markedTemplate(req)}
+	}
+	return Decision{Key: newUniqueId, Template: markedTemplate(req)}
+}
+
+/*
+*
+标记后的路径和参数，用于说明去重时被视为相同的请求
+*/
+func markedTemplate(req *model2.Request) string {
+	params := req.Filter.MarkedQueryMap
+	separator := "?"
+	if req.Method == config.POST || req.Method == config.PUT {
+		params = req.Filter.MarkedPostDataMap
+		separator = " "
+	}
+	template := req.Filter.MarkedPath
+	if len(params) == 0 {
+		return template
+	}
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, params[key]))
+	}
+	return template + separator + strings.Join(pairs, "&")
 }
 
 /*
@@ -174,13 +248,13 @@ func (s *SmartFilter) preQueryMark(rawQuery string) string {
 func (s *SmartFilter) getMark(req *model2.Request) {
 	// 首先是解码前的预先替换
 	todoURL := *(req.URL)
-	todoURL.RawQuery = s.preQueryMark(todoURL.RawQuery)
+	todoURL.RawQuery = s.preQueryMark(classifyRawQuery(todoURL.RawQuery, &s.config))
 
 	// 依次打标记
 	queryMap := todoURL.QueryMap()
 	queryMap = markParamName(queryMap)
 	queryMap = s.markParamValue(queryMap, *req)
-	markedPath := MarkPath(todoURL.Path)
+	markedPath := MarkPathWith(todoURL.Path, s.config)
 
 	// 计算唯一的ID
 	var queryKeyID string
@@ -199,6 +273,7 @@ func (s *SmartFilter) getMark(req *model2.Request) {
 	req.Filter.QueryMapId = queryMapID
 	req.Filter.MarkedPath = markedPath
 	req.Filter.PathId = pathID
+	req.Filter.GraphQLId = getGraphQLID(req)
 
 	// 最后计算标记后的唯一请求ID
 	req.Filter.UniqueId = getMarkedUniqueID(req)
@@ -213,7 +288,7 @@ func (s *SmartFilter) postMark(req *model2.Request) {
 
 	postDataMap = markParamName(postDataMap)
 	postDataMap = s.markParamValue(postDataMap, *req)
-	markedPath := MarkPath(req.URL.Path)
+	markedPath := MarkPathWith(req.URL.Path, s.config)
 
 	// 计算唯一的ID
 	var postDataMapID string
@@ -228,6 +303,7 @@ func (s *SmartFilter) postMark(req *model2.Request) {
 	req.Filter.PostDataId = postDataMapID
 	req.Filter.MarkedPath = markedPath
 	req.Filter.PathId = pathID
+	req.Filter.GraphQLId = getGraphQLID(req)
 
 	// 最后计算标记后的唯一请求ID
 	req.Filter.UniqueId = getMarkedUniqueID(req)
@@ -261,16 +337,24 @@ func markParamName(paramMap map[string]interface{}) map[string]interface{} {
 */
 func (s *SmartFilter) markParamValue(paramMap map[string]interface{}, req model2.Request) map[string]interface{} {
 	markedParamMap := map[string]interface{}{}
+	conf := &s.config
 	for key, value := range paramMap {
+		mark := ""
 		switch value.(type) {
 		case bool:
-			markedParamMap[key] = BoolMark
-			continue
+			mark = BoolMark
 		case types.Slice:
-			markedParamMap[key] = ListMark
-			continue
+			mark = ListMark
 		case float64:
-			markedParamMap[key] = NumberMark
+			mark = NumberMark
+		}
+		if mark != "" {
+			// 标记未启用时保留原值，统计中按字符串处理
+			if conf.enabled(mark) {
+				markedParamMap[key] = mark
+			} else {
+				markedParamMap[key] = fmt.Sprint(value)
+			}
 			continue
 		}
 		// 只处理string类型
@@ -283,41 +367,48 @@ func (s *SmartFilter) markParamValue(paramMap map[string]interface{}, req model2
 			name := req.URL.Hostname() + req.URL.Path + req.Method + key
 			s.filterLocationSet.Add(name)
 			markedParamMap[key] = CustomValueMark
+			// UUID、哈希、令牌、日期、文章标题、邮箱
+		} else if mark := classifySegment(valueStr, conf); mark != "" {
+			markedParamMap[key] = mark
 			// 全大写字母
-		} else if onlyAlphaUpperRegex.MatchString(valueStr) {
+		} else if conf.enabled(UpperMark) && onlyAlphaUpperRegex.MatchString(valueStr) {
 			markedParamMap[key] = UpperMark
 			// 参数值长度大于等于16
-		} else if len(valueStr) >= 16 {
+		} else if conf.enabled(TooLongMark) && len(valueStr) >= 16 {
 			markedParamMap[key] = TooLongMark
 			// 均为数字和一些符号组成
-		} else if onlyNumberRegex.MatchString(valueStr) || onlyNumberRegex.MatchString(numSymbolRegex.ReplaceAllString(valueStr, "")) {
+		} else if conf.enabled(NumberMark) && (onlyNumberRegex.MatchString(valueStr) || onlyNumberRegex.MatchString(numSymbolRegex.ReplaceAllString(valueStr, ""))) {
 			markedParamMap[key] = NumberMark
 			// 存在中文
-		} else if chineseRegex.MatchString(valueStr) {
+		} else if conf.enabled(ChineseMark) && chineseRegex.MatchString(valueStr) {
 			markedParamMap[key] = ChineseMark
 			// urlencode
-		} else if urlencodeRegex.MatchString(valueStr) {
+		} else if conf.enabled(UrlEncodeMark) && urlencodeRegex.MatchString(valueStr) {
 			markedParamMap[key] = UrlEncodeMark
 			// unicode
-		} else if unicodeRegex.MatchString(valueStr) {
+		} else if conf.enabled(UnicodeMark) && unicodeRegex.MatchString(valueStr) {
 			markedParamMap[key] = UnicodeMark
 			// 时间
-		} else if onlyNumberRegex.MatchString(timeSymbolRegex.ReplaceAllString(valueStr, "")) {
+		} else if conf.enabled(TimeMark) && onlyNumberRegex.MatchString(timeSymbolRegex.ReplaceAllString(valueStr, "")) {
 			markedParamMap[key] = TimeMark
 			// 字母加数字
-		} else if onlyAlphaNumRegex.MatchString(valueStr) && numberRegex.MatchString(valueStr) {
+		} else if conf.enabled(MixAlphaNumMark) && onlyAlphaNumRegex.MatchString(valueStr) && numberRegex.MatchString(valueStr) {
 			markedParamMap[key] = MixAlphaNumMark
 			// 含有一些特殊符号
-		} else if hasSpecialSymbol(valueStr) {
+		} else if conf.enabled(MixSymbolMark) && hasSpecialSymbol(valueStr) {
 			markedParamMap[key] = MixSymbolMark
 			// 数字出现的次数超过3，视为数值型参数
-		} else if b := OneNumberRegex.ReplaceAllString(valueStr, "0"); strings.Count(b, "0") >= 3 {
+		} else if b := OneNumberRegex.ReplaceAllString(valueStr, "0"); conf.enabled(MixNumMark) && strings.Count(b, "0") >= 3 {
 			markedParamMap[key] = MixNumMark
 			// 严格模式
 		} else if s.StrictMode {
 			// 无小写字母
 			if !alphaLowerRegex.MatchString(valueStr) {
-				markedParamMap[key] = NoLowerAlphaMark
+				if conf.enabled(NoLowerAlphaMark) {
+					markedParamMap[key] = NoLowerAlphaMark
+				} else {
+					markedParamMap[key] = value
+				}
 				// 常见的值一般为 大写字母、小写字母、数字、下划线的任意组合，组合类型超过三种则视为伪静态
 			} else {
 				count := 0
@@ -333,7 +424,7 @@ func (s *SmartFilter) markParamValue(paramMap map[string]interface{}, req model2
 				if strings.Contains(valueStr, "_") || strings.Contains(valueStr, "-") {
 					count += 1
 				}
-				if count >= 3 {
+				if count >= 3 && conf.enabled(MixStringMark) {
 					markedParamMap[key] = MixStringMark
 				}
 			}
@@ -349,35 +440,45 @@ func (s *SmartFilter) markParamValue(paramMap map[string]interface{}, req model2
 标记路径
 */
 func MarkPath(path string) string {
+	return MarkPathWith(path, DefaultSmartFilterConfig())
+}
+
+/*
+*
+按配置中启用的标记标记路径
+*/
+func MarkPathWith(path string, conf SmartFilterConfig) string {
 	pathParts := strings.Split(path, "/")
 	for index, part := range pathParts {
-		if len(part) >= 32 {
+		if mark := classifySegment(part, &conf); mark != "" {
+			pathParts[index] = mark
+		} else if conf.enabled(TooLongMark) && len(part) >= 32 {
 			pathParts[index] = TooLongMark
-		} else if onlyNumberRegex.MatchString(numSymbolRegex.ReplaceAllString(part, "")) {
+		} else if conf.enabled(NumberMark) && onlyNumberRegex.MatchString(numSymbolRegex.ReplaceAllString(part, "")) {
 			pathParts[index] = NumberMark
 		} else if strings.HasSuffix(part, ".html") || strings.HasSuffix(part, ".htm") || strings.HasSuffix(part, ".shtml") {
 			part = htmlReplaceRegex.ReplaceAllString(part, "")
 			// 大写、小写、数字混合
-			if numberRegex.MatchString(part) && alphaUpperRegex.MatchString(part) && alphaLowerRegex.MatchString(part) {
+			if conf.enabled(MixAlphaNumMark) && numberRegex.MatchString(part) && alphaUpperRegex.MatchString(part) && alphaLowerRegex.MatchString(part) {
 				pathParts[index] = MixAlphaNumMark
 				// 纯数字
-			} else if b := numSymbolRegex.ReplaceAllString(part, ""); onlyNumberRegex.MatchString(b) {
+			} else if b := numSymbolRegex.ReplaceAllString(part, ""); conf.enabled(NumberMark) && onlyNumberRegex.MatchString(b) {
 				pathParts[index] = NumberMark
 			}
 			// 含有特殊符号
-		} else if hasSpecialSymbol(part) {
+		} else if conf.enabled(MixSymbolMark) && hasSpecialSymbol(part) {
 			pathParts[index] = MixSymbolMark
-		} else if chineseRegex.MatchString(part) {
+		} else if conf.enabled(ChineseMark) && chineseRegex.MatchString(part) {
 			pathParts[index] = ChineseMark
-		} else if unicodeRegex.MatchString(part) {
+		} else if conf.enabled(UnicodeMark) && unicodeRegex.MatchString(part) {
 			pathParts[index] = UnicodeMark
-		} else if onlyAlphaUpperRegex.MatchString(part) {
+		} else if conf.enabled(UpperMark) && onlyAlphaUpperRegex.MatchString(part) {
 			pathParts[index] = UpperMark
 			// 均为数字和一些符号组成
-		} else if b := numSymbolRegex.ReplaceAllString(part, ""); onlyNumberRegex.MatchString(b) {
+		} else if b := numSymbolRegex.ReplaceAllString(part, ""); conf.enabled(NumberMark) && onlyNumberRegex.MatchString(b) {
 			pathParts[index] = NumberMark
 			// 数字出现的次数超过3，视为伪静态path
-		} else if b := OneNumberRegex.ReplaceAllString(part, "0"); strings.Count(b, "0") > 3 {
+		} else if b := OneNumberRegex.ReplaceAllString(part, "0"); conf.enabled(MixNumMark) && strings.Count(b, "0") > 3 {
 			pathParts[index] = MixNumMark
 		}
 	}
@@ -427,38 +528,14 @@ func (s *SmartFilter) repeatCountStatistic(req *model2.Request) {
 		for key, value := range req.Filter.MarkedQueryMap {
 			// 某个URL的所有参数名重复数量统计
 			paramQueryKey := queryKeyId + key
-
-			if set, ok := s.filterParamKeySingleValues.Load(paramQueryKey); ok {
-				set := set.(mapset.Set)
-				set.Add(value)
-			} else {
-				s.filterParamKeySingleValues.Store(paramQueryKey, mapset.NewSet(value))
-			}
+			addBounded(&s.filterParamKeySingleValues, paramQueryKey, value, s.config.MaxParamKeySingleValues)
 
 			//本轮所有URL中某个参数重复数量统计
-			if _, ok := s.filterParamKeyAllValues.Load(key); !ok {
-				s.filterParamKeyAllValues.Store(key, mapset.NewSet(value))
-			} else {
-				if v, ok := s.filterParamKeyAllValues.Load(key); ok {
-					set := v.(mapset.Set)
-					if !set.Contains(value) {
-						set.Add(value)
-					}
-				}
-			}
+			addBounded(&s.filterParamKeyAllValues, key, value, s.config.MaxParamKeyAllCount)
 
 			// 如果参数值为空，统计该PATH下的空值参数名个数
 			if value == "" {
-				if _, ok := s.filterPathParamEmptyValues.Load(pathId); !ok {
-					s.filterPathParamEmptyValues.Store(pathId, mapset.NewSet(key))
-				} else {
-					if v, ok := s.filterPathParamEmptyValues.Load(pathId); ok {
-						set := v.(mapset.Set)
-						if !set.Contains(key) {
-							set.Add(key)
-						}
-					}
-				}
+				addBounded(&s.filterPathParamEmptyValues, pathId, key, s.config.MaxPathParamEmptyCount)
 			}
 
 			pathIdKey := pathId + key
@@ -482,16 +559,81 @@ func (s *SmartFilter) repeatCountStatistic(req *model2.Request) {
 	//
 	parentPathId := tools.StrMd5(req.URL.ParentPath())
 	currentPath := strings.Replace(req.Filter.MarkedPath, req.URL.ParentPath(), "", -1)
-	if _, ok := s.filterParentPathValues.Load(parentPathId); !ok {
-		s.filterParentPathValues.Store(parentPathId, mapset.NewSet(currentPath))
-	} else {
-		if v, ok := s.filterParentPathValues.Load(parentPathId); ok {
-			set := v.(mapset.Set)
-			if !set.Contains(currentPath) {
-				set.Add(currentPath)
-			}
+	addBounded(&s.filterParentPathValues, parentPathId, currentPath, s.config.MaxParentPathCount)
+}
+
+/*
+*
+向统计集合中添加元素，集合元素数量超过阈值之后不再添加
+统计只判断集合大小是否超过阈值，限制大小不影响结果
+键的数量由 counterMap 限制，达到上限后新的键不再统计
+*/
+func addBounded(m *counterMap, key string, value interface{}, limit int) {
+	v, ok := m.Load(key)
+	if !ok {
+		if v, ok = m.loadOrAdd(key, mapset.NewSet()); !ok {
+			return
 		}
 	}
+	set := v.(mapset.Set)
+	if set.Cardinality() <= limit {
+		set.Add(value)
+	}
+}
+
+/*
+*
+重复统计使用的map，键的数量达到 limit 后不再添加新的键，已有的键仍然更新
+未统计的路径和参数不会因重复而被标记，但内存不随不同路径、参数组合的数量增长
+*/
+type counterMap struct {
+	sync.Map
+	keys  int64
+	limit int64
+}
+
+/*
+*
+占用一个键的位置，达到上限返回false
+*/
+func (c *counterMap) reserve() bool {
+	if atomic.AddInt64(&c.keys, 1) > c.limit {
+		atomic.AddInt64(&c.keys, -1)
+		return false
+	}
+	return true
+}
+
+func (c *counterMap) Store(key string, value interface{}) {
+	if _, ok := c.Map.Load(key); ok {
+		c.Map.Store(key, value)
+		return
+	}
+	if !c.reserve() {
+		return
+	}
+	if _, loaded := c.Map.LoadOrStore(key, value); loaded {
+		atomic.AddInt64(&c.keys, -1)
+		c.Map.Store(key, value)
+	}
+}
+
+/*
+*
+返回已有的值，不存在时添加 value 并返回，键不存在且达到上限时返回 nil, false
+*/
+func (c *counterMap) loadOrAdd(key string, value interface{}) (interface{}, bool) {
+	if v, ok := c.Map.Load(key); ok {
+		return v, true
+	}
+	if !c.reserve() {
+		return nil, false
+	}
+	v, loaded := c.Map.LoadOrStore(key, value)
+	if loaded {
+		atomic.AddInt64(&c.keys, -1)
+	}
+	return v, true
 }
 
 /*
@@ -502,14 +644,14 @@ func (s *SmartFilter) overCountMark(req *model2.Request) {
 	queryKeyId := req.Filter.QueryKeysId
 	pathId := req.Filter.PathId
 	// 参数不为空，
-	if req.Filter.QueryKeysId != "" {
+	if req.Filter.QueryKeysId != "" && s.config.enabled(FixParamRepeatMark) {
 		// 某个URL的所有参数名重复数量超过阈值 且该参数有超过三个不同的值 则打标记
-		if v, ok := s.filterParamKeyRepeatCount.Load(queryKeyId); ok && v.(int) > MaxParamKeySingleCount {
+		if v, ok := s.filterParamKeyRepeatCount.Load(queryKeyId); ok && v.(int) > s.config.MaxParamKeySingleCount {
 			for key := range req.Filter.MarkedQueryMap {
 				paramQueryKey := queryKeyId + key
 				if set, ok := s.filterParamKeySingleValues.Load(paramQueryKey); ok {
 					set := set.(mapset.Set)
-					if set.Cardinality() > 3 {
+					if set.Cardinality() > s.config.MaxParamKeySingleValues {
 						req.Filter.MarkedQueryMap[key] = FixParamRepeatMark
 					}
 				}
@@ -520,14 +662,14 @@ func (s *SmartFilter) overCountMark(req *model2.Request) {
 			// 所有URL中，某个参数不同的值出现次数超过阈值，打标记去重
 			if paramKeySet, ok := s.filterParamKeyAllValues.Load(key); ok {
 				paramKeySet := paramKeySet.(mapset.Set)
-				if paramKeySet.Cardinality() > MaxParamKeyAllCount {
+				if paramKeySet.Cardinality() > s.config.MaxParamKeyAllCount {
 					req.Filter.MarkedQueryMap[key] = FixParamRepeatMark
 				}
 			}
 
 			pathIdKey := pathId + key
 			// 某个PATH的GET参数值去重标记出现次数超过阈值，则对该PATH的该参数进行全局标记
-			if v, ok := s.filterPathParamKeySymbol.Load(pathIdKey); ok && v.(int) > MaxPathParamKeySymbolCount {
+			if v, ok := s.filterPathParamKeySymbol.Load(pathIdKey); ok && v.(int) > s.config.MaxPathParamKeySymbolCount {
 				req.Filter.MarkedQueryMap[key] = FixParamRepeatMark
 			}
 		}
@@ -535,7 +677,7 @@ func (s *SmartFilter) overCountMark(req *model2.Request) {
 		// 处理某个path下空参数值的参数个数超过阈值 如伪静态： http://bang.360.cn/?chu_xiu
 		if v, ok := s.filterPathParamEmptyValues.Load(pathId); ok {
 			set := v.(mapset.Set)
-			if set.Cardinality() > MaxPathParamEmptyCount {
+			if set.Cardinality() > s.config.MaxPathParamEmptyCount {
 				newMarkerQueryMap := map[string]interface{}{}
 				for key, value := range req.Filter.MarkedQueryMap {
 					if value == "" {
@@ -550,13 +692,13 @@ func (s *SmartFilter) overCountMark(req *model2.Request) {
 	}
 
 	// 处理本级path的伪静态
-	if req.URL.ParentPath() == "" || inCommonScriptSuffix(req.URL.FileExt()) {
+	if req.URL.ParentPath() == "" || inCommonScriptSuffix(req.URL.FileExt()) || !s.config.enabled(FixPathMark) {
 		return
 	}
 	parentPathId := tools.StrMd5(req.URL.ParentPath())
 	if set, ok := s.filterParentPathValues.Load(parentPathId); ok {
 		set := set.(mapset.Set)
-		if set.Cardinality() > MaxParentPathCount {
+		if set.Cardinality() > s.config.MaxParentPathCount {
 			if strings.HasSuffix(req.URL.ParentPath(), "/") {
 				req.Filter.MarkedPath = req.URL.ParentPath() + FixPathMark
 			} else {
@@ -595,7 +737,7 @@ func getMarkedUniqueID(req *model2.Request) string {
 		paramId = req.Filter.PostDataId
 	}
 
-	uniqueStr := req.Method + paramId + req.Filter.PathId + req.URL.Host + req.Filter.FragmentID
+	uniqueStr := req.Method + paramId + req.Filter.PathId + req.URL.Host + req.Filter.FragmentID + req.Filter.GraphQLId + req.Variant
 	if req.RedirectionFlag {
 		uniqueStr += "Redirection"
 	}
@@ -606,6 +748,24 @@ func getMarkedUniqueID(req *model2.Request) string {
 	return tools.StrMd5(uniqueStr)
 }
 
+/*
+*
+GraphQL请求的参数值会被标记，操作签名（操作类型、名称和顶层字段）作为额外的ID
+GET请求的查询在 query 参数中
+*/
+func getGraphQLID(req *model2.Request) string {
+	var signature string
+	if req.PostData != "" {
+		signature = graphql.Signature(req.PostData)
+	} else {
+		signature = graphql.QuerySignature(req.URL.Query().Get("query"))
+	}
+	if signature == "" {
+		return ""
+	}
+	return tools.StrMd5(signature)
+}
+
 /*
 *
 计算请求参数的key标记后的唯一ID
@@ -670,3 +830,77 @@ func hasSpecialSymbol(str string) bool {
 func inCommonScriptSuffix(suffix string) bool {
 	return config.ScriptSuffixSet.Contains(suffix)
 }
+
+/*
+*
+去重集合及重复统计的状态
+*/
+func (s *SmartFilter) Stats() FilterStats {
+	stats := s.SimpleFilter.Stats()
+	stats.MarkedIds = s.uniqueMarkedIds.Len()
+	stats.MemoryBytes += s.uniqueMarkedIds.MemoryBytes()
+	for _, m := range []*counterMap{&s.filterParamKeyRepeatCount, &s.filterParamKeySingleValues, &s.filterPathParamKeySymbol,
+		&s.filterParamKeyAllValues, &s.filterPathParamEmptyValues, &s.filterParentPathValues} {
+		m.Range(func(key, value interface{}) bool {
+			stats.CounterKeys++
+			stats.MemoryBytes += int64(len(key.(string))) + memoryEntryOverhead
+			if set, ok := value.(mapset.Set); ok {
+				stats.MemoryBytes += int64(set.Cardinality()) * memoryEntryOverhead
+			}
+			return true
+		})
+	}
+	return stats
+}
+
+/*
+*
+重复统计的快照，键与统计时使用的ID相同，用于调整阈值时查看各统计的数量
+*/
+func (s *SmartFilter) Counters() SmartFilterCounters {
+	counters := SmartFilterCounters{GlobalLocations: s.filterLocationSet.Cardinality()}
+	for _, item := range []struct {
+		target *map[string]int
+		source *counterMap
+	}{
+		{&counters.ParamKeyRepeat, &s.filterParamKeyRepeatCount},
+		{&counters.ParamKeySingleValues, &s.filterParamKeySingleValues},
+		{&counters.PathParamKeySymbol, &s.filterPathParamKeySymbol},
+		{&counters.ParamKeyAllValues, &s.filterParamKeyAllValues},
+		{&counters.PathParamEmptyValues, &s.filterPathParamEmptyValues},
+		{&counters.ParentPathValues, &s.filterParentPathValues},
+	} {
+		values := map[string]int{}
+		item.source.Range(func(key, value interface{}) bool {
+			switch value := value.(type) {
+			case int:
+				values[key.(string)] = value
+			case mapset.Set:
+				values[key.(string)] = value.Cardinality()
+			}
+			return true
+		})
+		*item.target = values
+	}
+	return counters
+}
+
+/*
+*
+当前使用的阈值及启用的标记
+*/
+func (s *SmartFilter) Config() SmartFilterConfig {
+	return s.config
+}
+
+/*
+*
+释放去重集合
+*/
+func (s *SmartFilter) Close() error {
+	err := s.uniqueMarkedIds.Close()
+	if baseErr := s.SimpleFilter.Close(); baseErr != nil {
+		return baseErr
+	}
+	return err
+}
@@ -52,3 +52,41 @@ func TestDoFilter_countFragment(t *testing.T) {
 		assert.Equal(t, smart.DoFilter(&rq), true)
 	}
 }
+
+func TestDoFilter_graphQLOperations(t *testing.T) {
+	graphQLFilter := NewSmartFilter(NewSimpleFilter(""), false)
+	url, err := model2.GetUrl("http://test.nil.local.com/graphql")
+	assert.Nil(t, err)
+	newReq := func(body string) *model2.Request {
+		req := model2.GetRequest(config.POST, url, model2.Options{
+			Headers:  map[string]interface{}{"Content-Type": "application/json"},
+			PostData: body,
+		})
+		return &req
+	}
+
+	assert.False(t, graphQLFilter.DoFilter(newReq(`{"query":"query GetUser($id: ID!) { user(id: $id) { id } }","variables":{"id":"1"}}`)))
+	// 不同的操作请求体结构相同，不应该被过滤
+	assert.False(t, graphQLFilter.DoFilter(newReq(`{"query":"query GetPosts { posts { id } }","variables":{}}`)))
+	assert.False(t, graphQLFilter.DoFilter(newReq(`{"query":"mutation Login($u: String!) { login(username: $u) { token } }","variables":{"u":"a"}}`)))
+	// 同一操作只有变量值不同，应该被过滤
+	assert.True(t, graphQLFilter.DoFilter(newReq(`{"query":"query GetUser($id: ID!) { user(id: $id) { id } }","variables":{"id":"2"}}`)))
+}
+
+func TestDoFilter_formVariants(t *testing.T) {
+	variantFilter := NewSmartFilter(NewSimpleFilter(""), false)
+	newReq := func(rawUrl, variant string) *model2.Request {
+		url, err := model2.GetUrl(rawUrl)
+		assert.Nil(t, err)
+		req := model2.GetRequest(config.GET, url)
+		req.Variant = variant
+		return &req
+	}
+
+	assert.False(t, variantFilter.DoFilter(newReq("http://test.nil.local.com/search?type=1&q=a", "type=1")))
+	// 不同选项组合提交的表单，参数结构相同也不应该被过滤
+	assert.False(t, variantFilter.DoFilter(newReq("http://test.nil.local.com/search?type=2&q=a", "type=2")))
+	// 没有选项组合时，只有参数值不同应该被过滤
+	assert.False(t, variantFilter.DoFilter(newReq("http://test.nil.local.com/list?type=1", "")))
+	assert.True(t, variantFilter.DoFilter(newReq("http://test.nil.local.com/list?type=2", "")))
+}
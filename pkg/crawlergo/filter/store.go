@@ -0,0 +1,230 @@
+package filter
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"sync"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/projectdiscovery/hmap/store/hybrid"
+)
+
+// 去重集合的存储方式
+const (
+	StoreMemory = "memory" // 全部保存在内存中
+	StoreDisk   = "disk"   // 保存在磁盘上的hmap中，内存占用不随请求数量增长
+	StoreBloom  = "bloom"  // 布隆过滤器，内存固定，存在一定误判率（误判时请求被过滤）
+)
+
+const (
+	DefaultBloomCapacity      = 1000000 // 布隆过滤器预计容纳的元素数量
+	DefaultBloomFalsePositive = 0.001   // 布隆过滤器达到预计容量时的误判率
+	memoryEntryOverhead       = 64      // 内存集合中每个元素除字符串之外的估算开销
+)
+
+// StoreOptions 去重集合的配置
+type StoreOptions struct {
+	Type          string  // memory、disk、bloom，为空则使用内存
+	Capacity      uint    // 布隆过滤器预计容纳的元素数量
+	FalsePositive float64 // 布隆过滤器的误判率
+}
+
+// UniqueStore 去重使用的字符串集合
+type UniqueStore interface {
+	// AddIfAbsent 元素不存在时添加并返回 true
+	AddIfAbsent(key string) bool
+	Contains(key string) bool
+	Len() int
+	// MemoryBytes 估算的内存占用
+	MemoryBytes() int64
+	Close() error
+}
+
+/*
+*
+按配置新建去重集合
+*/
+func NewUniqueStore(options StoreOptions) (UniqueStore, error) {
+	switch options.Type {
+	case "", StoreMemory:
+		return NewMemoryStore(), nil
+	case StoreDisk:
+		return NewDiskStore()
+	case StoreBloom:
+		return NewBloomStore(options.Capacity, options.FalsePositive), nil
+	}
+	return nil, errors.New("unsupported filter store: " + options.Type)
+}
+
+type memoryStore struct {
+	set   mapset.Set
+	lock  sync.Mutex
+	bytes int64
+}
+
+func NewMemoryStore() UniqueStore {
+	return &memoryStore{set: mapset.NewSet()}
+}
+
+func (m *memoryStore) AddIfAbsent(key string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.set.Add(key) {
+		return false
+	}
+	m.bytes += int64(len(key)) + memoryEntryOverhead
+	return true
+}
+
+func (m *memoryStore) Contains(key string) bool {
+	return m.set.Contains(key)
+}
+
+func (m *memoryStore) Len() int {
+	return m.set.Cardinality()
+}
+
+func (m *memoryStore) MemoryBytes() int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.bytes
+}
+
+func (m *memoryStore) Close() error {
+	return nil
+}
+
+type diskStore struct {
+	data  *hybrid.HybridMap
+	lock  sync.Mutex
+	count int
+}
+
+/*
+*
+新建保存在磁盘上的去重集合，与katana的去重使用相同的hmap存储，关闭时删除
+*/
+func NewDiskStore() (UniqueStore, error) {
+	data, err := hybrid.New(hybrid.DefaultDiskOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &diskStore{data: data}, nil
+}
+
+func (d *diskStore) AddIfAbsent(key string) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if _, found := d.data.Get(key); found {
+		return false
+	}
+	if err := d.data.Set(key, nil); err != nil {
+		return true
+	}
+	d.count++
+	return true
+}
+
+func (d *diskStore) Contains(key string) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	_, found := d.data.Get(key)
+	return found
+}
+
+func (d *diskStore) Len() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.count
+}
+
+func (d *diskStore) MemoryBytes() int64 {
+	return 0
+}
+
+func (d *diskStore) Close() error {
+	return d.data.Close()
+}
+
+type bloomStore struct {
+	bits   []uint64
+	hashes uint64
+	lock   sync.Mutex
+	count  int
+}
+
+/*
+*
+新建布隆过滤器，按预计容量和误判率计算位数组大小和哈希次数
+*/
+func NewBloomStore(capacity uint, falsePositive float64) UniqueStore {
+	if capacity == 0 {
+		capacity = DefaultBloomCapacity
+	}
+	if falsePositive <= 0 || falsePositive >= 1 {
+		falsePositive = DefaultBloomFalsePositive
+	}
+	bitCount := math.Ceil(-float64(capacity) * math.Log(falsePositive) / (math.Ln2 * math.Ln2))
+	hashes := math.Max(1, math.Round(bitCount/float64(capacity)*math.Ln2))
+	return &bloomStore{
+		bits:   make([]uint64, uint64(bitCount)/64+1),
+		hashes: uint64(hashes),
+	}
+}
+
+// 双重哈希生成 hashes 个位置
+func (b *bloomStore) locations(key string) []uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum64()
+	h1, h2 := sum&0xffffffff, sum>>32|1
+	size := uint64(len(b.bits)) * 64
+	locations := make([]uint64, b.hashes)
+	for i := uint64(0); i < b.hashes; i++ {
+		locations[i] = (h1 + i*h2) % size
+	}
+	return locations
+}
+
+func (b *bloomStore) AddIfAbsent(key string) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	added := false
+	for _, location := range b.locations(key) {
+		mask := uint64(1) << (location % 64)
+		if b.bits[location/64]&mask == 0 {
+			b.bits[location/64] |= mask
+			added = true
+		}
+	}
+	if added {
+		b.count++
+	}
+	return added
+}
+
+func (b *bloomStore) Contains(key string) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for _, location := range b.locations(key) {
+		if b.bits[location/64]&(uint64(1)<<(location%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *bloomStore) Len() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.count
+}
+
+func (b *bloomStore) MemoryBytes() int64 {
+	return int64(len(b.bits)) * 8
+}
+
+func (b *bloomStore) Close() error {
+	return nil
+}
@@ -0,0 +1,93 @@
+package filter
+
+import (
+	"fmt"
+	"katanacrawlgo/pkg/crawlergo/config"
+	model2 "katanacrawlgo/pkg/crawlergo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUniqueStore(t *testing.T) {
+	for _, storeType := range []string{StoreMemory, StoreDisk, StoreBloom} {
+		store, err := NewUniqueStore(StoreOptions{Type: storeType, Capacity: 1000, FalsePositive: 0.01})
+		assert.Nil(t, err, storeType)
+
+		assert.True(t, store.AddIfAbsent("a"), storeType)
+		assert.False(t, store.AddIfAbsent("a"), storeType)
+		assert.True(t, store.AddIfAbsent("b"), storeType)
+		assert.True(t, store.Contains("b"), storeType)
+		assert.False(t, store.Contains("c"), storeType)
+		assert.Equal(t, 2, store.Len(), storeType)
+		assert.Nil(t, store.Close(), storeType)
+	}
+
+	_, err := NewUniqueStore(StoreOptions{Type: "redis"})
+	assert.NotNil(t, err)
+}
+
+func TestBloomStore_falsePositive(t *testing.T) {
+	store := NewBloomStore(10000, 0.01)
+	for i := 0; i < 10000; i++ {
+		store.AddIfAbsent(fmt.Sprintf("http://test.nil.local.com/page/%d", i))
+	}
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if store.Contains(fmt.Sprintf("http://test.nil.local.com/other/%d", i)) {
+			falsePositives++
+		}
+	}
+	// 达到预计容量时误判率应接近配置值
+	assert.Less(t, falsePositives, 300)
+	assert.Equal(t, int64(len(store.(*bloomStore).bits))*8, store.MemoryBytes())
+}
+
+func TestSmartFilter_boundedCounters(t *testing.T) {
+	base := NewSimpleFilterWithStore("", NewMemoryStore())
+	boundedFilter := NewSmartFilterWithStore(base, false, NewMemoryStore())
+	for i := 0; i < 200; i++ {
+		url, err := model2.GetUrl(fmt.Sprintf("http://test.nil.local.com/list/item%da?page=%d&tag=t%dx", i, i, i))
+		assert.Nil(t, err)
+		req := model2.GetRequest(config.GET, url)
+		boundedFilter.DoFilter(&req)
+	}
+	boundedFilter.filterParamKeyAllValues.Range(func(key, value interface{}) bool {
+		// 统计集合超过阈值后不再增长
+		assert.LessOrEqual(t, value.(interface{ Cardinality() int }).Cardinality(), MaxParamKeyAllCount+1)
+		return true
+	})
+	boundedFilter.filterParentPathValues.Range(func(key, value interface{}) bool {
+		assert.LessOrEqual(t, value.(interface{ Cardinality() int }).Cardinality(), MaxParentPathCount+1)
+		return true
+	})
+
+	stats := boundedFilter.Stats()
+	assert.Equal(t, 200, stats.UniqueIds)
+	assert.Less(t, stats.MarkedIds, 200)
+	assert.Greater(t, stats.CounterKeys, 0)
+	assert.Greater(t, stats.MemoryBytes, int64(0))
+	assert.Nil(t, boundedFilter.Close())
+}
+
+func TestCounterMap_keyLimit(t *testing.T) {
+	counters := counterMap{limit: 3}
+	for i := 0; i < 10; i++ {
+		counters.Store(fmt.Sprintf("path%d", i), 1)
+		addBounded(&counters, fmt.Sprintf("parent%d", i), "child", MaxParentPathCount)
+	}
+	keys := 0
+	counters.Range(func(key, value interface{}) bool {
+		keys++
+		return true
+	})
+	assert.Equal(t, 3, keys)
+
+	// 已有的键达到上限后仍然更新
+	counters.Store("path0", 2)
+	v, ok := counters.Load("path0")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	_, ok = counters.Load("path9")
+	assert.False(t, ok)
+}
@@ -0,0 +1,177 @@
+package crawlergo
+
+import (
+	"encoding/json"
+	"fmt"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/crawlergo/tools"
+	"katanacrawlgo/pkg/crawlergo/tools/requests"
+	"katanacrawlgo/pkg/graphql"
+	"log"
+	"sort"
+
+	"github.com/ttacon/chalk"
+)
+
+/*
+*
+判断请求是否发往GraphQL端点：路径特征或请求中携带GraphQL操作
+*/
+func IsGraphQLRequest(req *model.Request) bool {
+	if graphql.IsEndpointPath(req.URL.Path) {
+		return true
+	}
+	contentType, _ := req.Headers["Content-Type"].(string)
+	return graphql.IsRequest(req.Method, req.URL.String(), contentType, req.PostData)
+}
+
+/*
+*
+从请求列表中收集GraphQL端点，extra 为其它来源（如katana）发现的端点
+返回排序后的端点，以及每个端点的一个请求，用于复用其请求头
+*/
+func CollectGraphQLEndpoints(reqList []*model.Request, extra []string) ([]string, map[string]*model.Request) {
+	samples := map[string]*model.Request{}
+	seen := map[string]bool{}
+	for _, req := range reqList {
+		if !IsGraphQLRequest(req) {
+			continue
+		}
+		endpoint := graphql.Endpoint(req.URL.String())
+		if endpoint == "" {
+			continue
+		}
+		seen[endpoint] = true
+		// 优先使用真实的GraphQL请求，而不是生成的探测请求
+		if sample, ok := samples[endpoint]; !ok || (sample.Source == config.FromGraphQL && req.Source != config.FromGraphQL) {
+			samples[endpoint] = req
+		}
+	}
+	for _, rawURL := range extra {
+		if endpoint := graphql.Endpoint(rawURL); endpoint != "" {
+			seen[endpoint] = true
+		}
+	}
+	endpoints := make([]string, 0, len(seen))
+	for endpoint := range seen {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	return endpoints, samples
+}
+
+/*
+*
+对发现的GraphQL端点发送内省查询，为每个query字段生成一个代表请求
+只内省爬取范围内的端点，范围外的端点只记录
+mutation 只有在 GraphQLMutations 开启时生成，且仍受安全模式规则约束
+*/
+func (t *CrawlerTask) expandGraphQL() {
+	endpoints, samples := CollectGraphQLEndpoints(t.Result.AllReqList, t.Config.GraphQLEndpoints)
+	t.Result.GraphQLEndpoints = endpoints
+	if !t.Config.GraphQLIntrospect {
+		return
+	}
+	var inScope []string
+	for _, endpoint := range endpoints {
+		if t.inScope(endpoint) {
+			inScope = append(inScope, endpoint)
+		}
+	}
+	if len(inScope) > config.GraphQLMaxEndpoints {
+		inScope = inScope[:config.GraphQLMaxEndpoints]
+	}
+	for _, endpoint := range inScope {
+		headers := t.graphQLHeaders(endpoint, samples[endpoint])
+		schema := introspectGraphQL(endpoint, headers, t.Config.Proxy)
+		if schema == nil {
+			continue
+		}
+		operations := graphql.Operations(schema, t.Config.GraphQLMutations)
+		log.Println(chalk.Green.Color(fmt.Sprintf("GraphQL内省成功: %s, 生成%d个操作", endpoint, len(operations))))
+		for _, req := range t.graphQLRequests(endpoint, headers, operations) {
+			t.addAllRequest(req)
+			if !t.filter.DoFilter(req) {
+				t.Result.ReqList = append(t.Result.ReqList, req)
+			}
+		}
+	}
+}
+
+/*
+*
+内省请求复用端点上真实请求的请求头（Cookie、认证信息等），没有则使用自定义请求头
+请求头中可能有认证信息，不复用其它域名上的请求
+*/
+func (t *CrawlerTask) graphQLHeaders(endpoint string, sample *model.Request) map[string]string {
+	var headers map[string]string
+	if sample != nil && len(sample.Headers) > 0 && sameHost(sample.URL, endpoint) {
+		headers = tools.ConvertHeaders(sample.Headers)
+	} else {
+		headers = tools.ConvertHeaders(t.Config.ExtraHeaders)
+	}
+	delete(headers, "Content-Length")
+	headers["Content-Type"] = graphql.ContentType
+	return headers
+}
+
+/*
+*
+发送内省查询，端点关闭了内省或不可达时返回nil
+*/
+func introspectGraphQL(endpoint string, headers map[string]string, proxy string) *graphql.Schema {
+	body, _ := json.Marshal(map[string]string{"query": graphql.IntrospectionQuery})
+	resp, err := requests.Request(config.POST, endpoint, headers, body, &requests.ReqOptions{
+		Timeout: config.GraphQLTimeout,
+		Proxy:   proxy,
+	})
+	if err != nil {
+		log.Println(chalk.Red.Color("error: GraphQL内省请求失败, " + endpoint + ", " + err.Error()))
+		return nil
+	}
+	schema, err := graphql.ParseSchema([]byte(resp.Text))
+	if err != nil {
+		log.Println(chalk.Yellow.Color("GraphQL内省失败: " + endpoint + ", " + err.Error()))
+		return nil
+	}
+	return schema
+}
+
+/*
+*
+将生成的操作转换为请求，安全模式判定为危险的操作会被记录并跳过
+*/
+func (t *CrawlerTask) graphQLRequests(endpoint string, headers map[string]string, operations []*graphql.Operation) []*model.Request {
+	url, err := model.GetUrl(endpoint)
+	if err != nil {
+		return nil
+	}
+	var result []*model.Request
+	for _, operation := range operations {
+		reqHeaders := map[string]interface{}{}
+		for key, value := range headers {
+			reqHeaders[key] = value
+		}
+		req := model.GetRequest(config.POST, url, model.Options{
+			Headers:  reqHeaders,
+			PostData: operation.Body(),
+		})
+		req.Source = config.FromGraphQL
+		req.Proxy = t.Config.Proxy
+		if t.SafePolicy != nil {
+			decision := t.SafePolicy.ClassifyRequest(req.Method, endpoint, req.PostData)
+			if !decision.Allowed() {
+				t.SafePolicy.Record("request", req.Method+" "+endpoint+" "+operation.Name, endpoint, decision)
+				continue
+			}
+		}
+		result = append(result, &req)
+	}
+	return result
+}
+
+func sameHost(url *model.URL, rawURL string) bool {
+	other, err := model.GetUrl(rawURL)
+	return err == nil && url != nil && url.Host == other.Host
+}
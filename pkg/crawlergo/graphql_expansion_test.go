@@ -0,0 +1,124 @@
+package crawlergo
+
+import (
+	"encoding/json"
+	"io"
+	"katanacrawlgo/pkg/crawlergo/config"
+	filter3 "katanacrawlgo/pkg/crawlergo/filter"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/graphql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIntrospection = `{"data":{"__schema":{
+  "queryType":{"name":"Query"},"mutationType":{"name":"Mutation"},
+  "types":[
+    {"kind":"OBJECT","name":"Query","fields":[
+      {"name":"me","args":[],"type":{"kind":"OBJECT","name":"User"}},
+      {"name":"user","args":[{"name":"id","defaultValue":null,"type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"SCALAR","name":"ID"}}}],"type":{"kind":"OBJECT","name":"User"}}
+    ]},
+    {"kind":"OBJECT","name":"Mutation","fields":[
+      {"name":"deleteUser","args":[{"name":"id","defaultValue":null,"type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"SCALAR","name":"ID"}}}],"type":{"kind":"SCALAR","name":"Boolean"}}
+    ]},
+    {"kind":"OBJECT","name":"User","fields":[{"name":"id","args":[],"type":{"kind":"SCALAR","name":"ID"}}]},
+    {"kind":"SCALAR","name":"ID"},{"kind":"SCALAR","name":"Boolean"}
+  ]}}}`
+
+func newGraphQLRequest(t *testing.T, method, rawURL, body string) *model.Request {
+	url, err := model.GetUrl(rawURL)
+	require.NoError(t, err)
+	req := model.GetRequest(method, url, model.Options{
+		Headers:  map[string]interface{}{"Content-Type": "application/json", "Cookie": "session=1"},
+		PostData: body,
+	})
+	return &req
+}
+
+func TestCollectGraphQLEndpoints(t *testing.T) {
+	reqList := []*model.Request{
+		newGraphQLRequest(t, config.GET, "http://test.com/index.html", ""),
+		newGraphQLRequest(t, config.POST, "http://test.com/api", `{"query":"query Me { me { id } }"}`),
+		newGraphQLRequest(t, config.POST, "http://test.com/api?op=Me", `{"query":"query Me { me { id } }"}`),
+		newGraphQLRequest(t, config.GET, "http://test.com/graphiql", ""),
+	}
+	endpoints, samples := CollectGraphQLEndpoints(reqList, []string{"http://other.com/v1/graphql", "/relative"})
+	assert.Equal(t, []string{"http://other.com/v1/graphql", "http://test.com/api", "http://test.com/graphiql"}, endpoints)
+	assert.Equal(t, reqList[1], samples["http://test.com/api"])
+	assert.Nil(t, samples["http://other.com/v1/graphql"])
+}
+
+func TestExpandGraphQL(t *testing.T) {
+	var cookies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]interface{}
+		_ = json.Unmarshal(body, &payload)
+		if payload["query"] == graphql.IntrospectionQuery {
+			cookies = append(cookies, r.Header.Get("Cookie"))
+			_, _ = w.Write([]byte(testIntrospection))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":null}`))
+	}))
+	defer server.Close()
+	var outOfScope int
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outOfScope++
+		_, _ = w.Write([]byte(testIntrospection))
+	}))
+	defer other.Close()
+
+	newTask := func(mutations bool) *CrawlerTask {
+		url, err := model.GetUrl(server.URL)
+		require.NoError(t, err)
+		base := filter3.NewSimpleFilter(url.Host)
+		return &CrawlerTask{
+			Config: &TaskConfig{GraphQLIntrospect: true, GraphQLMutations: mutations},
+			Result: &Result{AllReqList: []*model.Request{
+				newGraphQLRequest(t, config.POST, server.URL+"/graphql", `{"query":"query Me { me { id } }"}`),
+				newGraphQLRequest(t, config.POST, other.URL+"/graphql", `{"query":"query Me { me { id } }"}`),
+			}},
+			filter:    filter3.NewSmartFilter(base, false),
+			scope:     filter3.ScopeStage(base),
+			allReqSet: filter3.NewMemoryStore(),
+		}
+	}
+
+	task := newTask(false)
+	task.expandGraphQL()
+	assert.ElementsMatch(t, []string{server.URL + "/graphql", other.URL + "/graphql"}, task.Result.GraphQLEndpoints)
+	assert.Equal(t, []string{"session=1"}, cookies, "introspection reuses the headers of the observed request")
+	assert.Zero(t, outOfScope, "endpoints out of scope are not introspected")
+	var generated []string
+	for _, req := range task.Result.AllReqList[2:] {
+		assert.Equal(t, config.FromGraphQL, req.Source)
+		assert.Equal(t, graphql.ContentType, req.Headers["Content-Type"])
+		generated = append(generated, graphql.Signature(req.PostData))
+	}
+	assert.Equal(t, []string{"query QueryMe me", "query QueryUser user"}, generated)
+	assert.Len(t, task.Result.ReqList, 2, "different operations are not deduplicated")
+
+	task = newTask(true)
+	task.expandGraphQL()
+	assert.Zero(t, outOfScope)
+	last := task.Result.AllReqList[len(task.Result.AllReqList)-1]
+	assert.True(t, strings.HasPrefix(graphql.Signature(last.PostData), "mutation MutationDeleteUser"))
+}
+
+func TestGraphQLHeaders(t *testing.T) {
+	task := &CrawlerTask{Config: &TaskConfig{ExtraHeaders: map[string]interface{}{"X-Token": "1"}}}
+	sample := newGraphQLRequest(t, config.POST, "https://test.com/graphql", "")
+
+	headers := task.graphQLHeaders("https://test.com/graphql", sample)
+	assert.Equal(t, "session=1", headers["Cookie"])
+	headers = task.graphQLHeaders("https://api.other.com/graphql", sample)
+	assert.Empty(t, headers["Cookie"], "headers are not sent to another host")
+	assert.Equal(t, "1", headers["X-Token"])
+	assert.Equal(t, graphql.ContentType, headers["Content-Type"])
+}
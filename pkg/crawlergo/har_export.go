@@ -0,0 +1,68 @@
+package crawlergo
+
+import (
+	"encoding/json"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/engine"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/ttacon/chalk"
+)
+
+var harFileNameRegex = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+/*
+*
+收集标签页的HAR记录
+tab 模式直接写入文件，target 模式按目标主机合并，任务结束后统一写入
+*/
+func (t *CrawlerTask) collectHar(tab *engine.Tab) {
+	if tab.Har == nil {
+		return
+	}
+	harLog := tab.Har.Log()
+	host := harFileNameRegex.ReplaceAllString(tab.NavigateReq.URL.Host, "_")
+	if t.Config.HarMode != config.HarModeTarget {
+		writeHarFile(filepath.Join(t.Config.HarDir, host+"-"+tab.NavigateReq.UniqueId()+".har"), harLog)
+		return
+	}
+	t.harLock.Lock()
+	defer t.harLock.Unlock()
+	if t.harLogs == nil {
+		t.harLogs = map[string]*engine.HarLog{}
+	}
+	if _, ok := t.harLogs[host]; !ok {
+		t.harLogs[host] = engine.NewHarLog()
+	}
+	engine.MergeHarLog(t.harLogs[host], harLog)
+}
+
+/*
+*
+写入按目标合并的HAR文件
+*/
+func (t *CrawlerTask) writeTargetHar() {
+	t.harLock.Lock()
+	defer t.harLock.Unlock()
+	for host, harLog := range t.harLogs {
+		writeHarFile(filepath.Join(t.Config.HarDir, host+".har"), harLog)
+	}
+}
+
+func writeHarFile(fileName string, harLog *engine.HarLog) {
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		log.Println(chalk.Red.Color("error: 创建HAR目录失败, " + err.Error()))
+		return
+	}
+	data, err := json.Marshal(engine.Har{Log: harLog})
+	if err != nil {
+		log.Println(chalk.Red.Color("error: HAR序列化失败, " + err.Error()))
+		return
+	}
+	if err := os.WriteFile(fileName, data, 0644); err != nil {
+		log.Println(chalk.Red.Color("error: HAR写入" + fileName + "失败, " + err.Error()))
+	}
+}
@@ -8,51 +8,8 @@ import (
 const TabInitJS = `
 (function addTabInitScript () {
 
-	// Pass the Webdriver Test.
-	Object.defineProperty(navigator, 'webdriver', {
-        get: () => false,
-    });
-
-	// Pass the Plugins Length Test.
-	// Overwrite the plugins property to use a custom getter.
-	Object.defineProperty(navigator, 'plugins', {
-        // This just needs to have length > 0 for the current test,
-        // but we could mock the plugins too if necessary.
-        get: () => [1, 2, 3, 4, 5],
-    });
-	
-	// Pass the Chrome Test.
-	// We can mock this in as much depth as we need for the test.
-	window.chrome = {
-		runtime: {},
-	};
+	// 浏览器指纹相关的属性由 identity 配置文件的脚本统一修改
 
-	// Pass the Permissions Test.
-  	const originalQuery = window.navigator.permissions.query;
-	window.navigator.permissions.query = (parameters) => (
-    	parameters.name === 'notifications' ?
-			Promise.resolve({ state: Notification.permission }) :
-			originalQuery(parameters)
-	);
-
-	//Pass the Permissions Test. navigator.userAgent
-	Object.defineProperty(navigator, 'userAgent', {
-        get: () => "Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/79.0.3945.0 Safari/537.36",
-    });
-
-	// 修改浏览器对象的属性
-	Object.defineProperty(navigator, 'platform', {
-		get: function () { return 'win32'; }
-	});
-	
-	Object.defineProperty(navigator, 'language', {
-		get: function () { return 'zh-CN'; }
-	});
-	
-	Object.defineProperty(navigator, 'languages', {
-		get: function () { return ["zh-CN", "zh"]; }
-	});
-	
 	// history api hook
 	window.history.pushState = function(a, b, c) { 
 		window.addLink(c, "HistoryAPI");
@@ -67,23 +24,24 @@ const TabInitJS = `
 		window.addLink(document.location.href, "HashChange");
 	});
 	
+	// WebSocket 和 EventSource 只记录URL，参数原样传递，保留原型和常量
 	var oldWebSocket = window.WebSocket;
-	window.WebSocket = function(url, arg) {
+	window.WebSocket = function(url) {
 		window.addLink(url, "WebSocket");
-		return new oldWebSocket(url, arg);
+		return new oldWebSocket(...arguments);
 	}
+	window.WebSocket.prototype = oldWebSocket.prototype;
+	Object.assign(window.WebSocket, {CONNECTING: 0, OPEN: 1, CLOSING: 2, CLOSED: 3});
 	
 	var oldEventSource = window.EventSource;
 	window.EventSource = function(url) {
 		window.addLink(url, "EventSource");
-		return new oldEventSource(url);
+		return new oldEventSource(...arguments);
 	}
+	window.EventSource.prototype = oldEventSource.prototype;
+	Object.assign(window.EventSource, {CONNECTING: 0, OPEN: 1, CLOSED: 2});
 	
-	var oldFetch = window.fetch;
-	window.fetch = function(url) {
-		window.addLink(url, "Fetch");
-		return oldFetch(url);
-	}
+	// fetch 不再hook，请求的方法、请求体和请求头由 Fetch.requestPaused 拦截完整记录
 	
 	// 锁定表单重置
 	HTMLFormElement.prototype.reset = function() {console.log("cancel reset form")};
@@ -184,42 +142,65 @@ const TabInitJS = `
 	};
 	Object.defineProperty(window,"setInterval",{"writable": false, "configurable": false});
 	
-	// 劫持原生ajax，并对每个请求设置最大请求次数
-	window.ajax_req_count_sec_auto = {};
+	// 劫持原生ajax，只记录方法和URL，请求原样发出，由 Fetch.requestPaused 拦截记录
 	XMLHttpRequest.prototype.__originalOpen = XMLHttpRequest.prototype.open;
 	XMLHttpRequest.prototype.open = function(method, url, async, user, password) {
 		// hook code
 		this.url = url;
 		this.method = method;
-		let name = method + url;
-		if (!window.ajax_req_count_sec_auto.hasOwnProperty(name)) {
-			window.ajax_req_count_sec_auto[name] = 1
-		} else {
-			window.ajax_req_count_sec_auto[name] += 1
-		}
-		
-		if (window.ajax_req_count_sec_auto[name] <= 10) {
-			return this.__originalOpen(method, url, true, user, password);
-		}
+		return this.__originalOpen.apply(this, arguments);
 	}
 	Object.defineProperty(XMLHttpRequest.prototype,"open",{"writable": false, "configurable": false});
 	
 	XMLHttpRequest.prototype.__originalSend = XMLHttpRequest.prototype.send;
 	XMLHttpRequest.prototype.send = function(data) {
 		// hook code
-		let name = this.method + this.url;
-		if (window.ajax_req_count_sec_auto[name] <= 10) {
-			return this.__originalSend(data);
-		}
+		return this.__originalSend.apply(this, arguments);
 	}
 	Object.defineProperty(XMLHttpRequest.prototype,"send",{"writable": false, "configurable": false});
 
 	XMLHttpRequest.prototype.__originalAbort = XMLHttpRequest.prototype.abort;
 	XMLHttpRequest.prototype.abort = function() {
 		// hook code
+		return this.__originalAbort.apply(this, arguments);
 	}
 	Object.defineProperty(XMLHttpRequest.prototype,"abort",{"writable": false, "configurable": false});
 	
+	// 穿透开放的 shadow root 查询元素，包含插入到插槽中的节点
+	window.crawlergoDeepQueryAll = function (selector, root) {
+		let result = [];
+		let seen = new Set();
+		let add = function (node) {
+			if (!seen.has(node)) {
+				seen.add(node);
+				result.push(node);
+			}
+		};
+		let walk = function (scope) {
+			for (let node of scope.querySelectorAll(selector)) {
+				add(node);
+			}
+			for (let node of scope.querySelectorAll("*")) {
+				if (node.shadowRoot) {
+					walk(node.shadowRoot);
+				}
+				if (node.tagName === "SLOT") {
+					for (let slotted of node.assignedElements({flatten: true})) {
+						if (slotted.matches(selector)) {
+							add(slotted);
+						}
+						for (let child of slotted.querySelectorAll(selector)) {
+							add(child);
+						}
+					}
+				}
+			}
+		};
+		walk(root || document);
+		return result;
+	};
+	Object.defineProperty(window,"crawlergoDeepQueryAll",{"writable": false, "configurable": false});
+
 	// 打乱数组的方法
 	window.randArr = function (arr) {
 		for (var i = 0; i < arr.length; i++) {
@@ -352,7 +333,7 @@ const TriggerInlineEventJS = `
 	let eventNames = ["onabort", "onblur", "onchange", "onclick", "ondblclick", "onerror", "onfocus", "onkeydown", "onkeypress", "onkeyup", "onload", "onmousedown", "onmousemove", "onmouseout", "onmouseover", "onmouseup", "onreset", "onresize", "onselect", "onsubmit", "onunload"];
 	for (let eventName of eventNames) {
 		let event = eventName.replace("on", "");
-		let nodeList = document.querySelectorAll("[" + eventName + "]");
+		let nodeList = window.crawlergoDeepQueryAll("[" + eventName + "]");
 		if (nodeList.length > 100) {
 			nodeList = nodeList.slice(0, 100);
 		}
@@ -391,7 +372,7 @@ const TriggerDom2EventJS = `
 			}
 		}
 	}
-	let nodes = document.querySelectorAll("[sec_auto_dom2_event_flag]");
+	let nodes = window.crawlergoDeepQueryAll("[sec_auto_dom2_event_flag]");
 	if (nodes.length > 200) {
 		nodes = nodes.slice(0, 200);
 	}
@@ -423,7 +404,7 @@ const TriggerDom2EventJS = `
 
 const TriggerJavascriptProtocol = `
 (async function click_all_a_tag_javascript(){
-	let nodeListHref = document.querySelectorAll("[href]");
+	let nodeListHref = window.crawlergoDeepQueryAll("[href]");
 	nodeListHref = window.randArr(nodeListHref);
 	for (let node of nodeListHref) {
 		let attrValue = node.getAttribute("href");
@@ -435,7 +416,7 @@ const TriggerJavascriptProtocol = `
 			catch {}
 		}
 	}
-	let nodeListSrc = document.querySelectorAll("[src]");
+	let nodeListSrc = window.crawlergoDeepQueryAll("[src]");
 	nodeListSrc = window.randArr(nodeListSrc);
 	for (let node of nodeListSrc) {
 		let attrValue = node.getAttribute("src");
@@ -474,3 +455,802 @@ func CashX(flatten bool) func(*cdp.Node) string {
 		return fmt.Sprintf(`$x(%q)`, n.FullXPath())
 	}
 }
+
+const RouteDiscoveryJS = `
+(function crawlergo_route_discovery() {
+	let routes = [];
+	let hash = false;
+	let base = "";
+	function join(parent, path) {
+		if (path.startsWith("/")) {
+			return path;
+		}
+		return parent.replace(/\/+$/, "") + "/" + path;
+	}
+	function walk(list, parent, depth) {
+		if (!Array.isArray(list) || depth > 10) {
+			return;
+		}
+		for (let route of list) {
+			if (!route || typeof route !== "object") {
+				continue;
+			}
+			let current = parent;
+			if (typeof route.path === "string") {
+				current = join(parent, route.path);
+				routes.push(current);
+			}
+			walk(route.children, current, depth + 1);
+		}
+	}
+	function findProperty(name) {
+		let nodes = document.querySelectorAll("*");
+		for (let i = 0; i < nodes.length && i < 3000; i++) {
+			if (nodes[i][name]) {
+				return nodes[i][name];
+			}
+		}
+		return null;
+	}
+
+	// Vue Router 4 (Vue 3)
+	try {
+		let app = findProperty("__vue_app__");
+		let router = app && app.config.globalProperties.$router;
+		if (router) {
+			if (typeof router.getRoutes === "function") {
+				for (let route of router.getRoutes()) {
+					routes.push(route.path);
+				}
+			} else {
+				walk(router.options.routes, "/", 0);
+			}
+			let historyBase = router.options.history && router.options.history.base || "";
+			hash = hash || historyBase.indexOf("#") !== -1;
+		}
+	} catch (e) {}
+
+	// Vue Router 3 (Vue 2) 以及 Nuxt
+	try {
+		let vm = window.$nuxt || findProperty("__vue__");
+		let router = vm && vm.$root && vm.$root.$router;
+		if (router && router.options) {
+			walk(router.options.routes, "/", 0);
+			hash = hash || router.mode === "hash";
+			base = base || router.options.base || "";
+		}
+	} catch (e) {}
+
+	// React Router，遍历 fiber 树查找 routes 和 path 属性
+	try {
+		let container = document.getElementById("root") || document.body;
+		let fiberKey = Object.keys(container).find(key => key.startsWith("__reactContainer$") || key === "_reactRootContainer");
+		let root = fiberKey && container[fiberKey];
+		if (root && root._internalRoot) {
+			root = root._internalRoot.current;
+		}
+		let stack = root ? [root] : [];
+		let count = 0;
+		while (stack.length > 0 && count < 5000) {
+			let fiber = stack.pop();
+			count++;
+			let props = fiber.memoizedProps;
+			if (props && typeof props === "object") {
+				if (Array.isArray(props.routes)) {
+					walk(props.routes, "/", 0);
+				}
+				if (props.router && props.router.routes) {
+					walk(props.router.routes, "/", 0);
+				}
+				if (typeof props.path === "string" && fiber.type && fiber.type.name !== "Link") {
+					routes.push(props.path);
+				}
+			}
+			if (fiber.sibling) {
+				stack.push(fiber.sibling);
+			}
+			if (fiber.child) {
+				stack.push(fiber.child);
+			}
+		}
+		// Remix / React Router 7 的路由清单
+		let manifest = window.__remixManifest || window.__reactRouterManifest;
+		if (manifest && manifest.routes) {
+			for (let id in manifest.routes) {
+				let route = manifest.routes[id];
+				if (typeof route.path === "string") {
+					routes.push(join("/", route.path));
+				}
+			}
+		}
+	} catch (e) {}
+
+	// Angular，开发模式下通过 injector 找到 Router
+	try {
+		if (window.ng && typeof window.getAllAngularRootElements === "function") {
+			for (let rootElement of window.getAllAngularRootElements()) {
+				let injector = window.ng.getInjector(rootElement);
+				let records = injector && (injector.records || (injector.parent && injector.parent.records));
+				if (!records) {
+					continue;
+				}
+				for (let record of records.values()) {
+					let value = record && record.value;
+					if (value && Array.isArray(value.config) && typeof value.navigateByUrl === "function") {
+						walk(value.config, "/", 0);
+						hash = hash || (value.location && value.location._platformStrategy && value.location._platformStrategy.constructor.name === "HashLocationStrategy");
+					}
+				}
+			}
+		}
+	} catch (e) {}
+
+	// AngularJS ngRoute 以及 ui-router
+	try {
+		if (window.angular) {
+			let injector = window.angular.element(document.body).injector() || window.angular.element(document.documentElement).injector();
+			if (injector && injector.has("$route")) {
+				routes.push(...Object.keys(injector.get("$route").routes));
+				hash = true;
+			}
+			if (injector && injector.has("$state")) {
+				for (let state of injector.get("$state").get()) {
+					if (state.url) {
+						routes.push(state.url);
+					}
+				}
+				hash = true;
+			}
+		}
+	} catch (e) {}
+
+	// Next.js
+	try {
+		if (window.__NEXT_DATA__ && window.__NEXT_DATA__.page) {
+			routes.push(window.__NEXT_DATA__.page);
+		}
+		let buildManifest = window.__BUILD_MANIFEST;
+		if (buildManifest) {
+			let pages = buildManifest.sortedPages || Object.keys(buildManifest);
+			for (let page of pages) {
+				if (page.startsWith("/") && !page.startsWith("/_")) {
+					routes.push(page);
+				}
+			}
+		}
+	} catch (e) {}
+
+	return {routes: [...new Set(routes)], hash: !!hash, base: base};
+})()
+`
+
+// 逐步滚动页面及页面内的可滚动容器，触发无限滚动和懒加载，连续两次到底且高度不变时停止
+const ScrollPageJS = `
+(async function crawlergo_scroll_page(step, maxSteps, interval) {
+	let sleep = (time) => new Promise((resolve) => setTimeout(resolve, time));
+	let root = document.scrollingElement || document.documentElement;
+	let scrollers = [root];
+	let nodes = document.body ? document.body.getElementsByTagName("*") : [];
+	for (let i = 0; i < nodes.length && i < 3000 && scrollers.length < 6; i++) {
+		let node = nodes[i];
+		if (node.scrollHeight - node.clientHeight < 50 || node.clientHeight < 50) {
+			continue;
+		}
+		let overflowY = window.getComputedStyle(node).overflowY;
+		if (overflowY === "auto" || overflowY === "scroll") {
+			scrollers.push(node);
+		}
+	}
+	let heights = scrollers.map((s) => s.scrollHeight);
+	let steps = 0, stable = 0;
+	while (steps < maxSteps && stable < 2) {
+		for (let s of scrollers) {
+			s.scrollTop = s.scrollTop + step;
+		}
+		steps++;
+		await sleep(interval);
+		let newHeights = scrollers.map((s) => s.scrollHeight);
+		let atBottom = scrollers.every((s) => s.scrollTop + s.clientHeight >= s.scrollHeight - 2);
+		let unchanged = newHeights.every((h, i) => h === heights[i]);
+		stable = atBottom && unchanged ? stable + 1 : 0;
+		heights = newHeights;
+	}
+	return steps;
+})(%d, %d, %d)
+`
+
+// 加载更多、下一页 等分页控件的文本，不区分大小写，同时用于Go的正则和JS的RegExp
+const LoadMoreTextPattern = `^\s*(load|show|view|see)\s+(more|all)\b|^\s*more\s*$|^\s*next(\s+page)?\s*[›»>]*\s*$|^\s*[›»>]\s*$|加载更多|查看更多|显示更多|点击加载|下一页|^\s*更多\s*$`
+
+// 查找并点击 加载更多、下一页 等分页控件，指向其他页面的链接不点击，直接加入结果
+const ClickLoadMoreJS = `
+(async function crawlergo_click_load_more(maxClicks, interval, textPattern, maxTextLength) {
+	let sleep = (time) => new Promise((resolve) => setTimeout(resolve, time));
+	let textRegex = new RegExp(textPattern, "i");
+	let selectors = ["[rel=next]", "[aria-label*=next i]", "[aria-label*=more i]", ".load-more", ".loadmore", ".more-btn", ".next-page",
+		".pagination .next", ".pager .next", "li.next > a", "[class*=load-more]", "[class*=loadMore]", "[class*=load_more]", "[data-action*=more i]"];
+	let candidates = new Set();
+	for (let sel of selectors) {
+		try {
+			document.querySelectorAll(sel).forEach((node) => candidates.add(node));
+		} catch (e) {}
+	}
+	for (let node of document.querySelectorAll("button, a, [role=button], input[type=button], li")) {
+		let text = (node.innerText || node.value || "").trim();
+		if (text.length > 0 && text.length <= maxTextLength && textRegex.test(text)) {
+			candidates.add(node);
+		}
+	}
+	let clickable = (node) => {
+		if (!node.isConnected || node.disabled || node.getAttribute("aria-disabled") === "true" || node.hasAttribute("crawlergo-safe-blocked")) {
+			return false;
+		}
+		if (node.getClientRects().length === 0) {
+			return false;
+		}
+		if (node.tagName === "A") {
+			let href = (node.getAttribute("href") || "").trim();
+			if (href !== "" && !href.startsWith("#") && !href.toLowerCase().startsWith("javascript:")) {
+				window.addLink(node.href, "DOM");
+				return false;
+			}
+		}
+		return true;
+	};
+	let clicks = 0;
+	for (let node of candidates) {
+		// 同一个加载更多按钮可以多次点击
+		while (clicks < maxClicks && clickable(node)) {
+			try {
+				node.scrollIntoView({block: "center"});
+				node.click();
+			} catch (e) {
+				break;
+			}
+			clicks++;
+			await sleep(interval);
+			if (node.tagName === "A") {
+				break;
+			}
+		}
+		if (clicks >= maxClicks) {
+			break;
+		}
+	}
+	return clicks;
+})(%d, %d, %q, %d)
+`
+
+// 计算当前DOM状态的哈希，只包含可见元素的结构和状态属性，忽略文本避免时间等内容产生无限状态
+const DomStateHashJS = `
+(function crawlergo_dom_state_hash() {
+	let hash = 2166136261;
+	let update = (str) => {
+		for (let i = 0; i < str.length; i++) {
+			hash ^= str.charCodeAt(i);
+			hash = Math.imul(hash, 16777619) >>> 0;
+		}
+	};
+	let walk = (node, depth) => {
+		if (node.nodeType !== 1 || depth > 64) {
+			return;
+		}
+		if (node.hidden || node.getClientRects().length === 0 && node.tagName !== "BODY") {
+			return;
+		}
+		update("<" + node.tagName + (node.id ? "#" + node.id : ""));
+		for (let attr of ["role", "aria-expanded", "aria-selected", "aria-hidden", "open", "href", "type", "name"]) {
+			if (node.hasAttribute(attr)) {
+				update(attr + "=" + node.getAttribute(attr));
+			}
+		}
+		for (let child of node.children) {
+			walk(child, depth + 1);
+		}
+		update(">");
+	};
+	if (document.body) {
+		walk(document.body, 0);
+	}
+	return hash.toString(16);
+})()
+`
+
+// 获取当前状态下可交互的元素，返回可在页面重新加载后定位的选择器
+const StateClickableJS = `
+(function crawlergo_state_clickable(max) {
+	let cssPath = (el) => {
+		let parts = [];
+		while (el && el.nodeType === 1 && el !== document.documentElement) {
+			if (el.id && /^[A-Za-z][\w-]*$/.test(el.id) && document.querySelectorAll("#" + el.id).length === 1) {
+				parts.unshift("#" + el.id);
+				break;
+			}
+			let index = 1;
+			for (let sibling = el.previousElementSibling; sibling; sibling = sibling.previousElementSibling) {
+				if (sibling.tagName === el.tagName) {
+					index++;
+				}
+			}
+			parts.unshift(el.tagName.toLowerCase() + ":nth-of-type(" + index + ")");
+			el = el.parentElement;
+		}
+		return parts.join(" > ");
+	};
+	let selectors = ["[onclick]", "[sec_auto_dom2_event_flag*=click]", "[role=button]", "[role=tab]", "[role=menuitem]", "[role=treeitem]",
+		"[aria-expanded]", "[aria-haspopup]", "[data-toggle]", "[data-bs-toggle]", "summary", "button:not([type=submit])",
+		"a[href^='#']", "a[href^='javascript:' i]", "a:not([href])[class]"];
+	let result = [];
+	let seen = new Set();
+	for (let node of document.querySelectorAll(selectors.join(","))) {
+		if (result.length >= max) {
+			break;
+		}
+		if (node.disabled || node.closest("form") || node.hasAttribute("crawlergo-safe-blocked") || node.getClientRects().length === 0) {
+			continue;
+		}
+		let selector = cssPath(node);
+		if (seen.has(selector)) {
+			continue;
+		}
+		seen.add(selector);
+		let label = (node.innerText || node.getAttribute("aria-label") || node.title || "").trim().replace(/\s+/g, " ").slice(0, 30);
+		result.push({selector: selector, label: label});
+	}
+	return result;
+})(%d)
+`
+
+// 点击状态探索中的元素
+const StateClickJS = `
+(function crawlergo_state_click(selector) {
+	let node = document.querySelector(selector);
+	if (!node) {
+		return false;
+	}
+	try {
+		node.scrollIntoView({block: "center"});
+		node.click();
+	} catch (e) {
+		return false;
+	}
+	return true;
+})(%q)
+`
+
+// 收集尚未分类的按钮、链接、表单，交给安全模式策略判断
+const SafeModeCollectJS = `
+(function crawlergo_safe_mode_collect() {
+	window.crawlergo_safe_seq = window.crawlergo_safe_seq || 0;
+	let result = [];
+	let nodes = window.crawlergoDeepQueryAll("a, button, input[type=submit], input[type=button], input[type=image], form, [onclick], [role=button], [sec_auto_dom2_event_flag]");
+	for (let node of nodes) {
+		if (result.length >= 1000) {
+			break;
+		}
+		if (node.hasAttribute("crawlergo-safe-id")) {
+			continue;
+		}
+		let id = String(++window.crawlergo_safe_seq);
+		node.setAttribute("crawlergo-safe-id", id);
+		let attrs = {};
+		for (let attr of node.attributes) {
+			attrs[attr.name] = attr.value.slice(0, 200);
+		}
+		let form = node.tagName === "FORM" ? node : node.form;
+		let text = node.tagName === "FORM" ? "" : (node.innerText || node.value || "");
+		result.push({
+			id: id,
+			tag: node.tagName.toLowerCase(),
+			text: text.trim().replace(/\s+/g, " ").slice(0, 100),
+			attrs: attrs,
+			formAction: form ? (node.formAction || form.action || "") : "",
+			formMethod: form ? (form.getAttribute("method") || "get") : "",
+		});
+	}
+	return result;
+})()
+`
+
+// 阻止被安全模式拦截的元素的点击与提交，移除内联事件，表单内的按钮被拦截时整个表单不提交
+const SafeModeBlockJS = `
+(function crawlergo_safe_mode_block(ids) {
+	let stop = function (e) {
+		e.preventDefault();
+		e.stopImmediatePropagation();
+	};
+	let block = function (node) {
+		if (node.hasAttribute("crawlergo-safe-blocked")) {
+			return;
+		}
+		node.setAttribute("crawlergo-safe-blocked", "");
+		for (let attr of [...node.attributes]) {
+			if (attr.name.startsWith("on")) {
+				node.removeAttribute(attr.name);
+			}
+		}
+		node.addEventListener("click", stop, true);
+		node.removeAttribute("sec_auto_dom2_event_flag");
+		if (node.tagName === "FORM") {
+			node.addEventListener("submit", stop, true);
+			node.submit = function () {};
+			node.requestSubmit = function () {};
+		}
+	};
+	let nodes = {};
+	for (let node of window.crawlergoDeepQueryAll("[crawlergo-safe-id]")) {
+		nodes[node.getAttribute("crawlergo-safe-id")] = node;
+	}
+	for (let id of ids) {
+		let node = nodes[id];
+		if (!node) {
+			continue;
+		}
+		block(node);
+		if (node.form) {
+			block(node.form);
+		}
+	}
+	return ids.length;
+})(%s)
+`
+
+// 获取页面 meta refresh 的内容
+const MetaRefreshJS = `
+(function crawlergo_meta_refresh() {
+	let meta = document.querySelector("meta[http-equiv='refresh' i]");
+	return meta ? (meta.getAttribute("content") || "") : "";
+})()
+`
+
+// 收集frame中的链接和注释，链接按frame自身的地址解析为绝对地址
+const FrameCollectJS = `
+(function crawlergo_frame_collect() {
+	let result = {links: [], comments: []};
+	let push = function (value) {
+		if (!value) {
+			return;
+		}
+		value = value.trim();
+		let lower = value.toLowerCase();
+		if (lower === "" || lower.startsWith("#") || lower.startsWith("javascript:") || lower.startsWith("data:") || lower.startsWith("mailto:")) {
+			return;
+		}
+		try {
+			result.links.push(new URL(value, document.baseURI).href);
+		} catch (e) {}
+	};
+	for (let name of ["src", "href", "data-url", "data-href"]) {
+		for (let node of document.querySelectorAll("[" + name + "]")) {
+			push(node.getAttribute(name));
+		}
+	}
+	for (let node of document.querySelectorAll("object[data]")) {
+		push(node.getAttribute("data"));
+	}
+	let walker = document.createTreeWalker(document, NodeFilter.SHOW_COMMENT);
+	while (walker.nextNode() && result.comments.length < 500) {
+		result.comments.push(walker.currentNode.nodeValue.slice(0, 10000));
+	}
+	return result;
+})()
+`
+
+// 返回带id的输入框对应的label文本，用于匹配填充内容
+const FormLabelsJS = `
+(function crawlergo_form_labels() {
+	let result = {};
+	for (let node of document.querySelectorAll("input[id], textarea[id]")) {
+		if (node.labels && node.labels.length > 0) {
+			result[node.id] = node.labels[0].innerText.trim().slice(0, 100);
+		}
+	}
+	return result;
+})()
+`
+
+// 标记待填充的输入框，返回用于匹配填充内容的属性，shadowOnly 为 true 时只处理 shadow DOM 中的输入框
+const FormFieldsJS = `
+(function crawlergo_form_fields(shadowOnly) {
+	let result = [];
+	for (let node of window.crawlergoDeepQueryAll("input, textarea")) {
+		if (result.length >= 200) {
+			break;
+		}
+		if (shadowOnly && !(node.getRootNode() instanceof ShadowRoot)) {
+			continue;
+		}
+		let id = String(result.length);
+		node.setAttribute("crawlergo-fill-id", id);
+		let label = node.labels && node.labels.length > 0 ? node.labels[0].innerText : "";
+		result.push({
+			id: id,
+			tag: node.tagName.toLowerCase(),
+			type: (node.getAttribute("type") || "").toLowerCase(),
+			name: node.getAttribute("name") || "",
+			elemId: node.getAttribute("id") || "",
+			class: node.getAttribute("class") || "",
+			label: (label || node.getAttribute("aria-label") || "").trim().slice(0, 100),
+			placeholder: node.getAttribute("placeholder") || "",
+			autocomplete: node.getAttribute("autocomplete") || "",
+			min: node.getAttribute("min") || "",
+			max: node.getAttribute("max") || "",
+			step: node.getAttribute("step") || "",
+		});
+	}
+	return result;
+})(%t)
+`
+
+// 填充输入框，单选、复选框直接选中，下拉框选择第一项
+const FormFillJS = `
+(function crawlergo_form_fill(values, shadowOnly) {
+	let nodes = {};
+	for (let node of window.crawlergoDeepQueryAll("[crawlergo-fill-id]")) {
+		nodes[node.getAttribute("crawlergo-fill-id")] = node;
+	}
+	for (let id in values) {
+		let node = nodes[id];
+		if (!node) {
+			continue;
+		}
+		let type = (node.getAttribute("type") || "").toLowerCase();
+		if (type === "radio" || type === "checkbox") {
+			node.checked = true;
+		} else {
+			node.value = values[id];
+			if (node.tagName === "INPUT") {
+				node.setAttribute("value", values[id]);
+			}
+		}
+		node.dispatchEvent(new Event("input", {bubbles: true}));
+		node.dispatchEvent(new Event("change", {bubbles: true}));
+	}
+	for (let select of window.crawlergoDeepQueryAll("select")) {
+		if (shadowOnly && !(select.getRootNode() instanceof ShadowRoot)) {
+			continue;
+		}
+		if (select.options.length > 0) {
+			select.selectedIndex = 0;
+			select.dispatchEvent(new Event("change", {bubbles: true}));
+		}
+	}
+})(%s, %t)
+`
+
+// 将表单提交到隐藏的frame，点击表单内的按钮，没有按钮时直接提交，shadowOnly 为 true 时只处理 shadow DOM 中的表单
+const FormSubmitJS = `
+(function crawlergo_form_submit(name, shadowOnly) {
+	let forms = window.crawlergoDeepQueryAll("form:not([crawlergo-safe-blocked])").filter(
+		form => !shadowOnly || form.getRootNode() instanceof ShadowRoot);
+	if (forms.length === 0) {
+		return 0;
+	}
+	if (!document.getElementById(name)) {
+		let frame = document.createElement("iframe");
+		frame.setAttribute("name", name);
+		frame.setAttribute("id", name);
+		frame.setAttribute("style", "display: none");
+		document.body.appendChild(frame);
+	}
+	let count = 0;
+	for (let form of forms) {
+		form.setAttribute("target", name);
+		let buttons = form.querySelectorAll("input[type=submit]:not([crawlergo-safe-blocked]), button:not([crawlergo-safe-blocked])");
+		try {
+			if (buttons.length === 0) {
+				form.submit();
+			}
+			for (let button of buttons) {
+				button.click();
+			}
+			count++;
+		} catch (e) {}
+	}
+	return count;
+})(%q, %t)
+`
+
+// 在文档开始时挂钩危险的sink，参数中包含填充值或URL参数值时通过绑定上报
+// 不挂钩 eval，替换后页面中直接调用的 eval 会变为间接调用，丢失局部作用域，eval 通过 Debugger.scriptParsed 检测
+const SinkHookJS = `
+(function crawlergo_sink_hook(markers, bindingName, maxReports, maxValue) {
+	const report = window[bindingName];
+	if (typeof report !== "function" || window.__crawlergoSinkHooked) {
+		return;
+	}
+	Object.defineProperty(window, "__crawlergoSinkHooked", {value: true, enumerable: false});
+	try {
+		delete window[bindingName];
+	} catch (e) {}
+	markers = markers.filter(m => m);
+	if (markers.length === 0) {
+		return;
+	}
+
+	let reported = 0;
+	function check(sink, args) {
+		if (reported >= maxReports) {
+			return;
+		}
+		let value;
+		try {
+			value = Array.prototype.map.call(args, a => String(a)).join("");
+		} catch (e) {
+			return;
+		}
+		for (const marker of markers) {
+			let index = value.indexOf(marker);
+			if (index === -1) {
+				continue;
+			}
+			reported++;
+			let location = "";
+			try {
+				let frames = new Error().stack.split("\n").slice(3);
+				let match = frames.map(f => f.match(/\(?((?:https?|file):\/\/.*?:\d+:\d+)\)?\s*$/)).find(m => m);
+				location = match ? match[1] : "";
+			} catch (e) {}
+			let start = Math.max(0, index - Math.floor(maxValue / 2));
+			report(JSON.stringify({sink: sink, marker: marker, value: value.substr(start, maxValue), location: location}));
+			return;
+		}
+	}
+
+	function hookSetter(proto, prop, sink) {
+		const desc = proto && Object.getOwnPropertyDescriptor(proto, prop);
+		if (!desc || !desc.set) {
+			return;
+		}
+		Object.defineProperty(proto, prop, Object.assign({}, desc, {
+			set: function (value) {
+				check(sink, [value]);
+				return desc.set.call(this, value);
+			}
+		}));
+	}
+
+	function hookMethod(obj, name, sink, pick) {
+		const original = obj && obj[name];
+		if (typeof original !== "function") {
+			return;
+		}
+		obj[name] = function () {
+			check(sink, pick ? pick(arguments) : arguments);
+			return original.apply(this, arguments);
+		};
+	}
+
+	hookSetter(Element.prototype, "innerHTML", "innerHTML");
+	hookSetter(Element.prototype, "outerHTML", "outerHTML");
+	hookSetter(window.ShadowRoot && ShadowRoot.prototype, "innerHTML", "innerHTML");
+	hookSetter(HTMLIFrameElement.prototype, "srcdoc", "iframe.srcdoc");
+	hookMethod(Element.prototype, "insertAdjacentHTML", "insertAdjacentHTML", args => [args[1]]);
+	hookMethod(Range.prototype, "createContextualFragment", "createContextualFragment");
+	hookMethod(Document.prototype, "write", "document.write");
+	hookMethod(Document.prototype, "writeln", "document.writeln");
+	hookMethod(window, "setTimeout", "setTimeout", args => typeof args[0] === "string" ? [args[0]] : []);
+	hookMethod(window, "setInterval", "setInterval", args => typeof args[0] === "string" ? [args[0]] : []);
+
+	const OriginalFunction = window.Function;
+	window.Function = new Proxy(OriginalFunction, {
+		apply: function (target, thisArg, args) {
+			check("Function", args);
+			return Reflect.apply(target, thisArg, args);
+		},
+		construct: function (target, args, newTarget) {
+			check("Function", args);
+			return Reflect.construct(target, args, newTarget);
+		}
+	});
+})(%s, %q, %d, %d)
+`
+
+// 返回页面HTML的前256KB，用于识别验证码和JS挑战页面
+const DocumentHTMLJS = `
+(function crawlergo_document_html() {
+	return document.documentElement ? document.documentElement.outerHTML.slice(0, 262144) : "";
+})()
+`
+
+// 列出可提交表单中的下拉框和单选组及其可选值，用于按不同选项组合多次提交表单
+const FormChoicesJS = `
+(function crawlergo_form_choices() {
+	let result = [];
+	let forms = window.crawlergoDeepQueryAll("form:not([crawlergo-safe-blocked])");
+	forms.forEach((form, index) => {
+		let choices = [];
+		let radios = {};
+		for (let element of form.elements) {
+			if (!element.name || element.disabled) {
+				continue;
+			}
+			if (element.tagName === "SELECT" && !element.multiple) {
+				let choice = {name: element.name, values: [], default: 0};
+				for (let option of element.options) {
+					if (option.disabled) {
+						continue;
+					}
+					if (option.selected) {
+						choice.default = choice.values.length;
+					}
+					choice.values.push(option.value);
+				}
+				choices.push(choice);
+			} else if (element.type === "radio") {
+				let choice = radios[element.name];
+				if (!choice) {
+					choice = radios[element.name] = {name: element.name, values: [], default: 0};
+					choices.push(choice);
+				}
+				if (element.checked) {
+					choice.default = choice.values.length;
+				}
+				choice.values.push(element.value);
+			}
+		}
+		if (choices.length > 0) {
+			result.push({index: index, choices: choices});
+		}
+	});
+	return JSON.stringify(result);
+})()
+`
+
+// 设置表单的下拉框和单选组的值后提交到隐藏的frame
+const FormVariantSubmitJS = `
+(function crawlergo_form_variant_submit(name, index, values) {
+	let form = window.crawlergoDeepQueryAll("form:not([crawlergo-safe-blocked])")[index];
+	if (!form) {
+		return false;
+	}
+	for (let element of form.elements) {
+		if (!element.name || !(element.name in values)) {
+			continue;
+		}
+		if (element.tagName === "SELECT") {
+			element.value = values[element.name];
+		} else if (element.type === "radio") {
+			element.checked = element.value === values[element.name];
+		} else {
+			continue;
+		}
+		element.dispatchEvent(new Event("change", {bubbles: true}));
+	}
+	if (!document.getElementById(name)) {
+		let frame = document.createElement("iframe");
+		frame.setAttribute("name", name);
+		frame.setAttribute("id", name);
+		frame.setAttribute("style", "display: none");
+		document.body.appendChild(frame);
+	}
+	form.setAttribute("target", name);
+	let button = form.querySelector("input[type=submit]:not([crawlergo-safe-blocked]), button:not([type]):not([crawlergo-safe-blocked]), button[type=submit]:not([crawlergo-safe-blocked])");
+	try {
+		if (button) {
+			button.click();
+		} else {
+			form.submit();
+		}
+	} catch (e) {
+		return false;
+	}
+	return true;
+})(%q, %d, %s)
+`
+
+// 在页面中构造样例文件并设置到文件输入框，参数依次为base64编码的文件内容、文件名、文件类型
+const SetFileInputJS = `
+function crawlergo_set_file_input() {
+	let binary = atob(%q);
+	let bytes = new Uint8Array(binary.length);
+	for (let i = 0; i < binary.length; i++) {
+		bytes[i] = binary.charCodeAt(i);
+	}
+	let transfer = new DataTransfer();
+	transfer.items.add(new File([bytes], %q, {type: %q}));
+	this.files = transfer.files;
+	this.dispatchEvent(new Event("input", {bubbles: true}));
+	this.dispatchEvent(new Event("change", {bubbles: true}));
+}
+`
@@ -0,0 +1,31 @@
+package model
+
+// 页面运行时发现的类型
+const (
+	FindingException = "exception" // 未捕获的JS异常
+	FindingConsole   = "console"   // console.error 输出
+	FindingSink      = "sink"      // 填充值或URL参数到达危险的sink
+)
+
+/*
+*
+页面运行时的发现，用于XSS、DOM问题的分诊
+*/
+type Finding struct {
+	Type     string `json:"type"`
+	Page     string `json:"page"`               // 发现所在的页面
+	Frame    string `json:"frame,omitempty"`    // 发现所在的子frame，顶层页面为空
+	Sink     string `json:"sink,omitempty"`     // innerHTML、document.write、Function、eval、location 等
+	Marker   string `json:"marker,omitempty"`   // 命中的填充值或URL参数值
+	Value    string `json:"value,omitempty"`    // 传入sink的值，过长时截断
+	Message  string `json:"message,omitempty"`  // 异常或控制台信息
+	Location string `json:"location,omitempty"` // 脚本位置 url:行:列
+}
+
+/*
+*
+同一页面中重复的发现只保留一次
+*/
+func (f *Finding) Key() string {
+	return f.Type + "\x00" + f.Frame + "\x00" + f.Sink + "\x00" + f.Marker + "\x00" + f.Message + "\x00" + f.Location
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 重定向的方式
+const (
+	RedirectServer = "server" // 服务端返回 3XX
+	RedirectMeta   = "meta"   // <meta http-equiv="refresh">
+	RedirectJS     = "js"     // JS 修改 location
+)
+
+/*
+*
+重定向链中的一跳
+*/
+type RedirectHop struct {
+	StatusCode int    `json:"status_code,omitempty"`
+	From       string `json:"from"`
+	Location   string `json:"location"`
+	Type       string `json:"type"`
+}
+
+// 常见的跳转参数名
+// to、out、target、go、url、uri、link、ref、referer、service、location、back 等通用的参数名不在其中，只有值为绝对地址或协议相对地址时才识别
+var openRedirectParamNames = map[string]bool{
+	"redirect": true, "redirect_uri": true, "redirect_url": true, "redirecturl": true, "redirecturi": true, "redir": true,
+	"return": true, "return_to": true, "returnto": true, "return_url": true, "returnurl": true, "returnuri": true,
+	"next": true, "next_url": true, "nexturl": true, "goto": true, "dest": true, "destination": true,
+	"continue": true, "forward": true, "forward_url": true,
+	"callback": true, "callback_url": true, "jump": true, "jump_url": true, "jumpurl": true,
+	"back_url": true, "backurl": true, "success_url": true, "successurl": true, "fail_url": true,
+	"failurl": true, "rurl": true,
+}
+
+var redirectValueRegex = regexp.MustCompile(`(?i)^(?:https?:)?(?://|/\\|\\\\)[^/\\]|^(?:https?|javascript|data):`)
+
+/*
+*
+判断参数值是否像一个跳转地址，如 http://、//host、/\host、javascript:
+*/
+func LooksLikeRedirectValue(value string) bool {
+	value = strings.TrimSpace(value)
+	if decoded, err := url.QueryUnescape(value); err == nil {
+		value = decoded
+	}
+	return redirectValueRegex.MatchString(value)
+}
+
+/*
+*
+找出疑似开放重定向的参数，参数名为常见的跳转参数或者参数值是一个跳转地址
+返回 query:name 或 body:name 形式的列表
+*/
+func (req *Request) OpenRedirectParams() []string {
+	var result []string
+	for name, values := range req.QueryMap() {
+		if isOpenRedirectParam(name, values) {
+			result = append(result, "query:"+name)
+		}
+	}
+	if req.PostData != "" {
+		for name, value := range req.PostDataMap() {
+			if name == "key" {
+				continue
+			}
+			if isOpenRedirectParam(name, []string{fmt.Sprint(value)}) {
+				result = append(result, "body:"+name)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func isOpenRedirectParam(name string, values []string) bool {
+	lowerName := strings.ToLower(name)
+	for _, value := range values {
+		if LooksLikeRedirectValue(value) {
+			return true
+		}
+		// 参数名匹配时，值需要像一个路径或地址，避免 page=next 之类的误报
+		if openRedirectParamNames[lowerName] && (value == "" || strings.HasPrefix(value, "/") || strings.Contains(value, ".")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLooksLikeRedirectValue(t *testing.T) {
+	assert.True(t, LooksLikeRedirectValue("http://evil.com"))
+	assert.True(t, LooksLikeRedirectValue("https%3A%2F%2Fevil.com%2F"))
+	assert.True(t, LooksLikeRedirectValue("//evil.com"))
+	assert.True(t, LooksLikeRedirectValue(`/\evil.com`))
+	assert.True(t, LooksLikeRedirectValue("javascript:alert(1)"))
+	assert.False(t, LooksLikeRedirectValue("/home"))
+	assert.False(t, LooksLikeRedirectValue("next"))
+	assert.False(t, LooksLikeRedirectValue("123"))
+}
+
+func TestOpenRedirectParams(t *testing.T) {
+	u, _ := GetUrl("http://test.com/login?next=/dashboard&page=next&id=1&cb=https://a.com/")
+	req := GetRequest("POST", u, Options{
+		Headers:  map[string]interface{}{"Content-Type": "application/x-www-form-urlencoded"},
+		PostData: "user=admin&return_url=http%3A%2F%2Fb.com",
+	})
+	assert.Equal(t, []string{"body:return_url", "query:cb", "query:next"}, req.OpenRedirectParams())
+
+	u, _ = GetUrl("http://test.com/list?page=2&to=next")
+	req = GetRequest("GET", u)
+	assert.Empty(t, req.OpenRedirectParams())
+
+	// 通用的参数名只识别绝对地址或协议相对地址
+	u, _ = GetUrl("http://test.com/convert?to=/pdf&target=_blank&url=/docs/a.html&go=//evil.com&out=https://evil.com")
+	req = GetRequest("GET", u)
+	assert.Equal(t, []string{"query:go", "query:out"}, req.OpenRedirectParams())
+
+	u, _ = GetUrl("http://test.com/page?ref=/home&referer=docs.html&uri=/api/v1&link=/a.html&service=/login&location=/cn&back=/list&referrer=https://evil.com")
+	req = GetRequest("GET", u)
+	assert.Equal(t, []string{"query:referrer"}, req.OpenRedirectParams())
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"katanacrawlgo/pkg/challenge"
 	"katanacrawlgo/pkg/crawlergo/config"
 	"katanacrawlgo/pkg/crawlergo/tools"
 	"net/url"
@@ -19,6 +20,7 @@ type Filter struct {
 	MarkedPath        string
 	FragmentID        string
 	PathId            string
+	GraphQLId         string // GraphQL请求的操作签名，不同操作的请求体结构相同，需要单独区分
 	UniqueId          string
 }
 
@@ -36,6 +38,23 @@ type Request struct {
 	Source          string
 	RedirectionFlag bool
 	Proxy           string
+	ResourceType    string            // 浏览器记录的资源类型 XHR、Fetch、Document 等
+	Initiator       *Initiator        // 发起该请求的来源及JS调用栈
+	EventSequence   []string          // 状态探索中产生该请求的交互序列
+	RedirectChain   []RedirectHop     // 导航请求经过的重定向链
+	Frame           string            // 请求来源的子frame地址，顶层页面为空
+	Challenge       *challenge.Result // 打开后是验证码或JS挑战页面时的识别结果
+	Variant         string            // 按选项组合提交表单时，产生该请求的下拉框和单选组取值
+}
+
+/*
+*
+请求的发起者，来自 Network.requestWillBeSent 事件
+*/
+type Initiator struct {
+	Type  string   `json:"type"`
+	URL   string   `json:"url,omitempty"`
+	Stack []string `json:"stack,omitempty"`
 }
 
 var supportContentType = []string{config.JSON, config.URLENCODED}
@@ -0,0 +1,174 @@
+package crawlergo
+
+import (
+	"errors"
+	"fmt"
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/crawlergo/tools"
+	"katanacrawlgo/pkg/crawlergo/tools/requests"
+	"katanacrawlgo/pkg/openapi"
+	"log"
+	"sync"
+
+	"github.com/ttacon/chalk"
+)
+
+/*
+*
+收集需要下载的OpenAPI文档地址：爬取过程中发现的文档优先，其次是每个目标站点根目录下的常见位置
+*/
+func CollectOpenAPISpecURLs(targets []*model.Request, reqList []*model.Request) []string {
+	var specURLs []string
+	seen := map[string]bool{}
+	add := func(specURL string) {
+		if !seen[specURL] {
+			seen[specURL] = true
+			specURLs = append(specURLs, specURL)
+		}
+	}
+	for _, req := range reqList {
+		if req.Method != config.GET {
+			continue
+		}
+		if req.Source == config.FromOpenAPI || openapi.IsSpecPath(req.URL.Path) {
+			add(req.URL.String())
+		}
+	}
+	for _, target := range targets {
+		origin := target.URL.Scheme + "://" + target.URL.Host
+		for _, path := range openapi.SpecPaths {
+			add(origin + path)
+		}
+	}
+	return specURLs
+}
+
+/*
+*
+下载并解析OpenAPI文档，为文档中的每个接口生成请求
+只下载爬取范围内的文档，生成的请求同样经过范围、去重过滤和安全模式规则
+*/
+func (t *CrawlerTask) expandOpenAPI() {
+	if !t.Config.OpenAPIDiscovery {
+		return
+	}
+	var specURLs []string
+	for _, specURL := range CollectOpenAPISpecURLs(t.Targets, t.Result.AllReqList) {
+		if t.inScope(specURL) {
+			specURLs = append(specURLs, specURL)
+		}
+	}
+	headers := tools.ConvertHeaders(t.Config.ExtraHeaders)
+	docs := fetchOpenAPISpecs(specURLs, headers, t.Config.Proxy)
+
+	for i, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		if len(t.Result.OpenAPISpecs) >= config.OpenAPIMaxSpecs {
+			break
+		}
+		t.Result.OpenAPISpecs = append(t.Result.OpenAPISpecs, specURLs[i])
+		reqList := t.openAPIRequests(headers, doc.Requests(specURLs[i]))
+		log.Println(chalk.Green.Color(fmt.Sprintf("OpenAPI文档解析成功: %s, 生成%d个请求", specURLs[i], len(reqList))))
+		for _, req := range reqList {
+			t.addAllRequest(req)
+			if !t.filter.DoFilter(req) {
+				t.Result.ReqList = append(t.Result.ReqList, req)
+			}
+		}
+	}
+}
+
+/*
+*
+并发下载文档，返回的列表与地址一一对应，不是OpenAPI文档的位置为nil
+*/
+func fetchOpenAPISpecs(specURLs []string, headers map[string]string, proxy string) []*openapi.Document {
+	reqHeaders := map[string]string{}
+	for key, value := range headers {
+		reqHeaders[key] = value
+	}
+	// 文档通常超过默认Range头的长度，需要完整下载
+	reqHeaders["Range"] = "bytes=0-"
+
+	docs := make([]*openapi.Document, len(specURLs))
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, 10)
+	for i, specURL := range specURLs {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, specURL string) {
+			defer wg.Done()
+			defer func() { <-limit }()
+			resp, err := requests.Get(specURL, reqHeaders, &requests.ReqOptions{
+				Timeout:       config.OpenAPITimeout,
+				AllowRedirect: false,
+				Proxy:         proxy,
+			})
+			if err != nil || resp.StatusCode != 200 {
+				return
+			}
+			data := []byte(resp.Text)
+			if !openapi.LooksLikeSpec(data) {
+				return
+			}
+			doc, err := openapi.Parse(data)
+			if errors.Is(err, openapi.ErrNotSpec) {
+				return
+			}
+			if err != nil {
+				log.Println(chalk.Yellow.Color("OpenAPI文档解析失败: " + specURL + ", " + err.Error()))
+				return
+			}
+			docs[i] = doc
+		}(i, specURL)
+	}
+	wg.Wait()
+	return docs
+}
+
+/*
+*
+将文档中的接口转换为请求，安全模式判定为危险的接口会被记录并跳过
+只生成GET接口，OpenAPIPost 开启时也生成POST接口，其它方法可能修改或删除数据，不生成
+servers 中的地址可能指向其它域名，范围外的接口不生成，避免将请求头发往其它域名
+*/
+func (t *CrawlerTask) openAPIRequests(headers map[string]string, operations []*openapi.Request) []*model.Request {
+	var result []*model.Request
+	for _, operation := range operations {
+		if operation.Method != config.GET && !(t.Config.OpenAPIPost && operation.Method == config.POST) {
+			continue
+		}
+		if !t.inScope(operation.URL) {
+			continue
+		}
+		url, err := model.GetUrl(operation.URL)
+		if err != nil {
+			continue
+		}
+		reqHeaders := map[string]interface{}{}
+		for key, value := range headers {
+			reqHeaders[key] = value
+		}
+		for key, value := range operation.Headers {
+			reqHeaders[key] = value
+		}
+		req := model.GetRequest(operation.Method, url, model.Options{
+			Headers:  reqHeaders,
+			PostData: operation.Body,
+		})
+		req.Source = config.FromOpenAPI
+		req.Proxy = t.Config.Proxy
+		if t.SafePolicy != nil {
+			decision := t.SafePolicy.ClassifyRequest(req.Method, operation.URL, req.PostData)
+			if !decision.Allowed() {
+				t.SafePolicy.Record("request", req.Method+" "+operation.URL, operation.URL, decision)
+				continue
+			}
+		}
+		result = append(result, &req)
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+package crawlergo
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	filter3 "katanacrawlgo/pkg/crawlergo/filter"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/openapi"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectOpenAPISpecURLs(t *testing.T) {
+	target := newGraphQLRequest(t, config.GET, "https://test.com/index.html", "")
+	found := newGraphQLRequest(t, config.GET, "https://test.com/docs/spec.yaml", "")
+	found.Source = config.FromOpenAPI
+	reqList := []*model.Request{
+		target,
+		found,
+		newGraphQLRequest(t, config.GET, "https://api.test.com/v3/api-docs", ""),
+		newGraphQLRequest(t, config.POST, "https://test.com/swagger.json", "a=1"),
+	}
+	specURLs := CollectOpenAPISpecURLs([]*model.Request{target, target}, reqList)
+	assert.Equal(t, []string{"https://test.com/docs/spec.yaml", "https://api.test.com/v3/api-docs"}, specURLs[:2])
+	assert.Equal(t, "https://test.com/swagger.json", specURLs[2])
+	assert.Len(t, specURLs, 2+len(openapi.SpecPaths), "probed locations of the target are added once")
+}
+
+func TestExpandOpenAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/docs/spec.yaml":
+			_, _ = w.Write([]byte(`
+swagger: "2.0"
+paths:
+  /items/{id}:
+    get:
+      parameters:
+        - {name: id, in: path, type: integer}
+    delete:
+      parameters:
+        - {name: id, in: path, type: integer}
+  /items:
+    post:
+      parameters:
+        - name: body
+          in: body
+          schema:
+            type: object
+            properties:
+              name: {type: string}
+`))
+		case "/other/spec.yaml":
+			_, _ = w.Write([]byte(`
+swagger: "2.0"
+host: api.other.example
+paths:
+  /items:
+    get: {}
+`))
+		case "/swagger.json":
+			_, _ = w.Write([]byte(`<html><body>swagger paths</body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	target := newGraphQLRequest(t, config.GET, server.URL+"/", "")
+	found := newGraphQLRequest(t, config.GET, server.URL+"/docs/spec.yaml", "")
+	found.Source = config.FromOpenAPI
+	otherHost := newGraphQLRequest(t, config.GET, server.URL+"/other/spec.yaml", "")
+	otherHost.Source = config.FromOpenAPI
+	outOfScope := newGraphQLRequest(t, config.GET, "http://127.0.0.1:1/swagger.json", "")
+	outOfScope.Source = config.FromOpenAPI
+	url, err := model.GetUrl(server.URL)
+	require.NoError(t, err)
+	newTask := func(post bool) *CrawlerTask {
+		base := filter3.NewSimpleFilter(url.Host)
+		return &CrawlerTask{
+			Config:    &TaskConfig{OpenAPIDiscovery: true, OpenAPIPost: post},
+			Targets:   []*model.Request{target},
+			Result:    &Result{AllReqList: []*model.Request{found, otherHost, outOfScope}},
+			filter:    filter3.NewSmartFilter(base, false),
+			scope:     filter3.ScopeStage(base),
+			allReqSet: filter3.NewMemoryStore(),
+		}
+	}
+	generated := func(task *CrawlerTask) []string {
+		var generated []string
+		for _, req := range task.Result.ReqList {
+			assert.Equal(t, config.FromOpenAPI, req.Source)
+			generated = append(generated, req.Method+" "+req.URL.String()+" "+req.PostData)
+		}
+		return generated
+	}
+
+	task := newTask(false)
+	task.expandOpenAPI()
+	assert.Equal(t, []string{server.URL + "/docs/spec.yaml", server.URL + "/other/spec.yaml"}, task.Result.OpenAPISpecs, "specs out of scope are not fetched")
+	assert.Equal(t, []string{"GET " + server.URL + "/items/1 "}, generated(task), "only GET operations in scope by default")
+
+	task = newTask(true)
+	task.expandOpenAPI()
+	assert.Equal(t, []string{
+		"POST " + server.URL + `/items {"name":"test"}`,
+		"GET " + server.URL + "/items/1 ",
+	}, generated(task))
+	assert.Equal(t, "application/json", task.Result.ReqList[0].Headers["Content-Type"])
+}
@@ -0,0 +1,362 @@
+package safemode
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/ttacon/chalk"
+	"gopkg.in/yaml.v3"
+)
+
+// 规则作用的对象
+const (
+	TargetAll     = "all"
+	TargetElement = "element" // 按钮、链接、表单
+	TargetRequest = "request" // 浏览器发出的请求
+)
+
+// 命中规则后的处理方式
+const (
+	ActionAllow = "allow"
+	ActionBlock = "block" // 阻止点击、提交，请求直接失败
+	ActionFake  = "fake"  // 请求不发往服务器，返回伪造的响应
+)
+
+type Rule struct {
+	Name     string   `yaml:"name"`
+	Target   string   `yaml:"target"`   // all、element、request，为空则为 all
+	Keywords []string `yaml:"keywords"` // 匹配文本、属性、URL中的单词，不区分大小写，GET、HEAD 请求不匹配关键字
+	Regex    string   `yaml:"regex"`    // 匹配文本、属性、URL的正则
+	Methods  []string `yaml:"methods"`  // 匹配请求方法、表单提交方法
+	Action   string   `yaml:"action"`   // block、fake
+
+	regex *regexp.Regexp
+}
+
+type Policy struct {
+	Rules           []*Rule `yaml:"rules"`
+	ReplaceDefaults bool    `yaml:"replace_defaults"` // 自定义规则替换默认规则，否则追加在默认规则之前
+	FakeStatus      int     `yaml:"fake_status"`
+	FakeBody        string  `yaml:"fake_body"`
+
+	lock    sync.Mutex
+	blocked []BlockedAction
+}
+
+/*
+*
+页面中待分类的元素
+*/
+type Element struct {
+	Tag        string            `json:"tag"`
+	Text       string            `json:"text"`
+	Attributes map[string]string `json:"attrs"`
+	FormAction string            `json:"formAction"`
+	FormMethod string            `json:"formMethod"`
+}
+
+type Decision struct {
+	Action string
+	Rule   string
+	Match  string
+}
+
+func (d Decision) Allowed() bool {
+	return d.Action == "" || d.Action == ActionAllow
+}
+
+/*
+*
+被拦截的操作记录
+*/
+type BlockedAction struct {
+	Kind   string `json:"kind"` // element、request
+	Target string `json:"target"`
+	Action string `json:"action"`
+	Rule   string `json:"rule"`
+	Match  string `json:"match"`
+	Page   string `json:"page"`
+}
+
+var DefaultRules = []*Rule{
+	{
+		Name:    "destructive-method",
+		Target:  TargetRequest,
+		Methods: []string{"DELETE"},
+		Action:  ActionFake,
+	},
+	{
+		Name:   "session",
+		Target: TargetAll,
+		Keywords: []string{"logout", "log out", "logoff", "log off", "signout", "sign out", "sign off",
+			"注销", "退出登录", "退出"},
+		Action: ActionBlock,
+	},
+	{
+		Name:   "destructive",
+		Target: TargetAll,
+		Keywords: []string{"delete", "remove", "destroy", "drop table", "drop database", "truncate", "purge", "erase", "wipe",
+			"deactivate", "disable account", "close account", "unsubscribe", "revoke", "terminate", "shutdown",
+			"reboot", "restart", "uninstall", "factory reset", "reset all", "format disk",
+			"删除", "移除", "清空", "销毁", "重置", "卸载", "注销账号", "关闭账户", "重启", "关机"},
+		Action: ActionBlock,
+	},
+	{
+		Name:     "payment",
+		Target:   TargetElement,
+		Keywords: []string{"pay now", "place order", "purchase", "checkout", "transfer", "withdraw", "支付", "付款", "转账", "提现", "下单"},
+		Action:   ActionBlock,
+	},
+}
+
+/*
+*
+默认策略
+*/
+func DefaultPolicy() *Policy {
+	policy := &Policy{}
+	policy.Rules = append(policy.Rules, DefaultRules...)
+	_ = policy.compile()
+	return policy
+}
+
+/*
+*
+从YAML文件加载策略，路径为空时使用默认策略
+*/
+func LoadPolicy(path string) (*Policy, error) {
+	if path == "" {
+		return DefaultPolicy(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, err
+	}
+	if !policy.ReplaceDefaults {
+		policy.Rules = append(policy.Rules, DefaultRules...)
+	}
+	if err := policy.compile(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (p *Policy) compile() error {
+	if p.FakeStatus == 0 {
+		p.FakeStatus = 200
+	}
+	if p.FakeBody == "" {
+		p.FakeBody = "{}"
+	}
+	for _, rule := range p.Rules {
+		if rule.Target == "" {
+			rule.Target = TargetAll
+		}
+		if rule.Action == "" {
+			rule.Action = ActionBlock
+		}
+		if rule.Regex != "" && rule.regex == nil {
+			regex, err := regexp.Compile("(?i)" + rule.Regex)
+			if err != nil {
+				return fmt.Errorf("safe mode rule %s: %w", rule.Name, err)
+			}
+			rule.regex = regex
+		}
+	}
+	return nil
+}
+
+/*
+*
+对按钮、链接、表单进行分类，匹配文本、属性以及表单的提交地址和方法
+*/
+func (p *Policy) ClassifyElement(e Element) Decision {
+	texts := []string{e.Text, e.FormAction}
+	for _, name := range []string{"id", "name", "class", "value", "title", "aria-label", "href", "action", "onclick", "formaction", "data-action"} {
+		if value, ok := e.Attributes[name]; ok {
+			texts = append(texts, value)
+		}
+	}
+	method := strings.ToUpper(e.FormMethod)
+	for _, rule := range p.Rules {
+		if rule.Target == TargetRequest {
+			continue
+		}
+		if match, ok := rule.match(method, texts, true); ok {
+			// 元素无法伪造响应，统一阻止
+			return Decision{Action: ActionBlock, Rule: rule.Name, Match: match}
+		}
+	}
+	return Decision{Action: ActionAllow}
+}
+
+/*
+*
+对浏览器发出的请求进行分类，匹配方法、URL和请求体
+GET、HEAD 请求只匹配方法和正则，页面、脚本等资源的URL中常有 remove、reset 之类的单词，按关键字匹配会阻止正常的资源
+*/
+func (p *Policy) ClassifyRequest(method string, url string, postData string) Decision {
+	method = strings.ToUpper(method)
+	texts := []string{url, postData}
+	keywords := method != "GET" && method != "HEAD"
+	for _, rule := range p.Rules {
+		if rule.Target == TargetElement {
+			continue
+		}
+		if match, ok := rule.match(method, texts, keywords); ok {
+			return Decision{Action: rule.Action, Rule: rule.Name, Match: match}
+		}
+	}
+	return Decision{Action: ActionAllow}
+}
+
+/*
+*
+记录并输出被拦截的操作
+*/
+func (p *Policy) Record(kind string, target string, page string, decision Decision) {
+	action := BlockedAction{
+		Kind:   kind,
+		Target: target,
+		Action: decision.Action,
+		Rule:   decision.Rule,
+		Match:  decision.Match,
+		Page:   page,
+	}
+	log.Println(chalk.Yellow.Color(fmt.Sprintf("安全模式拦截 %s [%s/%s: %s]: %s", kind, action.Action, action.Rule, action.Match, target)))
+	p.lock.Lock()
+	p.blocked = append(p.blocked, action)
+	p.lock.Unlock()
+}
+
+/*
+*
+全部被拦截的操作
+*/
+func (p *Policy) BlockedActions() []BlockedAction {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]BlockedAction{}, p.blocked...)
+}
+
+func (rule *Rule) match(method string, texts []string, keywords bool) (string, bool) {
+	// 同时配置了方法与关键字时，需要两者都命中
+	if len(rule.Methods) > 0 {
+		if method == "" || !containsFold(rule.Methods, method) {
+			return "", false
+		}
+		if len(rule.Keywords) == 0 && rule.regex == nil {
+			return method, true
+		}
+	}
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+		if rule.regex != nil {
+			if match := rule.regex.FindString(text); match != "" {
+				return match, true
+			}
+		}
+		if !keywords || len(rule.Keywords) == 0 {
+			continue
+		}
+		words := splitWords(text)
+		for _, keyword := range rule.Keywords {
+			if matchKeyword(text, words, keyword) {
+				return keyword, true
+			}
+		}
+	}
+	return "", false
+}
+
+/*
+*
+英文关键字按单词匹配，避免 information 命中 format 之类的误报，允许常见的词尾变化
+非英文关键字直接按子串匹配
+*/
+func matchKeyword(text string, words []string, keyword string) bool {
+	keyword = strings.ToLower(keyword)
+	for _, r := range keyword {
+		if r > unicode.MaxASCII {
+			return strings.Contains(strings.ToLower(text), keyword)
+		}
+	}
+	keywordWords := strings.Fields(keyword)
+	if len(keywordWords) == 0 {
+		return false
+	}
+	for i := 0; i+len(keywordWords) <= len(words); i++ {
+		matched := true
+		for j, keywordWord := range keywordWords {
+			if !matchWord(words[i+j], keywordWord) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func matchWord(word string, keyword string) bool {
+	if !strings.HasPrefix(word, keyword) {
+		return false
+	}
+	switch strings.TrimPrefix(word, keyword) {
+	case "", "s", "d", "ed", "ing", "all":
+		return true
+	}
+	return false
+}
+
+/*
+*
+将文本拆分为小写单词，按非字母字符以及驼峰边界拆分
+*/
+func splitWords(text string) []string {
+	var words []string
+	var current []rune
+	var prev rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+	for _, r := range text {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			flush()
+			prev = r
+			continue
+		}
+		if unicode.IsUpper(r) && unicode.IsLower(prev) {
+			flush()
+		}
+		current = append(current, r)
+		prev = r
+	}
+	flush()
+	return words
+}
+
+func containsFold(list []string, item string) bool {
+	for _, value := range list {
+		if strings.EqualFold(value, item) {
+			return true
+		}
+	}
+	return false
+}
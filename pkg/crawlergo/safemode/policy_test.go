@@ -0,0 +1,86 @@
+package safemode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyElement(t *testing.T) {
+	policy := DefaultPolicy()
+
+	tests := []struct {
+		name    string
+		element Element
+		allowed bool
+	}{
+		{"delete button", Element{Tag: "button", Text: "Delete account"}, false},
+		{"remove by class", Element{Tag: "a", Attributes: map[string]string{"class": "btn btn-remove-item"}}, false},
+		{"logout link", Element{Tag: "a", Text: "Profile", Attributes: map[string]string{"href": "/user/signOut"}}, false},
+		{"form action", Element{Tag: "form", FormAction: "http://test.com/admin/deleteUser", FormMethod: "post"}, false},
+		{"chinese", Element{Tag: "button", Text: "删除文章"}, false},
+		{"payment", Element{Tag: "button", Text: "Checkout"}, false},
+		{"search", Element{Tag: "button", Text: "Search"}, true},
+		{"information", Element{Tag: "a", Text: "More information", Attributes: map[string]string{"class": "dropdown-toggle"}}, true},
+		{"deleted word boundary", Element{Tag: "a", Text: "undelete history"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, policy.ClassifyElement(tt.element).Allowed())
+		})
+	}
+}
+
+func TestClassifyRequest(t *testing.T) {
+	policy := DefaultPolicy()
+
+	decision := policy.ClassifyRequest("delete", "http://test.com/api/users/1", "")
+	assert.Equal(t, ActionFake, decision.Action)
+	assert.Equal(t, "destructive-method", decision.Rule)
+
+	decision = policy.ClassifyRequest("POST", "http://test.com/api/post", `{"op":"drop table users"}`)
+	assert.Equal(t, ActionBlock, decision.Action)
+
+	decision = policy.ClassifyRequest("POST", "http://test.com/logout.php", "")
+	assert.Equal(t, ActionBlock, decision.Action)
+
+	// GET、HEAD 请求不按关键字匹配，页面和脚本的URL中常有这些单词
+	assert.True(t, policy.ClassifyRequest("GET", "http://test.com/docs/remove-a-user.html", "").Allowed())
+	assert.True(t, policy.ClassifyRequest("GET", "http://test.com/static/js/password-reset.js", "").Allowed())
+	assert.True(t, policy.ClassifyRequest("GET", "http://test.com/help/delete-account", "").Allowed())
+	assert.True(t, policy.ClassifyRequest("HEAD", "http://test.com/logout.php", "").Allowed())
+
+	// 支付规则只作用于页面元素
+	assert.True(t, policy.ClassifyRequest("POST", "http://test.com/checkout", "").Allowed())
+	assert.True(t, policy.ClassifyRequest("GET", "http://test.com/api/list?page=1", "").Allowed())
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	content := `
+replace_defaults: true
+fake_status: 204
+rules:
+  - name: archive
+    target: request
+    methods: [POST, PUT]
+    regex: /archive/
+    action: fake
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	policy, err := LoadPolicy(path)
+	require.NoError(t, err)
+	assert.Equal(t, 204, policy.FakeStatus)
+	assert.Equal(t, "{}", policy.FakeBody)
+	assert.Len(t, policy.Rules, 1)
+
+	assert.Equal(t, ActionFake, policy.ClassifyRequest("POST", "http://test.com/archive/1", "").Action)
+	assert.True(t, policy.ClassifyRequest("GET", "http://test.com/archive/1", "").Allowed())
+	assert.True(t, policy.ClassifyRequest("DELETE", "http://test.com/api/1", "").Allowed())
+
+	policy.Record("request", "POST http://test.com/archive/1", "http://test.com/", policy.ClassifyRequest("POST", "http://test.com/archive/1", ""))
+	assert.Len(t, policy.BlockedActions(), 1)
+}
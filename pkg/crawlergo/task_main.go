@@ -2,11 +2,19 @@ package crawlergo
 
 import (
 	"encoding/json"
+	"errors"
+	"katanacrawlgo/pkg/challenge"
 	"katanacrawlgo/pkg/crawlergo/config"
 	"katanacrawlgo/pkg/crawlergo/engine"
 	filter3 "katanacrawlgo/pkg/crawlergo/filter"
 	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/crawlergo/safemode"
+	"katanacrawlgo/pkg/formfill"
+	"katanacrawlgo/pkg/identity"
+	"katanacrawlgo/pkg/upload"
 	"log"
+	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,26 +24,54 @@ import (
 )
 
 type CrawlerTask struct {
-	Browser       *engine.Browser       //
-	RootDomain    string                // 当前爬取根域名 用于子域名收集
-	Targets       []*model.Request      // 输入目标
-	Result        *Result               // 最终结果
-	Config        *TaskConfig           // 配置信息
-	filter        filter3.FilterHandler // 过滤对象
-	Pool          *ants.Pool            // 协程池
-	taskWG        sync.WaitGroup        // 等待协程池所有任务结束
-	crawledCount  int                   // 爬取过的数量
-	taskCountLock sync.Mutex            // 已爬取的任务总数锁
-	Start         time.Time             //开始时间
-
+	Browser       *engine.Browser           // 本地浏览器或单个远程浏览器
+	BrowserPool   *engine.BrowserPool       // 多个远程浏览器，不为空时 Browser 为空
+	RootDomain    string                    // 当前爬取根域名 用于子域名收集
+	Targets       []*model.Request          // 输入目标
+	Result        *Result                   // 最终结果
+	Config        *TaskConfig               // 配置信息
+	filter        filter3.FilterHandler     // 过滤对象
+	scope         filter3.Stage             // 过滤链的范围环节，用于爬取结束后生成的请求
+	allReqSet     filter3.UniqueStore       // 所有请求的去重集合，收集时去重以限制 AllReqList 的大小
+	filterAuditor *filter3.Auditor          // 过滤判定的统计及审计日志
+	smartFilter   *filter3.SmartFilter      // 智能去重，用于统计重复计数
+	Pool          *ants.Pool                // 协程池
+	taskWG        sync.WaitGroup            // 等待协程池所有任务结束
+	crawledCount  int                       // 爬取过的数量
+	taskCountLock sync.Mutex                // 已爬取的任务总数锁
+	Start         time.Time                 //开始时间
+	harLogs       map[string]*engine.HarLog // 按目标合并的HAR记录
+	SafePolicy    *safemode.Policy          // 安全模式策略
+	Identities    *identity.Pool            // 浏览器身份配置文件
+	FormRules     *formfill.Rules           // 表单填充规则
+	Challenges    *challenge.Tracker        // 按域名记录的挑战页面
+	harLock       sync.Mutex
 }
 
 type Result struct {
-	ReqList       []*model.Request // 返回的同域名结果
-	AllReqList    []*model.Request // 所有域名的请求
-	AllDomainList []string         // 所有域名列表
-	SubDomainList []string         // 子域名列表
-	resultLock    sync.Mutex       // 合并结果时加锁
+	ReqList          []*model.Request         // 返回的同域名结果
+	AllReqList       []*model.Request         // 所有域名的请求
+	AllDomainList    []string                 // 所有域名列表
+	SubDomainList    []string                 // 子域名列表
+	BlockedActions   []safemode.BlockedAction // 安全模式拦截的操作
+	Findings         []*model.Finding         // 页面运行时的异常、控制台错误和sink命中
+	GraphQLEndpoints []string                 // 发现的GraphQL端点
+	OpenAPISpecs     []string                 // 解析成功的OpenAPI文档
+	UploadEndpoints  []upload.Endpoint        // multipart表单提交的文件上传接口
+	ChallengeHosts   []challenge.HostReport   // 返回过验证码或JS挑战页面的域名
+	Stats            RunStats                 // 任务结束时的统计
+	resultLock       sync.Mutex               // 合并结果时加锁
+}
+
+// RunStats 任务运行的统计
+type RunStats struct {
+	Duration    time.Duration                `json:"duration"`
+	FilterStore string                       `json:"filter_store"`
+	Filter      filter3.FilterStats          `json:"filter"`
+	Decisions   map[string]int               `json:"filter_decisions"`                // 按原因统计的过滤判定，未过滤的为 kept
+	Counters    *filter3.SmartFilterCounters `json:"smart_filter_counters,omitempty"` // 智能去重的重复统计
+	AllRequests int                          `json:"all_requests"`
+	HeapBytes   uint64                       `json:"heap_bytes"` // 任务结束时的堆内存占用
 }
 
 type tabTask struct {
@@ -54,16 +90,48 @@ func NewCrawlerTask(targets []*model.Request, taskConf TaskConfig) (*CrawlerTask
 		Config: &taskConf,
 	}
 
-	baseFilter := filter3.NewSimpleFilter(targets[0].URL.Host)
-
-	if taskConf.FilterMode == config.SmartFilterMode {
-		crawlerTask.filter = filter3.NewSmartFilter(baseFilter, false)
+	uniqueStore, err := newFilterStore(taskConf)
+	if err != nil {
+		log.Println(chalk.Red.Color("error: 去重集合创建失败, " + err.Error()))
+		return nil, err
+	}
+	baseFilter := filter3.NewSimpleFilterWithStore(targets[0].URL.Host, uniqueStore)
 
-	} else if taskConf.FilterMode == config.StrictFilterMode {
-		crawlerTask.filter = filter3.NewSmartFilter(baseFilter, true)
+	var dedupe filter3.Deduplicator = baseFilter
+	if taskConf.FilterMode == config.SmartFilterMode || taskConf.FilterMode == config.StrictFilterMode {
+		smartConf, err := filter3.LoadSmartFilterConfig(taskConf.FilterProfile, taskConf.FilterConfigFile)
+		if err != nil {
+			log.Println(chalk.Red.Color("error: 智能去重配置加载失败, " + err.Error()))
+			return nil, err
+		}
+		markedStore, err := newFilterStore(taskConf)
+		if err != nil {
+			log.Println(chalk.Red.Color("error: 去重集合创建失败, " + err.Error()))
+			return nil, err
+		}
+		crawlerTask.smartFilter = filter3.NewSmartFilterWithConfig(baseFilter, taskConf.FilterMode == config.StrictFilterMode, markedStore, smartConf)
+		dedupe = crawlerTask.smartFilter
+	}
 
-	} else {
-		crawlerTask.filter = baseFilter
+	rules, err := filter3.LoadRules(taskConf.FilterRulesFile)
+	if err != nil {
+		log.Println(chalk.Red.Color("error: 过滤规则加载失败, " + err.Error()))
+		return nil, err
+	}
+	chain := filter3.NewDefaultChain(baseFilter, rules, dedupe)
+	chain.Auditor, err = filter3.NewAuditor(taskConf.FilterAuditFile)
+	if err != nil {
+		log.Println(chalk.Red.Color("error: 过滤审计日志创建失败, " + err.Error()))
+		return nil, err
+	}
+	crawlerTask.filter = chain
+	crawlerTask.scope = filter3.ScopeStage(baseFilter)
+	crawlerTask.filterAuditor = chain.Auditor
+
+	crawlerTask.allReqSet, err = newAllReqStore(taskConf)
+	if err != nil {
+		log.Println(chalk.Red.Color("error: 去重集合创建失败, " + err.Error()))
+		return nil, err
 	}
 
 	if len(targets) == 1 {
@@ -95,10 +163,22 @@ func NewCrawlerTask(targets []*model.Request, taskConf TaskConfig) (*CrawlerTask
 		WithBeforeExitDelay(config.BeforeExitDelay),
 		WithEventTriggerMode(config.DefaultEventTriggerMode),
 		WithIgnoreKeywords(config.DefaultIgnoreKeywords),
+		WithHarMode(config.HarModeTab),
+		WithScrollStepSize(config.ScrollStepSize),
+		WithScrollInterval(config.ScrollInterval),
+		WithStateMaxActions(config.StateMaxActions),
+		WithStateMaxDepth(config.StateMaxDepth),
 	} {
 		fn(&taskConf)
 	}
 
+	// 负数步长会向上滚动，无法触发懒加载
+	if taskConf.ScrollMaxSteps > 0 && taskConf.ScrollStepSize <= 0 {
+		err := errors.New("scroll step size must be greater than 0")
+		log.Println(chalk.Red.Color("error: 页面滚动步长必须大于0"))
+		return nil, err
+	}
+
 	if taskConf.ExtraHeadersString != "" {
 		err := json.Unmarshal([]byte(taskConf.ExtraHeadersString), &taskConf.ExtraHeaders)
 		if err != nil {
@@ -107,8 +187,52 @@ func NewCrawlerTask(targets []*model.Request, taskConf TaskConfig) (*CrawlerTask
 		}
 	}
 
-	if len(taskConf.ChromiumWSUrl) > 0 {
-		crawlerTask.Browser = engine.ConnectBrowser(taskConf.ChromiumWSUrl, taskConf.ExtraHeaders)
+	if taskConf.SafeMode {
+		policy, err := safemode.LoadPolicy(taskConf.SafeModeRules)
+		if err != nil {
+			log.Println(chalk.Red.Color("error: 安全模式规则加载失败, " + err.Error()))
+			return nil, err
+		}
+		crawlerTask.SafePolicy = policy
+	}
+
+	identities, err := identity.NewPool(taskConf.IdentityProfiles)
+	if err != nil {
+		log.Println(chalk.Red.Color("error: 浏览器身份配置加载失败, " + err.Error()))
+		return nil, err
+	}
+	crawlerTask.Identities = identities
+
+	crawlerTask.FormRules = formfill.Default()
+	if taskConf.FormRulesFile != "" {
+		rules, err := formfill.Load(taskConf.FormRulesFile)
+		if err != nil {
+			log.Println(chalk.Red.Color("error: 表单填充规则加载失败, " + err.Error()))
+			return nil, err
+		}
+		crawlerTask.FormRules = rules
+	}
+
+	crawlerTask.Challenges = taskConf.ChallengeTracker
+	if crawlerTask.Challenges == nil {
+		crawlerTask.Challenges = challenge.NewTracker()
+	}
+
+	var wsUrls []string
+	for _, wsUrl := range append([]string{taskConf.ChromiumWSUrl}, taskConf.ChromiumWSUrls...) {
+		if wsUrl = strings.TrimSpace(wsUrl); wsUrl != "" {
+			wsUrls = append(wsUrls, wsUrl)
+		}
+	}
+	if len(wsUrls) > 1 {
+		browserPool, err := engine.NewBrowserPool(wsUrls, taskConf.ExtraHeaders)
+		if err != nil {
+			log.Println(chalk.Red.Color("error: 远程浏览器池创建失败, " + err.Error()))
+			return nil, err
+		}
+		crawlerTask.BrowserPool = browserPool
+	} else if len(wsUrls) == 1 {
+		crawlerTask.Browser = engine.ConnectBrowser(wsUrls[0], taskConf.ExtraHeaders)
 	} else {
 		crawlerTask.Browser = engine.InitBrowser(taskConf.ChromiumPath, taskConf.ExtraHeaders, taskConf.Proxy, taskConf.NoHeadless)
 	}
@@ -121,6 +245,39 @@ func NewCrawlerTask(targets []*model.Request, taskConf TaskConfig) (*CrawlerTask
 	return &crawlerTask, nil
 }
 
+/*
+*
+按配置新建去重集合
+*/
+func newFilterStore(taskConf TaskConfig) (filter3.UniqueStore, error) {
+	return filter3.NewUniqueStore(filter3.StoreOptions{
+		Type:          taskConf.FilterStore,
+		Capacity:      taskConf.FilterCapacity,
+		FalsePositive: taskConf.FilterFalsePositive,
+	})
+}
+
+/*
+*
+所有请求的去重集合决定输出的 AllReqList，不能有误判，布隆过滤器存储时使用内存集合
+*/
+func newAllReqStore(taskConf TaskConfig) (filter3.UniqueStore, error) {
+	if taskConf.FilterStore == filter3.StoreDisk {
+		return filter3.NewDiskStore()
+	}
+	return filter3.NewMemoryStore(), nil
+}
+
+/*
+*
+请求不重复时加入 AllReqList
+*/
+func (t *CrawlerTask) addAllRequest(req *model.Request) {
+	if t.allReqSet.AddIfAbsent(req.UniqueId()) {
+		t.Result.AllReqList = append(t.Result.AllReqList, req)
+	}
+}
+
 /*
 *
 根据请求列表生成tabTask协程任务列表
@@ -134,13 +291,26 @@ func (t *CrawlerTask) generateTabTask(req *model.Request) *tabTask {
 	return &task
 }
 
+/*
+*
+关闭浏览器或远程浏览器池
+*/
+func (t *CrawlerTask) CloseBrowser() {
+	if t.BrowserPool != nil {
+		t.BrowserPool.Close()
+		return
+	}
+	t.Browser.Close()
+}
+
 /*
 *
 开始当前任务
 */
 func (t *CrawlerTask) Run() {
-	defer t.Pool.Release()  // 释放协程池
-	defer t.Browser.Close() // 关闭浏览器
+	defer t.Pool.Release() // 释放协程池
+	defer t.CloseBrowser() // 关闭浏览器
+	defer t.closeFilter()  // 释放去重集合
 
 	t.Start = time.Now()
 	if t.Config.PathFromRobots {
@@ -158,7 +328,9 @@ func (t *CrawlerTask) Run() {
 		t.Targets = append(t.Targets, reqsByFuzz...)
 	}
 
-	t.Result.AllReqList = t.Targets[:]
+	for _, req := range t.Targets {
+		t.addAllRequest(req)
+	}
 
 	var initTasks []*model.Request
 	for _, req := range t.Targets {
@@ -177,22 +349,65 @@ func (t *CrawlerTask) Run() {
 
 	t.taskWG.Wait()
 
-	// 对全部请求进行唯一去重
-	todoFilterAll := make([]*model.Request, len(t.Result.AllReqList))
-	copy(todoFilterAll, t.Result.AllReqList)
+	// OpenAPI文档探测及解析生成请求
+	t.expandOpenAPI()
 
-	t.Result.AllReqList = []*model.Request{}
-	var simpleFilter filter3.SimpleFilter
-	for _, req := range todoFilterAll {
-		if !simpleFilter.UniqueFilter(req) {
-			t.Result.AllReqList = append(t.Result.AllReqList, req)
-		}
-	}
+	// GraphQL端点识别及内省生成请求
+	t.expandGraphQL()
 
 	// 全部域名
 	t.Result.AllDomainList = AllDomainCollect(t.Result.AllReqList)
 	// 子域名
 	t.Result.SubDomainList = SubDomainCollect(t.Result.AllReqList, t.RootDomain)
+	// 文件上传接口
+	t.Result.UploadEndpoints = UploadEndpointCollect(t.Result.AllReqList)
+
+	if t.SafePolicy != nil {
+		t.Result.BlockedActions = t.SafePolicy.BlockedActions()
+	}
+	t.Result.ChallengeHosts = t.Challenges.Report()
+	t.Result.Stats = t.runStats()
+
+	t.writeTargetHar()
+}
+
+/*
+*
+统计运行时间、去重状态和内存占用
+*/
+func (t *CrawlerTask) runStats() RunStats {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	stats := RunStats{
+		Duration:    time.Since(t.Start),
+		FilterStore: t.Config.FilterStore,
+		Filter:      t.filter.Stats(),
+		Decisions:   t.filterAuditor.Summary(),
+		AllRequests: len(t.Result.AllReqList),
+		HeapBytes:   memStats.HeapAlloc,
+	}
+	if stats.FilterStore == "" {
+		stats.FilterStore = filter3.StoreMemory
+	}
+	if t.smartFilter != nil {
+		counters := t.smartFilter.Counters()
+		stats.Counters = &counters
+	}
+	stats.Filter.MemoryBytes += t.allReqSet.MemoryBytes()
+	return stats
+}
+
+/*
+*
+释放过滤器和请求去重集合，磁盘上的集合会被删除
+*/
+func (t *CrawlerTask) closeFilter() {
+	if err := t.filter.Close(); err != nil {
+		log.Println(chalk.Red.Color("error: " + err.Error()))
+	}
+	if err := t.allReqSet.Close(); err != nil {
+		log.Println(chalk.Red.Color("error: " + err.Error()))
+	}
 }
 
 /*
@@ -201,6 +416,14 @@ func (t *CrawlerTask) Run() {
 添加之前实时过滤
 */
 func (t *CrawlerTask) addTask2Pool(req *model.Request) {
+	// 安全模式判定为危险的请求不打开新的标签页
+	if t.SafePolicy != nil {
+		decision := t.SafePolicy.ClassifyRequest(req.Method, req.URL.String(), req.PostData)
+		if !decision.Allowed() {
+			t.SafePolicy.Record("request", req.Method+" "+req.URL.String(), req.URL.String(), decision)
+			return
+		}
+	}
 	t.taskCountLock.Lock()
 	if t.crawledCount >= t.Config.MaxCrawlCount {
 		t.taskCountLock.Unlock()
@@ -215,9 +438,18 @@ func (t *CrawlerTask) addTask2Pool(req *model.Request) {
 	}
 	t.taskCountLock.Unlock()
 
+	// 域名持续返回挑战页面时退避，预约该域名下一次请求的时间，在提交到协程池之前等待
+	wait, ok := t.Challenges.Reserve(req.URL.Hostname())
+	if !ok {
+		return
+	}
+
 	t.taskWG.Add(1)
 	task := t.generateTabTask(req)
 	go func() {
+		if wait > 0 {
+			time.Sleep(wait)
+		}
 		err := t.Pool.Submit(task.Task)
 		if err != nil {
 			t.taskWG.Done()
@@ -233,6 +465,12 @@ func (t *CrawlerTask) addTask2Pool(req *model.Request) {
 func (t *tabTask) Task() {
 	defer t.crawlerTask.taskWG.Done()
 
+	// 等待期间域名返回挑战页面的次数超过限制，不再打开
+	host := t.req.URL.Hostname()
+	if t.crawlerTask.Challenges.GaveUp(host) {
+		return
+	}
+
 	// 设置tab超时时间，若设置了程序最大运行时间， tab超时时间和程序剩余时间取小
 	timeremaining := t.crawlerTask.Start.Add(time.Duration(t.crawlerTask.Config.MaxRunTime) * time.Second).Sub(time.Now())
 	tabTime := t.crawlerTask.Config.TabRunTimeout
@@ -244,22 +482,31 @@ func (t *tabTask) Task() {
 		return
 	}
 
-	tab := engine.NewTab(t.browser, *t.req, engine.TabConfig{
-		TabRunTimeout:           tabTime,
-		DomContentLoadedTimeout: t.crawlerTask.Config.DomContentLoadedTimeout,
-		EventTriggerMode:        t.crawlerTask.Config.EventTriggerMode,
-		EventTriggerInterval:    t.crawlerTask.Config.EventTriggerInterval,
-		BeforeExitDelay:         t.crawlerTask.Config.BeforeExitDelay,
-		EncodeURLWithCharset:    t.crawlerTask.Config.EncodeURLWithCharset,
-		IgnoreKeywords:          t.crawlerTask.Config.IgnoreKeywords,
-		CustomFormValues:        t.crawlerTask.Config.CustomFormValues,
-		CustomFormKeywordValues: t.crawlerTask.Config.CustomFormKeywordValues,
-	})
+	browser := t.browser
+	if t.crawlerTask.BrowserPool != nil {
+		browser = t.crawlerTask.BrowserPool.Acquire()
+		if browser == nil {
+			log.Println(chalk.Red.Color("error: 没有可用的远程浏览器, " + t.req.URL.String()))
+			return
+		}
+		defer t.crawlerTask.BrowserPool.Release(browser)
+	}
+
+	tab := engine.NewTab(browser, *t.req, t.tabConfig(tabTime))
 	tab.Start()
+	t.crawlerTask.collectHar(tab)
+	t.crawlerTask.Challenges.Record(host, t.req.URL.String(), tab.Challenge)
+	t.req.Challenge = tab.Challenge
+	if len(tab.RedirectChain) > 0 {
+		t.req.RedirectChain = tab.RedirectChain
+	}
 
 	// 收集结果
 	t.crawlerTask.Result.resultLock.Lock()
-	t.crawlerTask.Result.AllReqList = append(t.crawlerTask.Result.AllReqList, tab.ResultList...)
+	for _, req := range tab.ResultList {
+		t.crawlerTask.addAllRequest(req)
+	}
+	t.crawlerTask.Result.Findings = append(t.crawlerTask.Result.Findings, tab.Findings...)
 	t.crawlerTask.Result.resultLock.Unlock()
 
 	for _, req := range tab.ResultList {
@@ -273,3 +520,52 @@ func (t *tabTask) Task() {
 		}
 	}
 }
+
+/*
+*
+地址是否在爬取范围内，与 ReqList 的范围过滤相同，未设置范围时不限制
+*/
+func (t *CrawlerTask) inScope(rawURL string) bool {
+	if t.scope == nil {
+		return true
+	}
+	url, err := model.GetUrl(rawURL)
+	if err != nil {
+		return false
+	}
+	filtered, _ := t.scope.Check(&model.Request{URL: url})
+	return !filtered
+}
+
+/*
+*
+按任务配置生成标签页配置
+*/
+func (t *tabTask) tabConfig(tabTime time.Duration) engine.TabConfig {
+	return engine.TabConfig{
+		TabRunTimeout:           tabTime,
+		DomContentLoadedTimeout: t.crawlerTask.Config.DomContentLoadedTimeout,
+		EventTriggerMode:        t.crawlerTask.Config.EventTriggerMode,
+		EventTriggerInterval:    t.crawlerTask.Config.EventTriggerInterval,
+		BeforeExitDelay:         t.crawlerTask.Config.BeforeExitDelay,
+		EncodeURLWithCharset:    t.crawlerTask.Config.EncodeURLWithCharset,
+		IgnoreKeywords:          t.crawlerTask.Config.IgnoreKeywords,
+		CustomFormValues:        t.crawlerTask.Config.CustomFormValues,
+		CustomFormKeywordValues: t.crawlerTask.Config.CustomFormKeywordValues,
+		RouteDiscovery:          t.crawlerTask.Config.RouteDiscovery,
+		RecordHar:               t.crawlerTask.Config.HarDir != "",
+		ScrollStepSize:          t.crawlerTask.Config.ScrollStepSize,
+		ScrollMaxSteps:          t.crawlerTask.Config.ScrollMaxSteps,
+		ScrollInterval:          t.crawlerTask.Config.ScrollInterval,
+		StateExplore:            t.crawlerTask.Config.StateExplore,
+		StateMaxActions:         t.crawlerTask.Config.StateMaxActions,
+		StateMaxDepth:           t.crawlerTask.Config.StateMaxDepth,
+		SafePolicy:              t.crawlerTask.SafePolicy,
+		FrameCrawl:              t.crawlerTask.Config.FrameCrawl,
+		Identity:                t.crawlerTask.Identities.ForTarget(t.req.URL.Hostname()),
+		SinkTelemetry:           t.crawlerTask.Config.SinkTelemetry,
+		OpenAPIDiscovery:        t.crawlerTask.Config.OpenAPIDiscovery,
+		FormRules:               t.crawlerTask.FormRules,
+		FormVariants:            t.crawlerTask.Config.FormVariants,
+	}
+}
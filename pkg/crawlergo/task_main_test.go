@@ -0,0 +1,39 @@
+package crawlergo
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/engine"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/formfill"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTabConfigSinkCanary(t *testing.T) {
+	// 与命令行相同，总是带有 default 自定义值
+	taskConf := TaskConfig{
+		CustomFormValues: map[string]string{"default": config.DefaultInputText, "phone": "13800000000"},
+		SinkTelemetry:    true,
+	}
+	task := &CrawlerTask{Config: &taskConf, FormRules: formfill.Default()}
+	req := newGraphQLRequest(t, config.GET, "https://test.com/", "")
+	tabConf := (&tabTask{crawlerTask: task, req: req}).tabConfig(time.Minute)
+	assert.True(t, tabConf.SinkTelemetry)
+
+	canary := "CrawlergoAbCdEfGh"
+	assert.Equal(t, canary, engine.MatchFieldText(&tabConf, canary, formfill.Field{Type: "text", Name: "remark"}), "the canary wins over the default value")
+	assert.Equal(t, canary, engine.MatchFieldText(&tabConf, canary, formfill.Field{Type: "textarea"}))
+	assert.Equal(t, canary, engine.MatchFieldText(&tabConf, canary, formfill.Field{Type: "text", Name: "username"}), "free text rules are filled with the canary")
+	assert.Equal(t, canary, engine.MatchFieldText(&tabConf, canary, formfill.Field{Type: "text", Autocomplete: "street-address"}))
+	assert.Equal(t, "Crawl3r@2024", engine.MatchFieldText(&tabConf, canary, formfill.Field{Type: "password", Name: "pwd"}), "typed rules keep their value")
+	assert.Equal(t, "13800000000", engine.MatchFieldText(&tabConf, canary, formfill.Field{Type: "tel", Name: "contact"}), "explicit custom values are kept")
+	assert.Equal(t, config.DefaultInputText, engine.MatchFieldText(&tabConf, "", formfill.Field{Type: "text", Name: "remark"}))
+}
+
+func TestNewCrawlerTaskScrollStepSize(t *testing.T) {
+	targets := []*model.Request{newGraphQLRequest(t, config.GET, "https://test.com/", "")}
+	_, err := NewCrawlerTask(targets, TaskConfig{ScrollMaxSteps: config.ScrollMaxSteps, ScrollStepSize: -800})
+	assert.Error(t, err, "negative step sizes scroll upwards")
+}
@@ -1,32 +1,65 @@
 package crawlergo
 
-import "time"
+import (
+	"katanacrawlgo/pkg/challenge"
+	"time"
+)
 
 type TaskConfig struct {
-	MaxCrawlCount           int    // 最大爬取的数量
-	FilterMode              string // simple、smart、strict
+	MaxCrawlCount           int     // 最大爬取的数量
+	FilterMode              string  // simple、smart、strict
+	FilterStore             string  // 去重集合的存储方式 memory、disk、bloom
+	FilterCapacity          uint    // bloom 存储预计容纳的请求数量
+	FilterFalsePositive     float64 // bloom 存储的误判率
+	FilterRulesFile         string  // 用户过滤规则的YAML文件，规则在去重之前按顺序匹配
+	FilterAuditFile         string  // 过滤判定的JSONL审计日志，为空则只统计
+	FilterProfile           string  // 智能去重的内置配置 default、aggressive、gentle
+	FilterConfigFile        string  // 智能去重阈值及启用标记的YAML文件，覆盖内置配置中的字段
 	ExtraHeaders            map[string]interface{}
 	ExtraHeadersString      string
 	AllDomainReturn         bool // 全部域名收集
 	SubDomainReturn         bool // 子域名收集
 	NoHeadless              bool // headless模式
 	DomContentLoadedTimeout time.Duration
-	TabRunTimeout           time.Duration     // 单个标签页超时
-	PathByFuzz              bool              // 通过字典进行Path Fuzz
-	FuzzDictPath            string            //Fuzz目录字典
-	PathFromRobots          bool              // 解析Robots文件找出路径
-	MaxTabsCount            int               // 允许开启的最大标签页数量 即同时爬取的数量
-	ChromiumPath            string            // Chromium的程序路径  `/home/zhusiyu1/chrome-linux/chrome`
-	ChromiumWSUrl           string            // Websocket debugging URL for a running chrome session
-	EventTriggerMode        string            // 事件触发的调用方式： 异步 或 顺序
-	EventTriggerInterval    time.Duration     // 事件触发的间隔
-	BeforeExitDelay         time.Duration     // 退出前的等待时间，等待DOM渲染，等待XHR发出捕获
-	EncodeURLWithCharset    bool              // 使用检测到的字符集自动编码URL
-	IgnoreKeywords          []string          // 忽略的关键字，匹配上之后将不再扫描且不发送请求
-	Proxy                   string            // 请求代理
-	CustomFormValues        map[string]string // 自定义表单填充参数
-	CustomFormKeywordValues map[string]string // 自定义表单关键词填充内容
-	MaxRunTime              int64             // 最大爬取时间(单位秒），超时则结束任务，平滑结束（比如某个url还未处理完不能结束，需要一次req完成后才可以结束整个任务）
+	TabRunTimeout           time.Duration      // 单个标签页超时
+	PathByFuzz              bool               // 通过字典进行Path Fuzz
+	FuzzDictPath            string             //Fuzz目录字典
+	PathFromRobots          bool               // 解析Robots文件找出路径
+	MaxTabsCount            int                // 允许开启的最大标签页数量 即同时爬取的数量
+	ChromiumPath            string             // Chromium的程序路径  `/home/zhusiyu1/chrome-linux/chrome`
+	ChromiumWSUrl           string             // Websocket debugging URL for a running chrome session
+	ChromiumWSUrls          []string           // 多个远程浏览器的DevTools地址，标签页在其间负载均衡
+	EventTriggerMode        string             // 事件触发的调用方式： 异步 或 顺序
+	EventTriggerInterval    time.Duration      // 事件触发的间隔
+	BeforeExitDelay         time.Duration      // 退出前的等待时间，等待DOM渲染，等待XHR发出捕获
+	EncodeURLWithCharset    bool               // 使用检测到的字符集自动编码URL
+	IgnoreKeywords          []string           // 忽略的关键字，匹配上之后将不再扫描且不发送请求
+	Proxy                   string             // 请求代理
+	CustomFormValues        map[string]string  // 自定义表单填充参数
+	CustomFormKeywordValues map[string]string  // 自定义表单关键词填充内容
+	FormRulesFile           string             // 自定义表单填充规则的YAML文件，为空则使用内置规则
+	FormVariants            int                // 每个表单按不同下拉框和单选组取值提交的最大次数，为0则不枚举
+	RouteDiscovery          bool               // 读取前端框架路由表以及JS中的路由定义，发现SPA路由
+	HarDir                  string             // HAR文件输出目录，为空则不记录
+	HarMode                 string             // HAR输出模式 tab、target
+	ScrollStepSize          int                // 每次滚动的像素，必须大于0
+	ScrollMaxSteps          int                // 最大滚动次数，为0则不滚动
+	ScrollInterval          time.Duration      // 每次滚动后等待懒加载的时间
+	StateExplore            bool               // 通过交互序列探索DOM状态
+	StateMaxActions         int                // 每个URL状态探索的最大交互次数
+	StateMaxDepth           int                // 交互序列的最大长度
+	SafeMode                bool               // 安全模式，阻止危险的点击、表单提交和请求
+	SafeModeRules           string             // 安全模式自定义规则的YAML文件
+	FrameCrawl              bool               // 收集iframe中的链接，并在同域的frame中填充表单、触发事件
+	IdentityProfiles        []string           // 浏览器身份配置文件名称，多个时按目标域名轮换
+	SinkTelemetry           bool               // 记录JS异常、控制台错误，以及填充值和URL参数到达危险sink
+	GraphQLIntrospect       bool               // 对发现的GraphQL端点发送内省查询，为每个字段生成代表请求
+	GraphQLMutations        bool               // 内省时同时生成mutation请求，需要显式开启
+	GraphQLEndpoints        []string           // 其它来源（如katana）发现的GraphQL端点
+	OpenAPIDiscovery        bool               // 探测常见位置及Swagger UI引用的OpenAPI文档，解析生成接口请求
+	OpenAPIPost             bool               // 同时生成OpenAPI文档中的POST接口，否则只生成GET接口
+	ChallengeTracker        *challenge.Tracker // 按域名记录挑战页面并退避，可与katana共享，为空则新建
+	MaxRunTime              int64              // 最大爬取时间(单位秒），超时则结束任务，平滑结束（比如某个url还未处理完不能结束，需要一次req完成后才可以结束整个任务）
 	URL                     string
 	URLList                 []string
 	ResultFile              string
@@ -203,3 +236,50 @@ func WithCustomFormKeywordValues(gen map[string]string) TaskConfigOptFunc {
 		}
 	}
 }
+func WithRouteDiscovery(gen bool) TaskConfigOptFunc {
+	return func(tc *TaskConfig) {
+		if !tc.RouteDiscovery {
+			tc.RouteDiscovery = gen
+		}
+	}
+}
+
+func WithHarMode(gen string) TaskConfigOptFunc {
+	return func(tc *TaskConfig) {
+		if tc.HarMode == "" {
+			tc.HarMode = gen
+		}
+	}
+}
+
+func WithScrollStepSize(gen int) TaskConfigOptFunc {
+	return func(tc *TaskConfig) {
+		if tc.ScrollStepSize == 0 {
+			tc.ScrollStepSize = gen
+		}
+	}
+}
+
+func WithScrollInterval(gen time.Duration) TaskConfigOptFunc {
+	return func(tc *TaskConfig) {
+		if tc.ScrollInterval == 0 {
+			tc.ScrollInterval = gen
+		}
+	}
+}
+
+func WithStateMaxActions(gen int) TaskConfigOptFunc {
+	return func(tc *TaskConfig) {
+		if tc.StateMaxActions == 0 {
+			tc.StateMaxActions = gen
+		}
+	}
+}
+
+func WithStateMaxDepth(gen int) TaskConfigOptFunc {
+	return func(tc *TaskConfig) {
+		if tc.StateMaxDepth == 0 {
+			tc.StateMaxDepth = gen
+		}
+	}
+}
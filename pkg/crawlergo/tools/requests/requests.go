@@ -9,11 +9,12 @@ import (
 	"strings"
 	"time"
 
+	"katanacrawlgo/pkg/identity"
+
 	"github.com/pkg/errors"
 )
 
-const DefaultUa = "Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko)" +
-	" Chrome/76.0.3809.132 Safari/537.36 C845D9D38B3A68F4F74057DB542AD252 tx/2.0"
+const DefaultUa = identity.DefaultUserAgent
 
 const defaultTimeout int = 15
 
@@ -0,0 +1,26 @@
+package crawlergo
+
+import (
+	"katanacrawlgo/pkg/crawlergo/model"
+	"katanacrawlgo/pkg/crawlergo/tools"
+	"katanacrawlgo/pkg/upload"
+	"strings"
+)
+
+/*
+*
+收集以multipart表单提交的请求地址，即可能的文件上传接口
+*/
+func UploadEndpointCollect(reqList []*model.Request) []upload.Endpoint {
+	endpoints := upload.NewEndpointSet()
+	for _, req := range reqList {
+		var contentType string
+		for key, value := range tools.ConvertHeaders(req.Headers) {
+			if strings.EqualFold(key, "Content-Type") {
+				contentType = value
+			}
+		}
+		endpoints.Add(upload.Detect(req.Method, req.URL.String(), contentType, req.PostData))
+	}
+	return endpoints.List()
+}
@@ -0,0 +1,25 @@
+package crawlergo
+
+import (
+	"katanacrawlgo/pkg/crawlergo/config"
+	"katanacrawlgo/pkg/crawlergo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadEndpointCollect(t *testing.T) {
+	upload := newGraphQLRequest(t, config.POST, "https://test.com/avatar", "--x\r\nContent-Disposition: form-data; name=\"file\"; filename=\"sample.png\"\r\nContent-Type: image/png\r\n\r\n")
+	upload.Headers = map[string]interface{}{"content-type": "multipart/form-data; boundary=x"}
+	reqList := []*model.Request{
+		newGraphQLRequest(t, config.GET, "https://test.com/index.html", ""),
+		newGraphQLRequest(t, config.POST, "https://test.com/api", `{"a":1}`),
+		upload,
+	}
+	endpoints := UploadEndpointCollect(reqList)
+	require.Len(t, endpoints, 1)
+	assert.Equal(t, "POST", endpoints[0].Method)
+	assert.Equal(t, "https://test.com/avatar", endpoints[0].URL)
+	assert.Equal(t, []string{"file"}, endpoints[0].FileFields)
+}
@@ -0,0 +1,138 @@
+package formfill
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type generator func(args map[string]string, field Field) string
+
+var generators = map[string]generator{
+	"email":      generateEmail,
+	"date":       generateDate("2006-01-02"),
+	"datetime":   generateDate("2006-01-02T15:04"),
+	"number":     generateNumber,
+	"idcard":     generateIDCard,
+	"creditcard": generateCreditCard,
+	"uuid":       generateUUID,
+}
+
+const lowerAlphaNumeric = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+func generateEmail(args map[string]string, _ Field) string {
+	domain := args["domain"]
+	if domain == "" {
+		domain = "example.com"
+	}
+	local := make([]byte, 8)
+	for i := range local {
+		local[i] = lowerAlphaNumeric[rand.Intn(len(lowerAlphaNumeric))]
+	}
+	return "crawler" + string(local) + "@" + domain
+}
+
+// generateDate returns a random day between the from and to arguments,
+// limited to the min and max attributes of the field
+func generateDate(layout string) generator {
+	return func(args map[string]string, field Field) string {
+		from := parseDate(args["from"], time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+		to := parseDate(args["to"], time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC))
+		if min := parseDate(field.Min, time.Time{}); !min.IsZero() && min.After(from) {
+			from = min
+		}
+		if max := parseDate(field.Max, time.Time{}); !max.IsZero() && max.Before(to) {
+			to = max
+		}
+		if to.Before(from) {
+			to = from
+		}
+		days := int(to.Sub(from).Hours()/24) + 1
+		date := from.AddDate(0, 0, rand.Intn(days))
+		if layout != "2006-01-02" {
+			date = date.Add(12 * time.Hour)
+		}
+		return date.Format(layout)
+	}
+}
+
+func parseDate(value string, fallback time.Time) time.Time {
+	if len(value) >= 10 {
+		if date, err := time.Parse("2006-01-02", value[:10]); err == nil {
+			return date
+		}
+	}
+	return fallback
+}
+
+// generateNumber returns min + step, stepping back when it exceeds max
+func generateNumber(args map[string]string, field Field) string {
+	number := func(value string, fallback int) int {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+		return fallback
+	}
+	min := number(field.Min, number(args["min"], 1))
+	max := number(field.Max, number(args["max"], 10))
+	step := number(field.Step, number(args["step"], 1))
+	value := min + step
+	if value > max {
+		value = max - step
+	}
+	if value < min {
+		value = min
+	}
+	return strconv.Itoa(value)
+}
+
+// generateIDCard returns an 18 digit resident ID: region, birth date,
+// sequence and the ISO 7064 MOD 11-2 check character
+func generateIDCard(_ map[string]string, _ Field) string {
+	birth := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, rand.Intn(365*30))
+	body := "110101" + birth.Format("20060102") + fmt.Sprintf("%03d", rand.Intn(1000))
+	weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, digit := range body {
+		sum += int(digit-'0') * weights[i]
+	}
+	return body + string("10X98765432"[sum%11])
+}
+
+// generateCreditCard returns a 16 digit Visa test number with a valid Luhn check digit
+func generateCreditCard(_ map[string]string, _ Field) string {
+	var builder strings.Builder
+	builder.WriteString("4")
+	for i := 0; i < 14; i++ {
+		builder.WriteByte(byte('0' + rand.Intn(10)))
+	}
+	body := builder.String()
+	return body + strconv.Itoa(luhnCheckDigit(body))
+}
+
+func luhnCheckDigit(body string) int {
+	sum := 0
+	double := true
+	for i := len(body) - 1; i >= 0; i-- {
+		digit := int(body[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
+
+func generateUUID(_ map[string]string, _ Field) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,283 @@
+// Package formfill chooses values for form fields. Rules loaded from YAML
+// match a field on its input type, autocomplete token, or a regex over its
+// name, id, class, label text and placeholder, and provide either a fixed
+// value or a generator. The same rules are used by crawlergo and katana.
+package formfill
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed rules.yaml
+var defaultRulesYAML []byte
+
+// Field describes a form field to fill
+type Field struct {
+	Type         string // input type, "textarea" for text areas
+	Name         string
+	ID           string
+	Class        string
+	Label        string // text of the associated label or aria-label
+	Placeholder  string
+	Autocomplete string
+	Min          string
+	Max          string
+	Step         string
+}
+
+// Rule provides the value of the fields it matches
+type Rule struct {
+	Name         string            `yaml:"name"`
+	Priority     int               `yaml:"priority"`
+	Types        []string          `yaml:"types"`
+	Autocomplete []string          `yaml:"autocomplete"`
+	Match        string            `yaml:"match"`
+	Value        string            `yaml:"value"`
+	Generator    string            `yaml:"generator"`
+	Args         map[string]string `yaml:"args"`
+	FreeText     bool              `yaml:"free_text"`
+
+	regex *regexp.Regexp
+}
+
+// Rules is an ordered set of rules with the value used when none matches
+type Rules struct {
+	Default         string  `yaml:"default"`
+	ReplaceDefaults bool    `yaml:"replace_defaults"`
+	Rules           []*Rule `yaml:"rules"`
+
+	lock      sync.Mutex
+	generated map[string]string
+	legacy    bool
+}
+
+var (
+	defaultOnce  sync.Once
+	defaultRules *Rules
+)
+
+// DefaultYAML returns the built-in rules, usable as a template for custom rules
+func DefaultYAML() []byte {
+	return defaultRulesYAML
+}
+
+// Default returns the built-in rules
+func Default() *Rules {
+	defaultOnce.Do(func() {
+		rules, err := parse(defaultRulesYAML)
+		if err != nil {
+			panic(fmt.Sprintf("formfill: invalid default rules: %s", err))
+		}
+		defaultRules = rules
+	})
+	return defaultRules
+}
+
+// Parse parses custom rules. Unless replace_defaults is set the built-in
+// rules are appended after them and the built-in default value is kept when
+// none is given.
+func Parse(data []byte) (*Rules, error) {
+	rules, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if rules.ReplaceDefaults {
+		return rules, nil
+	}
+	defaults := Default()
+	rules.Rules = append(rules.Rules, defaults.Rules...)
+	if rules.Default == "" {
+		rules.Default = defaults.Default
+	}
+	return rules, nil
+}
+
+// Load reads custom rules from a YAML file
+func Load(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// legacyRules maps the keys of the katana form config used before the rules
+// to the type rules that replace them
+var legacyRules = map[string]*Rule{
+	"email":    {Name: "mail", Priority: 100, Types: []string{"email"}},
+	"color":    {Name: "color", Priority: 100, Types: []string{"color"}},
+	"password": {Name: "password", Priority: 100, Types: []string{"password"}},
+	"phone":    {Name: "phone", Priority: 100, Types: []string{"tel"}},
+}
+
+// parseLegacy migrates a config in the old format with only email, color,
+// password, phone and placeholder keys, false if data is not in that format
+func parseLegacy(data []byte) (*Rules, bool) {
+	var values map[string]string
+	if err := yaml.Unmarshal(data, &values); err != nil || len(values) == 0 {
+		return nil, false
+	}
+	for key := range values {
+		if _, ok := legacyRules[key]; !ok && key != "placeholder" {
+			return nil, false
+		}
+	}
+	rules := &Rules{Default: values["placeholder"], legacy: true}
+	for _, key := range []string{"email", "color", "password", "phone"} {
+		if value, ok := values[key]; ok {
+			rule := *legacyRules[key]
+			rule.Value = value
+			rules.Rules = append(rules.Rules, &rule)
+		}
+	}
+	return rules, true
+}
+
+// Legacy reports whether the rules were migrated from the old format
+func (r *Rules) Legacy() bool {
+	return r.legacy
+}
+
+func parse(data []byte) (*Rules, error) {
+	if rules, ok := parseLegacy(data); ok {
+		return rules, nil
+	}
+	rules := &Rules{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(rules); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for i, rule := range rules.Rules {
+		if rule == nil || rule.Name == "" {
+			return nil, fmt.Errorf("rule %d has no name", i)
+		}
+		if rule.Generator != "" {
+			if _, ok := generators[rule.Generator]; !ok {
+				return nil, fmt.Errorf("rule %s: unknown generator %s", rule.Name, rule.Generator)
+			}
+		}
+		if rule.Match != "" {
+			regex, err := regexp.Compile("(?i)" + rule.Match)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+			}
+			rule.regex = regex
+		}
+	}
+	return rules, nil
+}
+
+// Match returns the rule with the highest priority that applies to the
+// field, nil if none does
+func (r *Rules) Match(field Field) *Rule {
+	var matched *Rule
+	for _, rule := range r.Rules {
+		if (matched == nil || rule.Priority > matched.Priority) && rule.matches(field) {
+			matched = rule
+		}
+	}
+	return matched
+}
+
+// Value returns the value for a field
+func (r *Rules) Value(field Field) string {
+	rule := r.Match(field)
+	if rule == nil {
+		return r.Default
+	}
+	return r.RuleValue(rule, field)
+}
+
+// RuleValue returns the value a rule provides for a field. Generated values
+// are cached so the same field always receives the same value.
+func (r *Rules) RuleValue(rule *Rule, field Field) string {
+	if rule.Generator == "" {
+		return rule.Value
+	}
+	key := strings.Join([]string{rule.Name, rule.Generator, field.Min, field.Max, field.Step}, "\x00")
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if value, ok := r.generated[key]; ok {
+		return value
+	}
+	if r.generated == nil {
+		r.generated = map[string]string{}
+	}
+	value := generators[rule.Generator](rule.Args, field)
+	r.generated[key] = value
+	return value
+}
+
+// Names returns the distinct rule names
+func (r *Rules) Names() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, rule := range r.Rules {
+		if !seen[rule.Name] {
+			seen[rule.Name] = true
+			names = append(names, rule.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Values returns the values the rules fill into fields without range
+// attributes, including the default value
+func (r *Rules) Values() []string {
+	seen := map[string]bool{}
+	values := []string{r.Default}
+	seen[r.Default] = true
+	for _, rule := range r.Rules {
+		value := r.RuleValue(rule, Field{})
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func (rule *Rule) matches(field Field) bool {
+	if len(rule.Types) > 0 && !containsFold(rule.Types, field.Type) {
+		return false
+	}
+	if len(rule.Autocomplete) == 0 && rule.regex == nil {
+		return len(rule.Types) > 0
+	}
+	// autocomplete may hold several tokens such as "shipping postal-code"
+	for _, token := range strings.Fields(field.Autocomplete) {
+		if containsFold(rule.Autocomplete, token) {
+			return true
+		}
+	}
+	if rule.regex != nil {
+		for _, text := range []string{field.Name, field.ID, field.Class, field.Label, field.Placeholder} {
+			if text != "" && rule.regex.MatchString(strings.TrimSpace(text)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package formfill
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRules(t *testing.T) {
+	rules := Default()
+	for _, test := range []struct {
+		field Field
+		rule  string
+	}{
+		{Field{Type: "password", Name: "username"}, "password"},
+		{Field{Type: "email", Name: "login"}, "mail"},
+		{Field{Type: "text", Name: "login"}, "username"},
+		{Field{Type: "text", Name: "field1", Autocomplete: "shipping postal-code"}, "zip"},
+		{Field{Type: "text", Name: "field2", Label: "Verification code"}, "code"},
+		{Field{Type: "text", Name: "user_captcha"}, "code"},
+		{Field{Type: "text", Placeholder: "Enter your email address"}, "mail"},
+		{Field{Type: "text", Name: "phone_number"}, "phone"},
+		{Field{Type: "text", ID: "birthdate"}, "date"},
+		{Field{Type: "text", Name: "q"}, "search"},
+		{Field{Type: "search", Name: "field3"}, "search"},
+		{Field{Type: "text", Name: "name"}, "name"},
+		{Field{Type: "text", Name: "user_age"}, "number"},
+		{Field{Type: "number", Name: "age"}, "number"},
+		{Field{Type: "text", Class: "form-control id-card"}, "id_card"},
+		{Field{Type: "text", Name: "wechat_id"}, "qq"},
+	} {
+		rule := rules.Match(test.field)
+		require.NotNil(t, rule, test.field)
+		assert.Equal(t, test.rule, rule.Name, test.field)
+	}
+	assert.Nil(t, rules.Match(Field{Type: "textarea", Name: "message"}))
+	assert.Equal(t, "admin", rules.Value(Field{Type: "textarea", Name: "message"}))
+	assert.Equal(t, "Crawl3r@2024", rules.Value(Field{Type: "password"}))
+}
+
+func TestGeneratedValues(t *testing.T) {
+	rules := Default()
+
+	email := rules.Value(Field{Type: "email"})
+	assert.True(t, strings.HasSuffix(email, "@example.com"), email)
+	assert.Equal(t, email, rules.Value(Field{Type: "text", Name: "email"}), "generated values are stable")
+	assert.Contains(t, rules.Values(), email)
+
+	date := rules.Value(Field{Type: "date", Min: "2010-05-01", Max: "2010-05-03"})
+	assert.Contains(t, []string{"2010-05-01", "2010-05-02", "2010-05-03"}, date)
+	assert.Len(t, rules.Value(Field{Type: "datetime-local"}), len("2006-01-02T15:04"))
+
+	assert.Equal(t, "51", rules.Value(Field{Type: "number", Min: "50", Max: "80"}))
+	assert.Equal(t, "2", rules.Value(Field{Type: "range"}))
+
+	idCard := generateIDCard(nil, Field{})
+	require.Len(t, idCard, 18)
+	weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, weight := range weights {
+		sum += int(idCard[i]-'0') * weight
+	}
+	assert.Equal(t, string("10X98765432"[sum%11]), idCard[17:])
+
+	card := generateCreditCard(nil, Field{})
+	require.Len(t, card, 16)
+	check, _ := strconv.Atoi(card[15:])
+	assert.Equal(t, luhnCheckDigit(card[:15]), check)
+	assert.Equal(t, 3, luhnCheckDigit("7992739871"), "known Luhn example")
+
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, generateUUID(nil, Field{}))
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+rules:
+  - name: coupon
+    priority: 200
+    match: coupon|promo
+    value: FREE2024
+  - name: mail
+    priority: 150
+    types: [email]
+    value: scanner@corp.example
+`), 0644))
+	rules, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "FREE2024", rules.Value(Field{Name: "promo_code"}))
+	assert.Equal(t, "scanner@corp.example", rules.Value(Field{Type: "email"}))
+	assert.Equal(t, "Crawl3r@2024", rules.Value(Field{Type: "password"}), "defaults are kept")
+	assert.Equal(t, "admin", rules.Default)
+
+	rules, err = Parse([]byte("replace_defaults: true\ndefault: x\nrules: []\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "x", rules.Value(Field{Type: "password"}))
+
+	_, err = Parse([]byte("rules: [{name: a, generator: nope}]"))
+	assert.Error(t, err)
+	_, err = Parse([]byte("rules: [{name: a, match: '('}]"))
+	assert.Error(t, err)
+	_, err = Parse([]byte("rules: [{name: a, regex: b}]"))
+	assert.Error(t, err, "unknown keys are rejected")
+	_, err = Parse([]byte("email: a@b.c\nrules: []\n"))
+	assert.Error(t, err)
+
+	rules, err = Parse(nil)
+	require.NoError(t, err)
+	assert.False(t, rules.Legacy())
+	assert.Equal(t, "admin", rules.Default)
+}
+
+func TestParseLegacy(t *testing.T) {
+	rules, err := Parse([]byte(`
+email: scanner@corp.example
+color: '#000000'
+password: katanaP@assw0rd1
+phone: "2120000000"
+placeholder: katana
+`))
+	require.NoError(t, err)
+	assert.True(t, rules.Legacy())
+	assert.Equal(t, "scanner@corp.example", rules.Value(Field{Type: "email"}))
+	assert.Equal(t, "#000000", rules.Value(Field{Type: "color"}))
+	assert.Equal(t, "katanaP@assw0rd1", rules.Value(Field{Type: "password"}))
+	assert.Equal(t, "2120000000", rules.Value(Field{Type: "tel"}))
+	assert.Equal(t, "katana", rules.Value(Field{Type: "textarea"}))
+	assert.Equal(t, "10001", rules.Value(Field{Type: "text", Name: "zip"}), "built-in rules are kept")
+
+	rules, err = Parse([]byte("password: secret\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "secret", rules.Value(Field{Type: "password"}))
+	assert.Equal(t, "admin", rules.Default)
+}
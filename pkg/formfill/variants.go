@@ -0,0 +1,98 @@
+package formfill
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Choice is a form control with a fixed set of values, a select or a group
+// of radio buttons sharing a name
+type Choice struct {
+	Name    string   `json:"name"`
+	Values  []string `json:"values"`
+	Default int      `json:"default"` // index of the preselected value
+}
+
+// Variant assigns a value to every choice of a form
+type Variant struct {
+	Values map[string]string
+	Label  string // the assignments in form order, such as "sort=price&type=2"
+}
+
+// Variants returns up to limit assignments of the choices. The first one
+// keeps the default of every choice and each following one changes a single
+// choice to another of its values, taking the choices in turn, so the values
+// of all choices are covered before the limit is reached without enumerating
+// every combination. It returns nil when no choice has an alternative value.
+func Variants(choices []Choice, limit int) []Variant {
+	if limit <= 0 {
+		return nil
+	}
+	var normalized []Choice
+	rounds := 0
+	for _, choice := range choices {
+		if choice.Name == "" {
+			continue
+		}
+		values := uniqueValues(choice.Values)
+		if len(values) == 0 {
+			continue
+		}
+		defaultIndex := 0
+		if choice.Default >= 0 && choice.Default < len(choice.Values) {
+			for i, value := range values {
+				if value == choice.Values[choice.Default] {
+					defaultIndex = i
+				}
+			}
+		}
+		normalized = append(normalized, Choice{Name: choice.Name, Values: values, Default: defaultIndex})
+		if len(values)-1 > rounds {
+			rounds = len(values) - 1
+		}
+	}
+	if rounds == 0 {
+		return nil
+	}
+
+	variants := []Variant{newVariant(normalized, -1, 0)}
+	for round := 1; round <= rounds; round++ {
+		for i, choice := range normalized {
+			if len(variants) >= limit {
+				return variants
+			}
+			if round < len(choice.Values) {
+				variants = append(variants, newVariant(normalized, i, (choice.Default+round)%len(choice.Values)))
+			}
+		}
+	}
+	return variants
+}
+
+// newVariant returns the defaults with the choice at index changed to value
+func newVariant(choices []Choice, index, value int) Variant {
+	variant := Variant{Values: make(map[string]string, len(choices))}
+	var label []string
+	for i, choice := range choices {
+		selected := choice.Values[choice.Default]
+		if i == index {
+			selected = choice.Values[value]
+		}
+		variant.Values[choice.Name] = selected
+		label = append(label, url.QueryEscape(choice.Name)+"="+url.QueryEscape(selected))
+	}
+	variant.Label = strings.Join(label, "&")
+	return variant
+}
+
+func uniqueValues(values []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
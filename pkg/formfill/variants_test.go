@@ -0,0 +1,37 @@
+package formfill
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariants(t *testing.T) {
+	choices := []Choice{
+		{Name: "sort", Values: []string{"name", "price", "date"}, Default: 1},
+		{Name: "order", Values: []string{"asc", "desc", "asc"}},
+		{Name: "fixed", Values: []string{"only"}},
+	}
+
+	variants := Variants(choices, 10)
+	var labels []string
+	for _, variant := range variants {
+		labels = append(labels, variant.Label)
+	}
+	assert.Equal(t, []string{
+		"sort=price&order=asc&fixed=only",
+		"sort=date&order=asc&fixed=only",
+		"sort=price&order=desc&fixed=only",
+		"sort=name&order=asc&fixed=only",
+	}, labels)
+	assert.Equal(t, map[string]string{"sort": "date", "order": "asc", "fixed": "only"}, variants[1].Values)
+
+	require.Len(t, Variants(choices, 2), 2, "the limit caps the variants")
+	assert.Nil(t, Variants(choices, 0))
+	assert.Nil(t, Variants([]Choice{{Name: "fixed", Values: []string{"only"}}}, 10), "no alternative values")
+
+	escaped := Variants([]Choice{{Name: "q&a", Values: []string{"a b", "c"}}}, 10)
+	require.Len(t, escaped, 2)
+	assert.Equal(t, "q%26a=a+b", escaped[0].Label)
+}
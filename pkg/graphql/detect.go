@@ -0,0 +1,186 @@
+// Package graphql detects GraphQL endpoints from request paths, request
+// bodies and client libraries used in JavaScript, parses introspection
+// results and generates one representative request per schema field.
+package graphql
+
+import (
+	"encoding/json"
+	"mime"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the generated requests
+const ContentType = "application/json"
+
+// ProbeBody is a harmless request body for endpoints found without a
+// request, it is valid on every GraphQL server
+const ProbeBody = `{"query":"query Typename { __typename }"}`
+
+var endpointPathRegex = regexp.MustCompile(`(?i)(?:^|/)(?:graphql|graphiql|gql)(?:\.php)?(?:/(?:v\d+|console))?/?$`)
+
+var operationStartRegex = regexp.MustCompile(`^\s*(?:#[^\n]*\n\s*)*(?:\{|(?:query|mutation|subscription|fragment)\b)`)
+
+// jsClientRegexes extract the endpoint configured for common GraphQL clients
+var jsClientRegexes = []*regexp.Regexp{
+	// Apollo: new ApolloClient({uri: "..."}), createHttpLink({uri: "..."}), new HttpLink({uri: "..."})
+	regexp.MustCompile(`(?:ApolloClient|createHttpLink|HttpLink|BatchHttpLink|createUploadLink)\s*\(\s*\{[^}]{0,300}?\buri\s*:\s*["'\x60]([^"'\x60\s]+)["'\x60]`),
+	// graphql-request: new GraphQLClient("...")
+	regexp.MustCompile(`GraphQLClient\s*\(\s*["'\x60]([^"'\x60\s]+)["'\x60]`),
+	// urql: createClient({url: "..."})
+	regexp.MustCompile(`createClient\s*\(\s*\{[^}]{0,300}?\burl\s*:\s*["'\x60]([^"'\x60\s]+)["'\x60]`),
+}
+
+// jsLiteralRegex matches string literals that look like a GraphQL endpoint,
+// such as the argument of fetch("/graphql") in a Relay environment
+var jsLiteralRegex = regexp.MustCompile(`["'\x60]((?:https?:)?[^"'\x60\s<>]*?/(?:graphql|graphiql|gql)(?:\.php)?(?:/(?:v\d+|console))?/?)["'\x60]`)
+
+// IsEndpointPath reports whether a URL path looks like a GraphQL endpoint
+func IsEndpointPath(path string) bool {
+	return endpointPathRegex.MatchString(path)
+}
+
+// LooksLikeQuery reports whether a string is a GraphQL document
+func LooksLikeQuery(query string) bool {
+	return operationStartRegex.MatchString(query)
+}
+
+// IsRequest reports whether a request carries a GraphQL operation: a JSON
+// body (or batch) with a query or persisted query hash, an
+// application/graphql body or a GET request with a query parameter.
+func IsRequest(method, rawURL, contentType, body string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/graphql" {
+		return true
+	}
+	if strings.TrimSpace(body) != "" {
+		return len(parseBody(body)) > 0
+	}
+	if method != "" && !strings.EqualFold(method, "GET") {
+		return false
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return LooksLikeQuery(parsed.Query().Get("query"))
+}
+
+// Endpoint returns the URL of an endpoint without query and fragment
+func Endpoint(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	parsed.RawQuery = ""
+	parsed.ForceQuery = false
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String()
+}
+
+// DetectInJS returns the endpoints referenced by GraphQL clients or string
+// literals in a JavaScript source. The results may be relative URLs.
+func DetectInJS(source string) []string {
+	seen := map[string]bool{}
+	var endpoints []string
+	add := func(endpoint string) {
+		if endpoint == "" || seen[endpoint] {
+			return
+		}
+		seen[endpoint] = true
+		endpoints = append(endpoints, endpoint)
+	}
+	for _, clientRegex := range jsClientRegexes {
+		for _, match := range clientRegex.FindAllStringSubmatch(source, -1) {
+			add(match[1])
+		}
+	}
+	for _, match := range jsLiteralRegex.FindAllStringSubmatch(source, -1) {
+		add(match[1])
+	}
+	return endpoints
+}
+
+// request is a single operation of a request body
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    struct {
+		PersistedQuery struct {
+			Hash string `json:"sha256Hash"`
+		} `json:"persistedQuery"`
+	} `json:"extensions"`
+}
+
+// parseBody returns the GraphQL operations of a JSON body, batches included
+func parseBody(body string) []request {
+	body = strings.TrimSpace(body)
+	var requests []request
+	switch {
+	case strings.HasPrefix(body, "{"):
+		var single request
+		if err := json.Unmarshal([]byte(body), &single); err != nil {
+			return nil
+		}
+		requests = []request{single}
+	case strings.HasPrefix(body, "["):
+		if err := json.Unmarshal([]byte(body), &requests); err != nil {
+			return nil
+		}
+	case LooksLikeQuery(body):
+		// an application/graphql body is the document itself
+		return []request{{Query: body}}
+	default:
+		return nil
+	}
+	var operations []request
+	for _, item := range requests {
+		if LooksLikeQuery(item.Query) || item.Extensions.PersistedQuery.Hash != "" {
+			operations = append(operations, item)
+		}
+	}
+	return operations
+}
+
+// EndpointSet collects endpoints detected by concurrent crawl workers
+type EndpointSet struct {
+	mutex     sync.Mutex
+	endpoints map[string]struct{}
+}
+
+// NewEndpointSet creates an empty endpoint set
+func NewEndpointSet() *EndpointSet {
+	return &EndpointSet{endpoints: make(map[string]struct{})}
+}
+
+// Add records the endpoint of an absolute URL, reporting whether it is new
+func (set *EndpointSet) Add(rawURL string) bool {
+	endpoint := Endpoint(rawURL)
+	if endpoint == "" {
+		return false
+	}
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+	if _, ok := set.endpoints[endpoint]; ok {
+		return false
+	}
+	set.endpoints[endpoint] = struct{}{}
+	return true
+}
+
+// List returns the sorted endpoints
+func (set *EndpointSet) List() []string {
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+	endpoints := make([]string, 0, len(set.endpoints))
+	for endpoint := range set.endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
@@ -0,0 +1,154 @@
+package graphql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsEndpointPath(t *testing.T) {
+	for _, path := range []string{"/graphql", "/api/graphql", "/v1/graphql/", "/graphiql", "/gql", "/graphql.php", "/graphql/v2", "/graphql/console"} {
+		assert.True(t, IsEndpointPath(path), path)
+	}
+	for _, path := range []string{"/", "/graphql-docs", "/api/users", "/static/graphql.js", "/gqlx"} {
+		assert.False(t, IsEndpointPath(path), path)
+	}
+}
+
+func TestIsRequest(t *testing.T) {
+	assert.True(t, IsRequest("POST", "https://a.com/api", "application/json", `{"query":"query Q { me { id } }"}`))
+	assert.True(t, IsRequest("POST", "https://a.com/api", "application/json", `[{"query":"{ a }"},{"query":"{ b }"}]`))
+	assert.True(t, IsRequest("POST", "https://a.com/api", "application/json",
+		`{"operationName":"Me","extensions":{"persistedQuery":{"version":1,"sha256Hash":"abc"}}}`))
+	assert.True(t, IsRequest("POST", "https://a.com/api", "application/graphql", `{ me { id } }`))
+	assert.True(t, IsRequest("GET", "https://a.com/api?query=%7B+me+%7B+id+%7D+%7D", "", ""))
+	assert.False(t, IsRequest("POST", "https://a.com/api", "application/json", `{"query":"select * from users"}`))
+	assert.False(t, IsRequest("POST", "https://a.com/api", "application/json", `{"name":"test"}`))
+	assert.False(t, IsRequest("GET", "https://a.com/search?query=shoes", "", ""))
+}
+
+func TestDetectInJS(t *testing.T) {
+	source := `
+const client = new ApolloClient({ cache: new InMemoryCache(), uri: "https://api.example.com/v1/graphql" });
+const link = createHttpLink({uri: '/api/graphql', credentials: 'include'});
+const gqlClient = new GraphQLClient("/internal/gql", { headers: {} });
+const urqlClient = createClient({ url: '/urql/endpoint' });
+function fetchQuery(operation, variables) { return fetch("/relay/graphql", { method: "POST" }); }
+const doc = "/static/graphql.js";
+`
+	endpoints := DetectInJS(source)
+	assert.ElementsMatch(t, []string{
+		"https://api.example.com/v1/graphql",
+		"/api/graphql",
+		"/internal/gql",
+		"/urql/endpoint",
+		"/relay/graphql",
+	}, endpoints)
+}
+
+func TestSignature(t *testing.T) {
+	first := Signature(`{"query":"query GetUser($id: ID!) { user(id: $id) { id name } }","variables":{"id":"1"}}`)
+	second := Signature(`{"query":"query GetUser($id: ID!) { user(id: $id) { id email } }","variables":{"id":"2"}}`)
+	assert.Equal(t, "query GetUser user", first)
+	assert.Equal(t, first, second, "variable values and nested selections do not change the signature")
+
+	assert.NotEqual(t, first, Signature(`{"query":"query GetPosts { posts(first: 10) { id } }"}`))
+	assert.Equal(t, "mutation Login login", Signature(`{"query":"mutation Login($u: String!) { login(username: $u) { token } }"}`))
+	assert.Equal(t, "query  a,b", Signature(`{"query":"{ b(x: \"{ c }\") a }"}`))
+	assert.Equal(t, "query  me", Signature(`{"query":"{ viewer: me { id } me { name } }"}`),
+		"aliases resolve to the field name")
+	assert.Equal(t, "query Q ...Fields,user", Signature(
+		`{"query":"fragment Fields on User { id } query Q($f: Filter = {a: 1}) { ...Fields user @include(if: true) { id } }"}`))
+	assert.Equal(t, "query Second b", Signature(`{"query":"query First { a } query Second { b }","operationName":"Second"}`))
+	assert.Equal(t, "persisted Me abc", Signature(`{"operationName":"Me","extensions":{"persistedQuery":{"sha256Hash":"abc"}}}`))
+	assert.Equal(t, "query  a;mutation M b", Signature(`[{"query":"{ a }"},{"query":"mutation M { b }"}]`))
+	assert.Equal(t, "", Signature(`{"name":"test"}`))
+}
+
+const introspectionResponse = `{"data":{"__schema":{
+  "queryType":{"name":"Query"},
+  "mutationType":{"name":"Mutation"},
+  "types":[
+    {"kind":"OBJECT","name":"Query","fields":[
+      {"name":"user","args":[{"name":"id","defaultValue":null,"type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"SCALAR","name":"ID"}}}],
+       "type":{"kind":"OBJECT","name":"User"}},
+      {"name":"users","args":[{"name":"first","defaultValue":"10","type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"SCALAR","name":"Int"}}},
+                              {"name":"role","defaultValue":null,"type":{"kind":"ENUM","name":"Role"}}],
+       "type":{"kind":"LIST","name":null,"ofType":{"kind":"OBJECT","name":"User"}}},
+      {"name":"version","args":[],"type":{"kind":"SCALAR","name":"String"}},
+      {"name":"__typename","args":[],"type":{"kind":"SCALAR","name":"String"}}
+    ]},
+    {"kind":"OBJECT","name":"Mutation","fields":[
+      {"name":"createUser","args":[{"name":"input","defaultValue":null,"type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"INPUT_OBJECT","name":"UserInput"}}}],
+       "type":{"kind":"OBJECT","name":"User"}}
+    ]},
+    {"kind":"OBJECT","name":"User","fields":[
+      {"name":"id","args":[],"type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"SCALAR","name":"ID"}}},
+      {"name":"role","args":[],"type":{"kind":"ENUM","name":"Role"}},
+      {"name":"friends","args":[{"name":"after","defaultValue":null,"type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"SCALAR","name":"String"}}}],
+       "type":{"kind":"LIST","name":null,"ofType":{"kind":"OBJECT","name":"User"}}},
+      {"name":"profile","args":[],"type":{"kind":"OBJECT","name":"Profile"}}
+    ]},
+    {"kind":"OBJECT","name":"Profile","fields":[
+      {"name":"bio","args":[],"type":{"kind":"SCALAR","name":"String"}}
+    ]},
+    {"kind":"INPUT_OBJECT","name":"UserInput","inputFields":[
+      {"name":"name","defaultValue":null,"type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"SCALAR","name":"String"}}},
+      {"name":"role","defaultValue":null,"type":{"kind":"NON_NULL","name":null,"ofType":{"kind":"ENUM","name":"Role"}}},
+      {"name":"age","defaultValue":null,"type":{"kind":"SCALAR","name":"Int"}}
+    ]},
+    {"kind":"ENUM","name":"Role","enumValues":[{"name":"ADMIN"},{"name":"USER"}]},
+    {"kind":"SCALAR","name":"ID"},{"kind":"SCALAR","name":"String"},{"kind":"SCALAR","name":"Int"}
+  ]}}}`
+
+func TestParseSchema(t *testing.T) {
+	schema, err := ParseSchema([]byte(introspectionResponse))
+	require.NoError(t, err)
+	assert.Equal(t, "Query", schema.QueryType)
+	assert.Equal(t, "Mutation", schema.MutationType)
+	assert.Equal(t, "[User]", schema.Types["Query"].Fields[1].Type.String())
+	assert.Equal(t, "User", schema.Types["Query"].Fields[1].Type.Named().Name)
+
+	_, err = ParseSchema([]byte(`{"errors":[{"message":"introspection is disabled"}]}`))
+	assert.ErrorContains(t, err, "introspection is disabled")
+	_, err = ParseSchema([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestOperations(t *testing.T) {
+	schema, err := ParseSchema([]byte(introspectionResponse))
+	require.NoError(t, err)
+
+	operations := Operations(schema, false)
+	require.Len(t, operations, 3, "mutations need an explicit opt-in")
+	assert.Equal(t, "user", operations[0].Field)
+	assert.Equal(t, "QueryUser", operations[0].Name)
+	assert.Equal(t, "query QueryUser($id: ID!) { user(id: $id) { id role profile { bio } } }", operations[0].Query)
+	assert.Equal(t, map[string]interface{}{"id": "1"}, operations[0].Variables)
+	assert.Equal(t, "query QueryUsers { users { id role profile { bio } } }", operations[1].Query,
+		"optional arguments and arguments with defaults are omitted")
+	assert.Equal(t, "query QueryVersion { version }", operations[2].Query)
+
+	operations = Operations(schema, true)
+	require.Len(t, operations, 4)
+	mutation := operations[3]
+	assert.Equal(t, OperationMutation, mutation.Type)
+	assert.Equal(t, "mutation MutationCreateUser($input: UserInput!) { createUser(input: $input) { id role profile { bio } } }", mutation.Query)
+	assert.Equal(t, map[string]interface{}{"name": "test", "role": "ADMIN"}, mutation.Variables["input"])
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(operations[0].Body()), &body))
+	assert.Equal(t, "QueryUser", body["operationName"])
+	assert.Equal(t, "query QueryUser user", Signature(operations[0].Body()), "generated bodies are recognised")
+}
+
+func TestEndpointSet(t *testing.T) {
+	set := NewEndpointSet()
+	assert.True(t, set.Add("https://a.com/graphql?query=%7Ba%7D"))
+	assert.False(t, set.Add("https://a.com/graphql#x"))
+	assert.True(t, set.Add("https://a.com/api/gql"))
+	assert.False(t, set.Add("/relative/graphql"))
+	assert.Equal(t, []string{"https://a.com/api/gql", "https://a.com/graphql"}, set.List())
+}
@@ -0,0 +1,208 @@
+package graphql
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Operation types
+const (
+	OperationQuery    = "query"
+	OperationMutation = "mutation"
+)
+
+const (
+	// MaxOperations is the number of operations generated per schema
+	MaxOperations = 200
+	// MaxSelectionFields is the number of fields selected per object
+	MaxSelectionFields = 10
+	// MaxSelectionDepth is the depth of nested objects selected
+	MaxSelectionDepth = 2
+	// maxInputDepth limits the nesting of generated input objects
+	maxInputDepth = 3
+)
+
+// Operation is a representative request for a single root field
+type Operation struct {
+	Type      string                 `json:"type"`
+	Field     string                 `json:"field"`
+	Name      string                 `json:"operationName"`
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// Body returns the JSON request body of the operation
+func (op *Operation) Body() string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"query":         op.Query,
+		"operationName": op.Name,
+		"variables":     op.Variables,
+	})
+	return string(data)
+}
+
+// Operations generates one operation per query field, and per mutation
+// field when includeMutations is set. Required arguments are passed as
+// variables filled with sample values, the selection set contains the
+// scalar fields of the returned object.
+func Operations(schema *Schema, includeMutations bool) []*Operation {
+	var operations []*Operation
+	roots := []struct{ opType, typeName string }{{OperationQuery, schema.QueryType}}
+	if includeMutations {
+		roots = append(roots, struct{ opType, typeName string }{OperationMutation, schema.MutationType})
+	}
+	for _, root := range roots {
+		rootType := schema.Types[root.typeName]
+		if root.typeName == "" || rootType == nil {
+			continue
+		}
+		fields := append([]*Field(nil), rootType.Fields...)
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+		for _, field := range fields {
+			if len(operations) >= MaxOperations {
+				return operations
+			}
+			if field == nil || field.Name == "" || strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			operations = append(operations, schema.operation(root.opType, field))
+		}
+	}
+	return operations
+}
+
+func (schema *Schema) operation(opType string, field *Field) *Operation {
+	op := &Operation{
+		Type:      opType,
+		Field:     field.Name,
+		Name:      operationName(opType, field.Name),
+		Variables: map[string]interface{}{},
+	}
+	var definitions, arguments []string
+	for _, arg := range field.Args {
+		if arg == nil || arg.Type == nil || arg.Type.Kind != KindNonNull || arg.DefaultValue != nil {
+			continue
+		}
+		definitions = append(definitions, "$"+arg.Name+": "+arg.Type.String())
+		arguments = append(arguments, arg.Name+": $"+arg.Name)
+		op.Variables[arg.Name] = schema.sampleValue(arg.Type, 0)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(opType + " " + op.Name)
+	if len(definitions) > 0 {
+		builder.WriteString("(" + strings.Join(definitions, ", ") + ")")
+	}
+	builder.WriteString(" { " + field.Name)
+	if len(arguments) > 0 {
+		builder.WriteString("(" + strings.Join(arguments, ", ") + ")")
+	}
+	if selection := schema.selection(field.Type.Named(), 1); selection != "" {
+		builder.WriteString(" " + selection)
+	}
+	builder.WriteString(" }")
+	op.Query = builder.String()
+	return op
+}
+
+// selection returns the selection set of a returned type, empty for leaf types
+func (schema *Schema) selection(ref *TypeRef, depth int) string {
+	if ref == nil {
+		return ""
+	}
+	t := schema.Types[ref.Name]
+	if t == nil || t.Kind == KindScalar || t.Kind == KindEnum {
+		return ""
+	}
+	var selected []string
+	if t.Kind == KindObject || t.Kind == KindInterface {
+		for _, field := range t.Fields {
+			if len(selected) >= MaxSelectionFields {
+				break
+			}
+			if field == nil || field.Type == nil || hasRequiredArgs(field) {
+				continue
+			}
+			named := field.Type.Named()
+			if named == nil {
+				continue
+			}
+			fieldType := schema.Types[named.Name]
+			if fieldType == nil || fieldType.Kind == KindScalar || fieldType.Kind == KindEnum {
+				selected = append(selected, field.Name)
+			} else if depth < MaxSelectionDepth {
+				if nested := schema.selection(named, depth+1); nested != "" {
+					selected = append(selected, field.Name+" "+nested)
+				}
+			}
+		}
+	}
+	if len(selected) == 0 {
+		selected = []string{"__typename"}
+	}
+	return "{ " + strings.Join(selected, " ") + " }"
+}
+
+// sampleValue returns a value of the input type for a required variable
+func (schema *Schema) sampleValue(ref *TypeRef, depth int) interface{} {
+	if ref == nil {
+		return nil
+	}
+	switch ref.Kind {
+	case KindNonNull:
+		return schema.sampleValue(ref.OfType, depth)
+	case KindList:
+		if ref.OfType == nil {
+			return []interface{}{}
+		}
+		return []interface{}{schema.sampleValue(ref.OfType, depth)}
+	}
+	switch ref.Name {
+	case "Int":
+		return 1
+	case "Float":
+		return 1.5
+	case "Boolean":
+		return true
+	case "ID":
+		return "1"
+	}
+	t := schema.Types[ref.Name]
+	if t == nil {
+		return "test"
+	}
+	switch t.Kind {
+	case KindEnum:
+		if len(t.EnumValues) > 0 {
+			return t.EnumValues[0].Name
+		}
+	case KindInputObject:
+		object := map[string]interface{}{}
+		if depth >= maxInputDepth {
+			return object
+		}
+		for _, inputField := range t.InputFields {
+			if inputField == nil || inputField.Type == nil || inputField.Type.Kind != KindNonNull || inputField.DefaultValue != nil {
+				continue
+			}
+			object[inputField.Name] = schema.sampleValue(inputField.Type, depth+1)
+		}
+		return object
+	}
+	return "test"
+}
+
+func hasRequiredArgs(field *Field) bool {
+	for _, arg := range field.Args {
+		if arg != nil && arg.Type != nil && arg.Type.Kind == KindNonNull && arg.DefaultValue == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// operationName builds a valid operation name such as QueryUser
+func operationName(opType, field string) string {
+	return strings.ToUpper(opType[:1]) + opType[1:] + strings.ToUpper(field[:1]) + field[1:]
+}
@@ -0,0 +1,161 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// IntrospectionQuery requests the parts of the schema needed to build
+// requests: root types, fields, arguments, input objects and enums
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    types {
+      kind
+      name
+      fields(includeDeprecated: true) {
+        name
+        args { name defaultValue type { ...TypeRef } }
+        type { ...TypeRef }
+      }
+      inputFields { name defaultValue type { ...TypeRef } }
+      enumValues(includeDeprecated: true) { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } }
+}`
+
+// Type kinds of the introspection schema
+const (
+	KindScalar      = "SCALAR"
+	KindObject      = "OBJECT"
+	KindInterface   = "INTERFACE"
+	KindUnion       = "UNION"
+	KindEnum        = "ENUM"
+	KindInputObject = "INPUT_OBJECT"
+	KindList        = "LIST"
+	KindNonNull     = "NON_NULL"
+)
+
+// TypeRef is a possibly wrapped reference to a named type
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *TypeRef `json:"ofType"`
+}
+
+// InputValue is an argument or an input object field
+type InputValue struct {
+	Name         string   `json:"name"`
+	DefaultValue *string  `json:"defaultValue"`
+	Type         *TypeRef `json:"type"`
+}
+
+// Field is a field of an object or interface type
+type Field struct {
+	Name string        `json:"name"`
+	Args []*InputValue `json:"args"`
+	Type *TypeRef      `json:"type"`
+}
+
+// EnumValue is a value of an enum type
+type EnumValue struct {
+	Name string `json:"name"`
+}
+
+// Type is a named type of the schema
+type Type struct {
+	Kind        string        `json:"kind"`
+	Name        string        `json:"name"`
+	Fields      []*Field      `json:"fields"`
+	InputFields []*InputValue `json:"inputFields"`
+	EnumValues  []*EnumValue  `json:"enumValues"`
+}
+
+// Schema is the result of an introspection query
+type Schema struct {
+	QueryType    string
+	MutationType string
+	Types        map[string]*Type
+}
+
+// ParseSchema parses an introspection response. Both the full response
+// ({"data": {"__schema": ...}}) and the bare data object are accepted.
+func ParseSchema(data []byte) (*Schema, error) {
+	type namedType struct {
+		Name string `json:"name"`
+	}
+	type schemaData struct {
+		Schema *struct {
+			QueryType    *namedType `json:"queryType"`
+			MutationType *namedType `json:"mutationType"`
+			Types        []*Type    `json:"types"`
+		} `json:"__schema"`
+	}
+	var response struct {
+		schemaData
+		Data   *schemaData `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	introspection := response.Schema
+	if response.Data != nil && response.Data.Schema != nil {
+		introspection = response.Data.Schema
+	}
+	if introspection == nil {
+		if len(response.Errors) > 0 {
+			return nil, fmt.Errorf("introspection failed: %s", response.Errors[0].Message)
+		}
+		return nil, errors.New("response has no __schema")
+	}
+
+	schema := &Schema{Types: make(map[string]*Type, len(introspection.Types))}
+	for _, t := range introspection.Types {
+		if t != nil && t.Name != "" {
+			schema.Types[t.Name] = t
+		}
+	}
+	if introspection.QueryType != nil {
+		schema.QueryType = introspection.QueryType.Name
+	}
+	if introspection.MutationType != nil {
+		schema.MutationType = introspection.MutationType.Name
+	}
+	if schema.Types[schema.QueryType] == nil && schema.Types[schema.MutationType] == nil {
+		return nil, errors.New("schema has no root types")
+	}
+	return schema, nil
+}
+
+// Named returns the named type wrapped by LIST and NON_NULL
+func (t *TypeRef) Named() *TypeRef {
+	for t != nil && (t.Kind == KindList || t.Kind == KindNonNull) {
+		t = t.OfType
+	}
+	return t
+}
+
+// String returns the type in GraphQL syntax, e.g. [ID!]!
+func (t *TypeRef) String() string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case KindNonNull:
+		return t.OfType.String() + "!"
+	case KindList:
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
@@ -0,0 +1,228 @@
+package graphql
+
+import (
+	"sort"
+	"strings"
+)
+
+// Signature identifies the operations of a GraphQL request body regardless
+// of argument and variable values: operation type, operation name and the
+// sorted top level fields. Persisted queries are identified by their hash.
+// It returns an empty string when the body is not a GraphQL request.
+func Signature(body string) string {
+	operations := parseBody(body)
+	if len(operations) == 0 {
+		return ""
+	}
+	signatures := make([]string, 0, len(operations))
+	for _, operation := range operations {
+		signatures = append(signatures, operation.signature())
+	}
+	return strings.Join(signatures, ";")
+}
+
+// QuerySignature is the signature of a query document sent without a JSON
+// body, such as the query parameter of a GET request
+func QuerySignature(query string) string {
+	if !LooksLikeQuery(query) {
+		return ""
+	}
+	r := request{Query: query}
+	return r.signature()
+}
+
+func (r *request) signature() string {
+	if !LooksLikeQuery(r.Query) {
+		return "persisted " + r.OperationName + " " + r.Extensions.PersistedQuery.Hash
+	}
+	opType, opName, fields := topLevelFields(r.Query, r.OperationName)
+	if opName == "" {
+		opName = r.OperationName
+	}
+	sort.Strings(fields)
+	return opType + " " + opName + " " + strings.Join(fields, ",")
+}
+
+// topLevelFields returns the type, name and top level fields of the
+// operation named operationName, or the first operation of the document
+func topLevelFields(document, operationName string) (string, string, []string) {
+	tokens := tokenize(document)
+	var firstType, firstName string
+	var firstFields []string
+	found := false
+	for i := 0; i < len(tokens); {
+		opType, opName := "query", ""
+		switch tokens[i] {
+		case "fragment":
+			i = skipBlock(tokens, i)
+			continue
+		case "query", "mutation", "subscription":
+			opType = tokens[i]
+			i++
+			if i < len(tokens) && isName(tokens[i]) {
+				opName = tokens[i]
+				i++
+			}
+			// variable definitions may contain object default values
+			for parens := 0; i < len(tokens) && (tokens[i] != "{" || parens > 0); i++ {
+				switch tokens[i] {
+				case "(":
+					parens++
+				case ")":
+					parens--
+				}
+			}
+		case "{":
+		default:
+			i++
+			continue
+		}
+		fields, next := selectionFields(tokens, i)
+		i = next
+		if operationName == "" || opName == operationName {
+			return opType, opName, fields
+		}
+		if !found {
+			firstType, firstName, firstFields, found = opType, opName, fields, true
+		}
+	}
+	return firstType, firstName, firstFields
+}
+
+// selectionFields returns the fields of the selection set starting at
+// tokens[start] and the index after it. Aliases are resolved to the field
+// name, fragment spreads are kept as "...Name".
+func selectionFields(tokens []string, start int) ([]string, int) {
+	var fields []string
+	seen := map[string]bool{}
+	add := func(field string) {
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	depth, parens := 0, 0
+	i := start
+	for ; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token {
+		case "{":
+			depth++
+			continue
+		case "}":
+			depth--
+			if depth == 0 {
+				return fields, i + 1
+			}
+			continue
+		case "(":
+			parens++
+			continue
+		case ")":
+			parens--
+			continue
+		}
+		if depth != 1 || parens != 0 {
+			continue
+		}
+		switch {
+		case token == "...":
+			if i+1 < len(tokens) && tokens[i+1] == "on" && i+2 < len(tokens) {
+				add("...on " + tokens[i+2])
+				i += 2
+			} else if i+1 < len(tokens) && isName(tokens[i+1]) {
+				add("..." + tokens[i+1])
+				i++
+			}
+		case token == "@":
+			// directive name
+			i++
+		case isName(token):
+			if i+2 < len(tokens) && tokens[i+1] == ":" && isName(tokens[i+2]) {
+				i += 2
+				token = tokens[i]
+			}
+			add(token)
+		}
+	}
+	return fields, i
+}
+
+// skipBlock returns the index after the first balanced {} block from start
+func skipBlock(tokens []string, start int) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(tokens)
+}
+
+// tokenize splits a GraphQL document into names and punctuators, strings,
+// numbers and comments are dropped
+func tokenize(document string) []string {
+	var tokens []string
+	for i := 0; i < len(document); {
+		c := document[i]
+		switch {
+		case c == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(document[i:], `"""`):
+			end := strings.Index(document[i+3:], `"""`)
+			if end < 0 {
+				return tokens
+			}
+			i += end + 6
+		case c == '"':
+			i++
+			for i < len(document) && document[i] != '"' {
+				if document[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+		case strings.HasPrefix(document[i:], "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case strings.IndexByte("{}():@$!=[]", c) >= 0:
+			tokens = append(tokens, string(c))
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(document) && (document[i] == '_' || isLetter(document[i]) || isDigit(document[i])) {
+				i++
+			}
+			tokens = append(tokens, document[start:i])
+		case isDigit(c) || c == '-':
+			for i < len(document) && (isDigit(document[i]) || strings.IndexByte("-+.eE", document[i]) >= 0) {
+				i++
+			}
+		default:
+			// whitespace, commas and unknown characters
+			i++
+		}
+	}
+	return tokens
+}
+
+func isName(token string) bool {
+	return token != "" && (token[0] == '_' || isLetter(token[0]))
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,61 @@
+package identity
+
+import (
+	"hash/fnv"
+	"net/url"
+	"strings"
+)
+
+// Pool rotates a set of profiles across targets. The profile for a target
+// is derived from its hostname, so every engine and every request for the
+// same host presents the same identity without sharing state.
+type Pool struct {
+	profiles []*Profile
+}
+
+// NewPool creates a pool from profile names, an empty list uses the default profile
+func NewPool(names []string) (*Pool, error) {
+	pool := &Pool{}
+	seen := make(map[string]struct{})
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		profile, err := Get(name)
+		if err != nil {
+			return nil, err
+		}
+		pool.profiles = append(pool.profiles, profile)
+	}
+	if len(pool.profiles) == 0 {
+		pool.profiles = append(pool.profiles, Default())
+	}
+	return pool, nil
+}
+
+// Profiles returns the profiles of the pool
+func (p *Pool) Profiles() []*Profile {
+	return p.profiles
+}
+
+// ForTarget returns the profile for a target URL or hostname
+func (p *Pool) ForTarget(target string) *Profile {
+	if p == nil || len(p.profiles) == 0 {
+		return Default()
+	}
+	if len(p.profiles) == 1 {
+		return p.profiles[0]
+	}
+	host := target
+	if parsed, err := url.Parse(target); err == nil && parsed.Hostname() != "" {
+		host = parsed.Hostname()
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(strings.ToLower(host)))
+	return p.profiles[hash.Sum32()%uint32(len(p.profiles))]
+}
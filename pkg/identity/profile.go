@@ -0,0 +1,254 @@
+// Package identity contains named browser identity profiles shared by the
+// crawlergo and katana engines. A profile keeps the user agent, client hints,
+// viewport, locale, timezone and WebGL strings of one real browser together
+// so that every signal a target can observe tells the same story.
+package identity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultUserAgent is the user agent of the default profile, used where
+// a plain user agent string is needed without a profile.
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36"
+
+// DefaultProfile is the name of the profile used when none is configured
+const DefaultProfile = "chrome-windows"
+
+// Brand is a single entry of the Sec-CH-UA brand list
+type Brand struct {
+	Name    string
+	Version string
+}
+
+// Viewport is the emulated window and screen size
+type Viewport struct {
+	Width             int
+	Height            int
+	DeviceScaleFactor float64
+}
+
+// Profile is a named browser identity
+type Profile struct {
+	Name      string
+	UserAgent string
+	// Platform is the value of navigator.platform
+	Platform string
+	// Brands are sent in Sec-CH-UA with major versions
+	Brands []Brand
+	// FullVersion is the full browser version used in Sec-CH-UA-Full-Version-List
+	FullVersion string
+	// PlatformName is the value of Sec-CH-UA-Platform, e.g. Windows or macOS
+	PlatformName    string
+	PlatformVersion string
+	Architecture    string
+	Bitness         string
+	Model           string
+	Mobile          bool
+	Viewport        Viewport
+	// Locale is the ICU locale used for Intl and navigator.language
+	Locale         string
+	Languages      []string
+	AcceptLanguage string
+	// Timezone is an IANA timezone identifier
+	Timezone            string
+	WebGLVendor         string
+	WebGLRenderer       string
+	HardwareConcurrency int
+	DeviceMemory        int
+}
+
+var chromeBrands = []Brand{{Name: "Google Chrome", Version: "141"}, {Name: "Not?A_Brand", Version: "8"}, {Name: "Chromium", Version: "141"}}
+
+var profiles = map[string]*Profile{
+	"chrome-windows": {
+		UserAgent:           DefaultUserAgent,
+		Platform:            "Win32",
+		Brands:              chromeBrands,
+		FullVersion:         "141.0.7390.108",
+		PlatformName:        "Windows",
+		PlatformVersion:     "15.0.0",
+		Architecture:        "x86",
+		Bitness:             "64",
+		Viewport:            Viewport{Width: 1920, Height: 1080, DeviceScaleFactor: 1},
+		Locale:              "en-US",
+		Languages:           []string{"en-US", "en"},
+		AcceptLanguage:      "en-US,en;q=0.9",
+		Timezone:            "America/New_York",
+		WebGLVendor:         "Google Inc. (NVIDIA)",
+		WebGLRenderer:       "ANGLE (NVIDIA, NVIDIA GeForce RTX 3060 (0x00002504) Direct3D11 vs_5_0 ps_5_0, D3D11)",
+		HardwareConcurrency: 8,
+		DeviceMemory:        8,
+	},
+	"chrome-windows-zh": {
+		UserAgent:           DefaultUserAgent,
+		Platform:            "Win32",
+		Brands:              chromeBrands,
+		FullVersion:         "141.0.7390.108",
+		PlatformName:        "Windows",
+		PlatformVersion:     "10.0.0",
+		Architecture:        "x86",
+		Bitness:             "64",
+		Viewport:            Viewport{Width: 1536, Height: 864, DeviceScaleFactor: 1.25},
+		Locale:              "zh-CN",
+		Languages:           []string{"zh-CN", "zh"},
+		AcceptLanguage:      "zh-CN,zh;q=0.9",
+		Timezone:            "Asia/Shanghai",
+		WebGLVendor:         "Google Inc. (Intel)",
+		WebGLRenderer:       "ANGLE (Intel, Intel(R) UHD Graphics 630 (0x00003E92) Direct3D11 vs_5_0 ps_5_0, D3D11)",
+		HardwareConcurrency: 12,
+		DeviceMemory:        16,
+	},
+	"chrome-mac": {
+		UserAgent:           "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
+		Platform:            "MacIntel",
+		Brands:              chromeBrands,
+		FullVersion:         "141.0.7390.108",
+		PlatformName:        "macOS",
+		PlatformVersion:     "15.6.1",
+		Architecture:        "arm",
+		Bitness:             "64",
+		Viewport:            Viewport{Width: 1512, Height: 982, DeviceScaleFactor: 2},
+		Locale:              "en-US",
+		Languages:           []string{"en-US", "en"},
+		AcceptLanguage:      "en-US,en;q=0.9",
+		Timezone:            "America/Los_Angeles",
+		WebGLVendor:         "Google Inc. (Apple)",
+		WebGLRenderer:       "ANGLE (Apple, ANGLE Metal Renderer: Apple M2, Unspecified Version)",
+		HardwareConcurrency: 8,
+		DeviceMemory:        8,
+	},
+	"chrome-linux": {
+		UserAgent:           "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
+		Platform:            "Linux x86_64",
+		Brands:              chromeBrands,
+		FullVersion:         "141.0.7390.107",
+		PlatformName:        "Linux",
+		PlatformVersion:     "6.8.0",
+		Architecture:        "x86",
+		Bitness:             "64",
+		Viewport:            Viewport{Width: 1366, Height: 768, DeviceScaleFactor: 1},
+		Locale:              "en-GB",
+		Languages:           []string{"en-GB", "en"},
+		AcceptLanguage:      "en-GB,en;q=0.9",
+		Timezone:            "Europe/London",
+		WebGLVendor:         "Google Inc. (Intel)",
+		WebGLRenderer:       "ANGLE (Intel, Mesa Intel(R) UHD Graphics 620 (KBL GT2), OpenGL 4.6)",
+		HardwareConcurrency: 4,
+		DeviceMemory:        8,
+	},
+	"edge-windows": {
+		UserAgent:           "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36 Edg/141.0.0.0",
+		Platform:            "Win32",
+		Brands:              []Brand{{Name: "Microsoft Edge", Version: "141"}, {Name: "Not?A_Brand", Version: "8"}, {Name: "Chromium", Version: "141"}},
+		FullVersion:         "141.0.3537.85",
+		PlatformName:        "Windows",
+		PlatformVersion:     "15.0.0",
+		Architecture:        "x86",
+		Bitness:             "64",
+		Viewport:            Viewport{Width: 1920, Height: 1080, DeviceScaleFactor: 1},
+		Locale:              "de-DE",
+		Languages:           []string{"de-DE", "de", "en"},
+		AcceptLanguage:      "de-DE,de;q=0.9,en;q=0.8",
+		Timezone:            "Europe/Berlin",
+		WebGLVendor:         "Google Inc. (AMD)",
+		WebGLRenderer:       "ANGLE (AMD, AMD Radeon RX 6600 (0x000073FF) Direct3D11 vs_5_0 ps_5_0, D3D11)",
+		HardwareConcurrency: 12,
+		DeviceMemory:        8,
+	},
+	"chrome-android": {
+		UserAgent:           "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Mobile Safari/537.36",
+		Platform:            "Linux armv81",
+		Brands:              chromeBrands,
+		FullVersion:         "141.0.7390.111",
+		PlatformName:        "Android",
+		PlatformVersion:     "15.0.0",
+		Model:               "Pixel 8",
+		Mobile:              true,
+		Viewport:            Viewport{Width: 412, Height: 915, DeviceScaleFactor: 2.625},
+		Locale:              "en-US",
+		Languages:           []string{"en-US", "en"},
+		AcceptLanguage:      "en-US,en;q=0.9",
+		Timezone:            "America/Chicago",
+		WebGLVendor:         "Qualcomm",
+		WebGLRenderer:       "Adreno (TM) 740",
+		HardwareConcurrency: 8,
+		DeviceMemory:        8,
+	},
+}
+
+func init() {
+	for name, profile := range profiles {
+		profile.Name = name
+	}
+}
+
+// Names returns the sorted names of the built-in profiles
+func Names() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns a copy of the named profile
+func Get(name string) (*Profile, error) {
+	profile, ok := profiles[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown identity profile %q, available: %s", name, strings.Join(Names(), ", "))
+	}
+	clone := *profile
+	return &clone, nil
+}
+
+// Default returns the default profile
+func Default() *Profile {
+	profile, _ := Get(DefaultProfile)
+	return profile
+}
+
+// SecCHUA returns the Sec-CH-UA header value
+func (p *Profile) SecCHUA() string {
+	parts := make([]string, 0, len(p.Brands))
+	for _, brand := range p.Brands {
+		parts = append(parts, fmt.Sprintf("%q;v=%q", brand.Name, brand.Version))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FullVersionList returns the brands with full versions, the greasy brand
+// keeps its own version
+func (p *Profile) FullVersionList() []Brand {
+	brands := make([]Brand, 0, len(p.Brands))
+	for _, brand := range p.Brands {
+		if strings.HasPrefix(brand.Name, "Not") {
+			brands = append(brands, Brand{Name: brand.Name, Version: brand.Version + ".0.0.0"})
+			continue
+		}
+		brands = append(brands, Brand{Name: brand.Name, Version: p.FullVersion})
+	}
+	return brands
+}
+
+// Headers returns the request headers a browser with this identity sends
+// on every request: user agent, accept language and low entropy client hints.
+func (p *Profile) Headers() map[string]string {
+	headers := map[string]string{
+		"User-Agent":      p.UserAgent,
+		"Accept-Language": p.AcceptLanguage,
+	}
+	if len(p.Brands) > 0 {
+		mobile := "?0"
+		if p.Mobile {
+			mobile = "?1"
+		}
+		headers["Sec-CH-UA"] = p.SecCHUA()
+		headers["Sec-CH-UA-Mobile"] = mobile
+		headers["Sec-CH-UA-Platform"] = fmt.Sprintf("%q", p.PlatformName)
+	}
+	return headers
+}
@@ -0,0 +1,77 @@
+package identity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	for _, name := range Names() {
+		profile, err := Get(name)
+		require.NoError(t, err)
+		assert.Equal(t, name, profile.Name)
+		assert.NotEmpty(t, profile.UserAgent, name)
+		assert.NotEmpty(t, profile.Locale, name)
+		assert.NotEmpty(t, profile.Timezone, name)
+		assert.NotEmpty(t, profile.AcceptLanguage, name)
+		assert.True(t, strings.HasPrefix(profile.AcceptLanguage, profile.Languages[0]), name)
+		assert.Positive(t, profile.Viewport.Width, name)
+	}
+	assert.Equal(t, DefaultUserAgent, Default().UserAgent)
+
+	_, err := Get("netscape")
+	assert.Error(t, err)
+
+	// profiles are copies
+	profile, _ := Get("chrome-mac")
+	profile.UserAgent = "changed"
+	again, _ := Get("chrome-mac")
+	assert.NotEqual(t, "changed", again.UserAgent)
+}
+
+func TestHeaders(t *testing.T) {
+	headers := Default().Headers()
+	assert.Equal(t, DefaultUserAgent, headers["User-Agent"])
+	assert.Equal(t, "en-US,en;q=0.9", headers["Accept-Language"])
+	assert.Equal(t, `"Google Chrome";v="141", "Not?A_Brand";v="8", "Chromium";v="141"`, headers["Sec-CH-UA"])
+	assert.Equal(t, "?0", headers["Sec-CH-UA-Mobile"])
+	assert.Equal(t, `"Windows"`, headers["Sec-CH-UA-Platform"])
+
+	mobile, _ := Get("chrome-android")
+	assert.Equal(t, "?1", mobile.Headers()["Sec-CH-UA-Mobile"])
+}
+
+func TestInitScript(t *testing.T) {
+	profile, _ := Get("chrome-linux")
+	script := profile.InitScript()
+	assert.Contains(t, script, `"platform":"Linux x86_64"`)
+	assert.Contains(t, script, `"languages":["en-GB","en"]`)
+	assert.NotContains(t, script, "%!")
+}
+
+func TestPool(t *testing.T) {
+	pool, err := NewPool(nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProfile, pool.ForTarget("https://example.com/").Name)
+
+	_, err = NewPool([]string{"chrome-windows", "nope"})
+	assert.Error(t, err)
+
+	pool, err = NewPool([]string{"chrome-windows", "chrome-mac", "chrome-linux", "chrome-mac"})
+	require.NoError(t, err)
+	assert.Len(t, pool.Profiles(), 3)
+
+	// the same host always gets the same profile, regardless of path or scheme
+	first := pool.ForTarget("https://a.example.com/login")
+	assert.Equal(t, first.Name, pool.ForTarget("http://a.example.com/other?x=1").Name)
+	assert.Equal(t, first.Name, pool.ForTarget("a.example.com").Name)
+
+	names := make(map[string]struct{})
+	for _, host := range []string{"a.com", "b.com", "c.com", "d.com", "e.com", "f.com", "g.com", "h.com"} {
+		names[pool.ForTarget("https://"+host).Name] = struct{}{}
+	}
+	assert.Greater(t, len(names), 1)
+}
@@ -0,0 +1,117 @@
+package identity
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// initScript patches the navigator, screen and WebGL signals that do not
+// follow from the emulation overrides, and hides the automation markers.
+// It is evaluated before any page script on every new document.
+const initScript = `
+(function (p) {
+	if (window.__identityApplied) return;
+	Object.defineProperty(window, '__identityApplied', {value: true, enumerable: false});
+
+	const define = function (target, name, value) {
+		try {
+			Object.defineProperty(target, name, {get: function () { return value; }, configurable: true, enumerable: true});
+		} catch (e) {}
+	};
+	const nav = Navigator.prototype;
+	define(nav, 'webdriver', false);
+	define(nav, 'userAgent', p.userAgent);
+	define(nav, 'appVersion', p.userAgent.replace(/^Mozilla\//, ''));
+	define(nav, 'platform', p.platform);
+	define(nav, 'language', p.languages[0]);
+	define(nav, 'languages', Object.freeze(p.languages.slice()));
+	define(nav, 'vendor', 'Google Inc.');
+	if (p.hardwareConcurrency) define(nav, 'hardwareConcurrency', p.hardwareConcurrency);
+	if (p.deviceMemory) define(nav, 'deviceMemory', p.deviceMemory);
+	define(nav, 'maxTouchPoints', p.mobile ? 5 : 0);
+
+	if (!p.mobile && navigator.plugins && navigator.plugins.length === 0) {
+		const names = ['PDF Viewer', 'Chrome PDF Viewer', 'Chromium PDF Viewer', 'Microsoft Edge PDF Viewer', 'WebKit built-in PDF'];
+		const plugins = names.map(function (name) {
+			return {name: name, filename: 'internal-pdf-viewer', description: 'Portable Document Format', length: 0};
+		});
+		plugins.item = function (i) { return plugins[i] || null; };
+		plugins.namedItem = function (name) { return plugins.find(function (x) { return x.name === name; }) || null; };
+		plugins.refresh = function () {};
+		define(nav, 'plugins', plugins);
+	}
+
+	if (window.screen) {
+		const scr = Screen.prototype;
+		define(scr, 'width', p.width);
+		define(scr, 'height', p.height);
+		define(scr, 'availWidth', p.width);
+		define(scr, 'availHeight', p.mobile ? p.height : p.height - 40);
+		define(scr, 'colorDepth', 24);
+		define(scr, 'pixelDepth', 24);
+	}
+
+	if (!window.chrome) {
+		window.chrome = {runtime: {}, app: {isInstalled: false}};
+	}
+
+	if (navigator.permissions && navigator.permissions.query) {
+		const originalQuery = navigator.permissions.query.bind(navigator.permissions);
+		navigator.permissions.query = function (parameters) {
+			if (parameters && parameters.name === 'notifications') {
+				return Promise.resolve({state: Notification.permission, onchange: null});
+			}
+			return originalQuery(parameters);
+		};
+	}
+
+	const patchWebGL = function (proto) {
+		if (!proto) return;
+		const getParameter = proto.getParameter;
+		proto.getParameter = function (parameter) {
+			// UNMASKED_VENDOR_WEBGL / UNMASKED_RENDERER_WEBGL
+			if (parameter === 37445 && p.webglVendor) return p.webglVendor;
+			if (parameter === 37446 && p.webglRenderer) return p.webglRenderer;
+			return getParameter.apply(this, arguments);
+		};
+	};
+	patchWebGL(window.WebGLRenderingContext && WebGLRenderingContext.prototype);
+	patchWebGL(window.WebGL2RenderingContext && WebGL2RenderingContext.prototype);
+})(%s);
+`
+
+type scriptParams struct {
+	UserAgent           string   `json:"userAgent"`
+	Platform            string   `json:"platform"`
+	Languages           []string `json:"languages"`
+	HardwareConcurrency int      `json:"hardwareConcurrency"`
+	DeviceMemory        int      `json:"deviceMemory"`
+	Mobile              bool     `json:"mobile"`
+	Width               int      `json:"width"`
+	Height              int      `json:"height"`
+	WebGLVendor         string   `json:"webglVendor"`
+	WebGLRenderer       string   `json:"webglRenderer"`
+}
+
+// InitScript returns the JavaScript that makes the page environment match
+// the profile. It has to run before any page script, e.g. with
+// Page.addScriptToEvaluateOnNewDocument.
+func (p *Profile) InitScript() string {
+	languages := p.Languages
+	if len(languages) == 0 {
+		languages = []string{p.Locale}
+	}
+	params, _ := json.Marshal(scriptParams{
+		UserAgent:           p.UserAgent,
+		Platform:            p.Platform,
+		Languages:           languages,
+		HardwareConcurrency: p.HardwareConcurrency,
+		DeviceMemory:        p.DeviceMemory,
+		Mobile:              p.Mobile,
+		Width:               p.Viewport.Width,
+		Height:              p.Viewport.Height,
+		WebGLVendor:         p.WebGLVendor,
+		WebGLRenderer:       p.WebGLRenderer,
+	})
+	return fmt.Sprintf(initScript, params)
+}
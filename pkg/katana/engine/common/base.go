@@ -11,6 +11,8 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"github.com/go-rod/rod"
 	"github.com/projectdiscovery/gologger"
+	"katanacrawlgo/pkg/challenge"
+	"katanacrawlgo/pkg/identity"
 	"katanacrawlgo/pkg/katana/engine/parser"
 	"katanacrawlgo/pkg/katana/engine/parser/files"
 	"katanacrawlgo/pkg/katana/navigation"
@@ -41,7 +43,7 @@ func NewShared(options *types.CrawlerOptions) (*Shared, error) {
 		if err != nil {
 			return nil, errorutil.New("could not create http client").Wrap(err)
 		}
-		shared.KnownFiles = files.New(httpclient, options.Options.KnownFiles)
+		shared.KnownFiles = files.New(httpclient, options.Options.KnownFiles, options.Options.OpenAPIPost)
 	}
 	return shared, nil
 }
@@ -123,6 +125,7 @@ type CrawlSession struct {
 	Queue      *queue.Queue
 	HttpClient *retryablehttp.Client
 	Browser    *rod.Browser
+	Identity   *identity.Profile
 }
 
 func (s *Shared) NewCrawlSessionWithURL(URL string) (*CrawlSession, error) {
@@ -185,6 +188,7 @@ func (s *Shared) NewCrawlSessionWithURL(URL string) (*CrawlSession, error) {
 		Hostname:   hostname,
 		Queue:      queue,
 		HttpClient: httpclient,
+		Identity:   s.Options.Identities.ForTarget(hostname),
 	}
 	return crawlSession, nil
 }
@@ -223,6 +227,16 @@ func (s *Shared) Do(crawlSession *CrawlSession, doRequest DoRequestFunc) error {
 		go func() {
 			defer wg.Done()
 
+			// Back off from hosts answering with challenge pages
+			var host string
+			if parsed, err := urlutil.Parse(req.URL); err == nil {
+				host = parsed.Hostname()
+			}
+			if !s.Options.Challenges.Wait(host) {
+				gologger.Debug().Msgf("`%v` host served too many challenge pages. skipping", req.URL)
+				return
+			}
+
 			s.Options.RateLimit.Take()
 
 			// Delay if the user has asked for it
@@ -231,6 +245,10 @@ func (s *Shared) Do(crawlSession *CrawlSession, doRequest DoRequestFunc) error {
 			}
 
 			resp, err := doRequest(crawlSession, req)
+			if err == nil && resp != nil && resp.Resp != nil {
+				resp.Challenge = challenge.Detect(resp.StatusCode, resp.Headers, resp.Body)
+				s.Options.Challenges.Record(host, req.URL, resp.Challenge)
+			}
 
 			if inScope {
 				s.Output(req, resp, err)
@@ -253,6 +271,11 @@ func (s *Shared) Do(crawlSession *CrawlSession, doRequest DoRequestFunc) error {
 			if s.Options.Options.DisableRedirects && resp.IsRedirect() {
 				return
 			}
+			// Links of challenge pages belong to the protection, not the target
+			if resp.Challenge != nil {
+				gologger.Warning().Msgf("%s challenge page received for %s\n", resp.Challenge.Provider, req.URL)
+				return
+			}
 
 			navigationRequests := parser.ParseResponse(resp)
 			s.Enqueue(crawlSession.Queue, navigationRequests...)
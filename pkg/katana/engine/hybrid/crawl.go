@@ -37,6 +37,7 @@ func (c *Crawler) navigateRequest(s *common.CrawlSession, request *navigation.Re
 		return nil, errorutil.NewWithTag("hybrid", "could not create target").Wrap(err)
 	}
 	defer page.Close()
+	c.applyIdentityToPage(page, s.Identity)
 	c.addHeadersToPage(page)
 
 	pageRouter := NewHijack(page)
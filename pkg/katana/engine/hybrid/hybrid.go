@@ -133,6 +133,7 @@ func buildChromeLauncher(options *types.CrawlerOptions, dataStore string) (*laun
 		Set("hide-scrollbars", "true").
 		Set("window-size", fmt.Sprintf("%d,%d", 1080, 1920)).
 		Set("mute-audio", "true").
+		Set("disable-blink-features", "AutomationControlled").
 		Delete("use-mock-keychain").
 		UserDataDir(dataStore)
 
@@ -0,0 +1,58 @@
+package hybrid
+
+import (
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/projectdiscovery/gologger"
+	"katanacrawlgo/pkg/identity"
+)
+
+// applyIdentityToPage emulates the browser identity profile on a page
+// before navigation. User specified headers applied afterwards by
+// addHeadersToPage still take precedence.
+func (c *Crawler) applyIdentityToPage(page *rod.Page, profile *identity.Profile) {
+	if profile == nil {
+		return
+	}
+	metadata := &proto.EmulationUserAgentMetadata{
+		Platform:        profile.PlatformName,
+		PlatformVersion: profile.PlatformVersion,
+		Architecture:    profile.Architecture,
+		Model:           profile.Model,
+		Mobile:          profile.Mobile,
+		Bitness:         profile.Bitness,
+	}
+	for _, brand := range profile.Brands {
+		metadata.Brands = append(metadata.Brands, &proto.EmulationUserAgentBrandVersion{Brand: brand.Name, Version: brand.Version})
+	}
+	for _, brand := range profile.FullVersionList() {
+		metadata.FullVersionList = append(metadata.FullVersionList, &proto.EmulationUserAgentBrandVersion{Brand: brand.Name, Version: brand.Version})
+	}
+	if err := page.SetUserAgent(&proto.NetworkSetUserAgentOverride{
+		UserAgent:         profile.UserAgent,
+		AcceptLanguage:    profile.AcceptLanguage,
+		Platform:          profile.Platform,
+		UserAgentMetadata: metadata,
+	}); err != nil {
+		gologger.Error().Msgf("headless: could not set identity user agent: %v", err)
+	}
+
+	width, height := profile.Viewport.Width, profile.Viewport.Height
+	if err := page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
+		Width:             width,
+		Height:            height,
+		DeviceScaleFactor: profile.Viewport.DeviceScaleFactor,
+		Mobile:            profile.Mobile,
+		ScreenWidth:       &width,
+		ScreenHeight:      &height,
+	}); err != nil {
+		gologger.Error().Msgf("headless: could not set identity viewport: %v", err)
+	}
+	// Locale and timezone overrides are best effort, older browsers reject them
+	_ = proto.EmulationSetLocaleOverride{Locale: profile.Locale}.Call(page)
+	_ = proto.EmulationSetTimezoneOverride{TimezoneID: profile.Timezone}.Call(page)
+
+	if _, err := page.EvalOnNewDocument(profile.InitScript()); err != nil {
+		gologger.Error().Msgf("headless: could not add identity script: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package files
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"katanacrawlgo/pkg/katana/navigation"
+	"katanacrawlgo/pkg/katana/utils"
+	"katanacrawlgo/pkg/openapi"
+	"github.com/projectdiscovery/retryablehttp-go"
+)
+
+// maxSpecSize is the maximum size of a specification read while probing
+const maxSpecSize = 10 * 1024 * 1024
+
+type openAPICrawler struct {
+	httpclient *retryablehttp.Client
+	allowPost  bool
+}
+
+// Visit probes the common specification locations of the provided URL and
+// returns the operations of every specification found
+func (r *openAPICrawler) Visit(URL string) (navigationRequests []*navigation.Request, err error) {
+	URL = strings.TrimSuffix(URL, "/")
+	for _, path := range openapi.SpecPaths {
+		req, err := retryablehttp.NewRequest(http.MethodGet, URL+path, nil)
+		if err != nil {
+			continue
+		}
+		req.Header.Set("User-Agent", utils.WebUserAgent())
+
+		resp, err := r.httpclient.Do(req)
+		if err != nil {
+			continue
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxSpecSize))
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			continue
+		}
+		navResp := &navigation.Response{
+			Depth:      2,
+			Resp:       resp,
+			StatusCode: resp.StatusCode,
+			Headers:    utils.FlattenHeaders(resp.Header),
+		}
+		navigationRequests = append(navigationRequests, OpenAPIRequests(data, resp.Request.URL.String(), navResp, r.allowPost)...)
+	}
+	return
+}
+
+// OpenAPIRequests parses a specification and converts its operations into
+// navigation requests. Only GET operations are returned unless allowPost is
+// set, as POST operations may create or modify data. Other methods are never
+// returned as the crawler does not replay them.
+func OpenAPIRequests(data []byte, specURL string, resp *navigation.Response, allowPost bool) (navigationRequests []*navigation.Request) {
+	if !openapi.LooksLikeSpec(data) {
+		return nil
+	}
+	doc, err := openapi.Parse(data)
+	if err != nil {
+		return nil
+	}
+	for _, request := range doc.Requests(specURL) {
+		if request.Method != http.MethodGet && !(allowPost && request.Method == http.MethodPost) {
+			continue
+		}
+		navRequest := navigation.NewNavigationRequestURLFromResponse(request.URL, specURL, "file", "openapi", resp)
+		navRequest.Method = request.Method
+		navRequest.Body = request.Body
+		if len(request.Headers) > 0 {
+			navRequest.Headers = request.Headers
+		}
+		navigationRequests = append(navigationRequests, navRequest)
+	}
+	return
+}
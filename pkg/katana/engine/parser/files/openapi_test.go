@@ -0,0 +1,32 @@
+package files
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/projectdiscovery/retryablehttp-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIVisit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/api-docs":
+			_, _ = w.Write([]byte(`{"openapi":"3.0.0","paths":{"/items":{"get":{"parameters":[{"name":"q","in":"query","schema":{"type":"string"}}]}}}}`))
+		case "/swagger.json":
+			// a page served for every path is not a specification
+			_, _ = w.Write([]byte(`<html>swagger paths</html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	crawler := &openAPICrawler{httpclient: retryablehttp.NewClient(retryablehttp.DefaultOptionsSingle)}
+	navigationRequests, err := crawler.Visit(server.URL + "/")
+	require.Nil(t, err)
+	require.Len(t, navigationRequests, 1)
+	require.Equal(t, server.URL+"/items?q=test", navigationRequests[0].URL)
+	require.Equal(t, "openapi", navigationRequests[0].Attribute)
+}
@@ -12,8 +12,9 @@ type KnownFiles struct {
 	httpclient *retryablehttp.Client
 }
 
-// New returns a new known files parser instance
-func New(httpclient *retryablehttp.Client, files string) *KnownFiles {
+// New returns a new known files parser instance, openAPIPost also sends
+// the POST operations of the specifications found
+func New(httpclient *retryablehttp.Client, files string, openAPIPost bool) *KnownFiles {
 	parser := &KnownFiles{
 		httpclient: httpclient,
 	}
@@ -24,11 +25,16 @@ func New(httpclient *retryablehttp.Client, files string) *KnownFiles {
 	case "sitemapxml":
 		crawler := &sitemapXmlCrawler{httpclient: httpclient}
 		parser.parsers = append(parser.parsers, crawler.Visit)
+	case "openapi":
+		crawler := &openAPICrawler{httpclient: httpclient, allowPost: openAPIPost}
+		parser.parsers = append(parser.parsers, crawler.Visit)
 	default:
 		crawler := &robotsTxtCrawler{httpclient: httpclient}
 		parser.parsers = append(parser.parsers, crawler.Visit)
 		another := &sitemapXmlCrawler{httpclient: httpclient}
 		parser.parsers = append(parser.parsers, another.Visit)
+		spec := &openAPICrawler{httpclient: httpclient, allowPost: openAPIPost}
+		parser.parsers = append(parser.parsers, spec.Visit)
 	}
 	return parser
 }
@@ -7,9 +7,13 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/projectdiscovery/gologger"
+	"katanacrawlgo/pkg/katana/engine/parser/files"
 	"katanacrawlgo/pkg/katana/navigation"
 	"katanacrawlgo/pkg/katana/output"
 	"katanacrawlgo/pkg/katana/utils"
+	"katanacrawlgo/pkg/openapi"
+	"katanacrawlgo/pkg/upload"
+	mapsutil "github.com/projectdiscovery/utils/maps"
 	urlutil "github.com/projectdiscovery/utils/url"
 	"golang.org/x/net/html"
 )
@@ -492,6 +496,10 @@ func bodyHtmlDoctypeTagParser(resp *navigation.Response) (navigationRequests []*
 	return
 }
 
+// formVariantLimit is the maximum number of select option and radio choice
+// combinations submitted per form, variants are not enumerated when 0
+var formVariantLimit int
+
 // bodyFormTagParser parses forms from response
 func bodyFormTagParser(resp *navigation.Response) (navigationRequests []*navigation.Request) {
 	resp.Reader.Find("form").Each(func(i int, item *goquery.Selection) {
@@ -512,79 +520,121 @@ func bodyFormTagParser(resp *navigation.Response) (navigationRequests []*navigat
 			return
 		}
 
-		parsed, err := urlutil.Parse(actionURL)
-		if err != nil {
+		if _, err := urlutil.Parse(actionURL); err != nil {
 			gologger.Warning().Msgf("bodyFormTagParser :failed to parse url %v got %v", actionURL, err)
 			return
 		}
 
-		isMultipartForm := strings.HasPrefix(encType, "multipart/")
-
-		queryValuesWriter := urlutil.NewOrderedParams()
-		queryValuesWriter.IncludeEquals = true
-		var sb strings.Builder
-		var multipartWriter *multipart.Writer
-
-		if isMultipartForm {
-			multipartWriter = multipart.NewWriter(&sb)
-		}
-
 		// Get the form field suggestions for all elements in the form
 		formFields := []interface{}{}
+		fileSamples := make(map[string]*upload.Sample)
 		item.Find("input, select, textarea").Each(func(index int, item *goquery.Selection) {
 			if len(item.Nodes) == 0 {
 				return
 			}
 			formFields = append(formFields, utils.ConvertGoquerySelectionToFormField(item))
-		})
-
-		dataMap := utils.FormFillSuggestions(formFields)
-		dataMap.Iterate(func(key, value string) bool {
-			if key == "" {
-				return true
-			}
-			if isMultipartForm {
-				_ = multipartWriter.WriteField(key, value)
-			} else {
-				queryValuesWriter.Set(key, value)
+			if inputType, _ := item.Attr("type"); strings.EqualFold(inputType, "file") {
+				name, _ := item.Attr("name")
+				accept, _ := item.Attr("accept")
+				fileSamples[name] = upload.Select(accept)
 			}
-			return true
 		})
 
-		// Guess content-type
-		var contentType string
-		if multipartWriter != nil {
-			multipartWriter.Close()
-			contentType = multipartWriter.FormDataContentType()
-		} else {
-			contentType = encType
+		form := formRequest{
+			method:      method,
+			actionURL:   actionURL,
+			encType:     encType,
+			fileSamples: fileSamples,
+		}
+		// Submit every combination of select options and radio choices as its own variant
+		if variants := utils.FormFillVariants(formFields, formVariantLimit); len(variants) > 0 {
+			for _, variant := range variants {
+				req := form.build(resp, variant.Data)
+				req.Variant = variant.Label
+				navigationRequests = append(navigationRequests, req)
+			}
+			return
 		}
+		navigationRequests = append(navigationRequests, form.build(resp, utils.FormFillSuggestions(formFields)))
+	})
+	return
+}
 
-		req := &navigation.Request{
-			Method:       method,
-			URL:          actionURL,
-			Depth:        resp.Depth,
-			RootHostname: resp.RootHostname,
-			Tag:          "form",
-			Attribute:    "action",
-			Source:       resp.Resp.Request.URL.String(),
+// formRequest builds the requests submitting a form
+type formRequest struct {
+	method      string
+	actionURL   string
+	encType     string
+	fileSamples map[string]*upload.Sample
+}
+
+// build returns the request submitting the form with the given field values
+func (form formRequest) build(resp *navigation.Response, dataMap mapsutil.OrderedMap[string, string]) *navigation.Request {
+	isMultipartForm := strings.HasPrefix(form.encType, "multipart/")
+
+	queryValuesWriter := urlutil.NewOrderedParams()
+	queryValuesWriter.IncludeEquals = true
+	var sb strings.Builder
+	var multipartWriter *multipart.Writer
+
+	if isMultipartForm {
+		multipartWriter = multipart.NewWriter(&sb)
+	}
+
+	dataMap.Iterate(func(key, value string) bool {
+		if key == "" {
+			return true
 		}
-		switch method {
-		case "GET":
-			parsed.Params.Merge(queryValuesWriter.Encode())
-			req.URL = parsed.String()
-		case "POST":
-			if multipartWriter != nil {
-				req.Body = sb.String()
-			} else {
-				req.Body = queryValuesWriter.Encode()
-			}
-			req.Headers = make(map[string]string)
-			req.Headers["Content-Type"] = contentType
+		// file inputs submit a sample file, or only its name without multipart encoding
+		sample, isFile := form.fileSamples[key]
+		if isFile && isMultipartForm {
+			_ = upload.WriteFile(multipartWriter, key, sample)
+			return true
+		}
+		if isFile {
+			value = sample.Name
+		}
+		if isMultipartForm {
+			_ = multipartWriter.WriteField(key, value)
+		} else {
+			queryValuesWriter.Set(key, value)
 		}
-		navigationRequests = append(navigationRequests, req)
+		return true
 	})
-	return
+
+	// Guess content-type
+	var contentType string
+	if multipartWriter != nil {
+		multipartWriter.Close()
+		contentType = multipartWriter.FormDataContentType()
+	} else {
+		contentType = form.encType
+	}
+
+	req := &navigation.Request{
+		Method:       form.method,
+		URL:          form.actionURL,
+		Depth:        resp.Depth,
+		RootHostname: resp.RootHostname,
+		Tag:          "form",
+		Attribute:    "action",
+		Source:       resp.Resp.Request.URL.String(),
+	}
+	switch form.method {
+	case "GET":
+		parsed, _ := urlutil.Parse(form.actionURL)
+		parsed.Params.Merge(queryValuesWriter.Encode())
+		req.URL = parsed.String()
+	case "POST":
+		if multipartWriter != nil {
+			req.Body = sb.String()
+		} else {
+			req.Body = queryValuesWriter.Encode()
+		}
+		req.Headers = make(map[string]string)
+		req.Headers["Content-Type"] = contentType
+	}
+	return req
 }
 
 // bodyMetaContentTagParser parses meta content tag from response
@@ -685,6 +735,26 @@ func bodyScrapeEndpointsParser(resp *navigation.Response) (navigationRequests []
 	return
 }
 
+// -------------------------------------------------------------------------
+// Begin OpenAPI based parsers
+// -------------------------------------------------------------------------
+
+// openAPIPost enables the POST operations of OpenAPI specifications
+var openAPIPost bool
+
+// openAPISpecParser expands OpenAPI specifications into their operations
+func openAPISpecParser(resp *navigation.Response) (navigationRequests []*navigation.Request) {
+	return files.OpenAPIRequests([]byte(resp.Body), resp.Resp.Request.URL.String(), resp, openAPIPost)
+}
+
+// openAPIUIParser parses specification URLs from Swagger UI and ReDoc pages
+func openAPIUIParser(resp *navigation.Response) (navigationRequests []*navigation.Request) {
+	for _, spec := range openapi.DetectSpecURLs(string(resp.Body)) {
+		navigationRequests = append(navigationRequests, navigation.NewNavigationRequestURLFromResponse(spec, resp.Resp.Request.URL.String(), "openapi", "spec-url", resp))
+	}
+	return
+}
+
 // customFieldRegexParser parses custom regex from HTML body and header
 func customFieldRegexParser(resp *navigation.Response) (navigationRequests []*navigation.Request) {
 	var customField = make(map[string][]string)
@@ -15,6 +15,7 @@ import (
 func InitWithOptions(options *types.Options) {
 	if options.AutomaticFormFill {
 		responseParsers = append(responseParsers, responseParser{bodyParser, bodyFormTagParser})
+		formVariantLimit = options.FormVariants
 	}
 	if options.ScrapeJSLuiceResponses {
 		responseParsers = append(responseParsers, responseParser{bodyParser, scriptContentJsluiceParser})
@@ -25,6 +26,11 @@ func InitWithOptions(options *types.Options) {
 		responseParsers = append(responseParsers, responseParser{contentParser, scriptJSFileRegexParser})
 		responseParsers = append(responseParsers, responseParser{contentParser, bodyScrapeEndpointsParser})
 	}
+	if options.OpenAPI {
+		openAPIPost = options.OpenAPIPost
+		responseParsers = append(responseParsers, responseParser{contentParser, openAPISpecParser})
+		responseParsers = append(responseParsers, responseParser{contentParser, openAPIUIParser})
+	}
 	if !options.DisableRedirects {
 		responseParsers = append(responseParsers, responseParser{headerParser, headerLocationParser})
 	}
@@ -7,12 +7,18 @@ import "katanacrawlgo/pkg/katana/types"
 func InitWithOptions(options *types.Options) {
 	if options.AutomaticFormFill {
 		responseParsers = append(responseParsers, responseParser{bodyParser, bodyFormTagParser})
+		formVariantLimit = options.FormVariants
 	}
 	if options.ScrapeJSResponses {
 		responseParsers = append(responseParsers, responseParser{bodyParser, scriptContentRegexParser})
 		responseParsers = append(responseParsers, responseParser{contentParser, scriptJSFileRegexParser})
 		responseParsers = append(responseParsers, responseParser{contentParser, bodyScrapeEndpointsParser})
 	}
+	if options.OpenAPI {
+		openAPIPost = options.OpenAPIPost
+		responseParsers = append(responseParsers, responseParser{contentParser, openAPISpecParser})
+		responseParsers = append(responseParsers, responseParser{contentParser, openAPIUIParser})
+	}
 	if !options.DisableRedirects {
 		responseParsers = append(responseParsers, responseParser{headerParser, headerLocationParser})
 	}
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"regexp"
 	"strings"
@@ -356,6 +358,52 @@ func TestBodyParsers(t *testing.T) {
 			require.Equal(t, "https://security-crawl-maze.app/test/html/body/form/action-post.found", navigationRequests[0].URL, "could not get correct url")
 			require.Equal(t, "POST", navigationRequests[0].Method, "could not get correct method")
 		})
+		t.Run("upload", func(t *testing.T) {
+			documentReader, _ := goquery.NewDocumentFromReader(strings.NewReader("<form action=\"/upload\" method=\"POST\" enctype=\"multipart/form-data\"><input type=\"text\" name=\"title\" value=\"test\"><input type=\"file\" name=\"doc\" accept=\".pdf,image/*\"></form>"))
+			resp := &navigation.Response{Resp: &http.Response{Request: &http.Request{URL: parsed.URL}}, Reader: documentReader}
+			navigationRequests := bodyFormTagParser(resp)
+			require.Len(t, navigationRequests, 1)
+			req := navigationRequests[0]
+			_, params, err := mime.ParseMediaType(req.Headers["Content-Type"])
+			require.NoError(t, err)
+			form, err := multipart.NewReader(strings.NewReader(req.Body), params["boundary"]).ReadForm(1 << 20)
+			require.NoError(t, err)
+			require.Equal(t, []string{"test"}, form.Value["title"])
+			require.Len(t, form.File["doc"], 1)
+			require.Equal(t, "sample.pdf", form.File["doc"][0].Filename)
+			require.Equal(t, "application/pdf", form.File["doc"][0].Header.Get("Content-Type"))
+
+			documentReader, _ = goquery.NewDocumentFromReader(strings.NewReader("<form action=\"/upload\" method=\"POST\"><input type=\"file\" name=\"doc\"></form>"))
+			resp = &navigation.Response{Resp: &http.Response{Request: &http.Request{URL: parsed.URL}}, Reader: documentReader}
+			navigationRequests = bodyFormTagParser(resp)
+			require.Equal(t, "doc=sample.png", navigationRequests[0].Body, "urlencoded forms only submit the file name")
+		})
+		t.Run("variants", func(t *testing.T) {
+			defer func() { formVariantLimit = 0 }()
+			formVariantLimit = 4
+			documentReader, _ := goquery.NewDocumentFromReader(strings.NewReader("<form action=\"/search\"><select name=\"sort\"><option value=\"name\">Name</option><option value=\"price\" selected>Price</option></select><input type=\"radio\" name=\"type\" value=\"1\"><input type=\"radio\" name=\"type\" value=\"2\"><input type=\"radio\" name=\"type\" value=\"3\"><input type=\"text\" name=\"q\" value=\"test\"></form>"))
+			resp := &navigation.Response{Resp: &http.Response{Request: &http.Request{URL: parsed.URL}}, Reader: documentReader}
+			navigationRequests := bodyFormTagParser(resp)
+			require.Len(t, navigationRequests, 4)
+			var urls, variants []string
+			for _, req := range navigationRequests {
+				urls = append(urls, req.URL)
+				variants = append(variants, req.Variant)
+			}
+			require.Equal(t, []string{
+				"https://security-crawl-maze.app/search?sort=price&type=1&q=test",
+				"https://security-crawl-maze.app/search?sort=name&type=1&q=test",
+				"https://security-crawl-maze.app/search?sort=price&type=2&q=test",
+				"https://security-crawl-maze.app/search?sort=price&type=3&q=test",
+			}, urls)
+			require.Equal(t, []string{"sort=price&type=1", "sort=name&type=1", "sort=price&type=2", "sort=price&type=3"}, variants)
+
+			documentReader, _ = goquery.NewDocumentFromReader(strings.NewReader("<form action=\"/search\"><input type=\"text\" name=\"q\" value=\"test\"></form>"))
+			resp = &navigation.Response{Resp: &http.Response{Request: &http.Request{URL: parsed.URL}}, Reader: documentReader}
+			navigationRequests = bodyFormTagParser(resp)
+			require.Len(t, navigationRequests, 1, "forms without choices are submitted once")
+			require.Empty(t, navigationRequests[0].Variant)
+		})
 	})
 
 	t.Run("meta", func(t *testing.T) {
@@ -523,3 +571,34 @@ func TestHtmxBodyParser(t *testing.T) {
 		require.Equal(t, "PATCH", navigationRequests[0].Method, "could not get correct method")
 	})
 }
+
+func TestOpenAPIParsers(t *testing.T) {
+	t.Run("spec", func(t *testing.T) {
+		parsed, _ := urlutil.Parse("https://example.com/v2/api-docs")
+		spec := `{"swagger":"2.0","basePath":"/api","paths":{
+  "/users/{id}":{"get":{"parameters":[{"name":"id","in":"path","type":"integer"}]},"delete":{}},
+  "/users":{"post":{"parameters":[{"name":"body","in":"body","schema":{"type":"object","properties":{"name":{"type":"string"}}}}]}}}}`
+		resp := &navigation.Response{Resp: &http.Response{Request: &http.Request{URL: parsed.URL}}, Body: spec}
+		navigationRequests := openAPISpecParser(resp)
+		require.Len(t, navigationRequests, 1, "only GET operations are crawled by default")
+		require.Equal(t, "GET", navigationRequests[0].Method)
+
+		openAPIPost = true
+		defer func() { openAPIPost = false }()
+		navigationRequests = openAPISpecParser(resp)
+		require.Len(t, navigationRequests, 2, "only GET and POST operations are crawled")
+		require.Equal(t, "POST", navigationRequests[0].Method)
+		require.Equal(t, "https://example.com/api/users", navigationRequests[0].URL)
+		require.Equal(t, `{"name":"test"}`, navigationRequests[0].Body)
+		require.Equal(t, "application/json", navigationRequests[0].Headers["Content-Type"])
+		require.Equal(t, "https://example.com/api/users/1", navigationRequests[1].URL)
+	})
+	t.Run("swagger-ui", func(t *testing.T) {
+		parsed, _ := urlutil.Parse("https://example.com/docs/")
+		page := `<div id="swagger-ui"></div><script>SwaggerUIBundle({url: "/openapi.json"})</script>`
+		resp := &navigation.Response{Resp: &http.Response{Request: &http.Request{URL: parsed.URL}}, Body: page}
+		navigationRequests := openAPIUIParser(resp)
+		require.Len(t, navigationRequests, 1)
+		require.Equal(t, "https://example.com/openapi.json", navigationRequests[0].URL)
+	})
+}
@@ -37,6 +37,11 @@ func (c *Crawler) makeRequest(s *common.CrawlSession, request *navigation.Reques
 		return response, err
 	}
 	req.Header.Set("User-Agent", utils.WebUserAgent())
+	if s.Identity != nil {
+		for k, v := range s.Identity.Headers() {
+			req.Header.Set(k, v)
+		}
+	}
 
 	// Set the headers for the request.
 	for k, v := range request.Headers {
@@ -22,6 +22,7 @@ type Request struct {
 	Source         string              `json:"source,omitempty"`
 	CustomFields   map[string][]string `json:"-"`
 	Raw            string              `json:"raw,omitempty"`
+	Variant        string              `json:"variant,omitempty"`
 }
 
 // RequestURL returns the request URL for the navigation
@@ -6,6 +6,7 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	jsoniter "github.com/json-iterator/go"
+	"katanacrawlgo/pkg/challenge"
 )
 
 type Headers map[string]string
@@ -41,6 +42,7 @@ type Response struct {
 	Forms              []Form            `json:"forms,omitempty"`
 	XhrRequests        []Request         `json:"xhr_requests,omitempty"`
 	StoredResponsePath string            `json:"stored_response_path,omitempty"`
+	Challenge          *challenge.Result `json:"challenge,omitempty"`
 }
 
 func (n Response) AbsoluteURL(path string) string {
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/projectdiscovery/fastdialer/fastdialer"
+	"katanacrawlgo/pkg/challenge"
+	"katanacrawlgo/pkg/identity"
 	"katanacrawlgo/pkg/katana/output"
 	"katanacrawlgo/pkg/katana/utils/extensions"
 	"katanacrawlgo/pkg/katana/utils/filters"
@@ -34,6 +36,10 @@ type CrawlerOptions struct {
 	Dialer *fastdialer.Dialer
 	// Wappalyzer instance for technologies detection
 	Wappalyzer *wappalyzer.Wappalyze
+	// Identities is the pool of browser identity profiles
+	Identities *identity.Pool
+	// Challenges tracks challenge pages per host for back-off
+	Challenges *challenge.Tracker
 }
 
 // NewCrawlerOptions creates a new crawler options structure
@@ -102,6 +108,16 @@ func NewCrawlerOptions(options *Options) (*CrawlerOptions, error) {
 		return nil, errorutil.NewWithErr(err).Msgf("could not create output writer")
 	}
 
+	identities, err := identity.NewPool(options.IdentityProfiles)
+	if err != nil {
+		return nil, errorutil.NewWithErr(err).Msgf("could not create identity profiles")
+	}
+
+	challenges := options.ChallengeTracker
+	if challenges == nil {
+		challenges = challenge.NewTracker()
+	}
+
 	crawlerOptions := &CrawlerOptions{
 		ExtensionsValidator: extensionsValidator,
 		ScopeManager:        scopeManager,
@@ -109,6 +125,8 @@ func NewCrawlerOptions(options *Options) (*CrawlerOptions, error) {
 		Options:             options,
 		Dialer:              fastdialerInstance,
 		OutputWriter:        outputWriter,
+		Identities:          identities,
+		Challenges:          challenges,
 	}
 
 	if options.RateLimit > 0 {
@@ -8,6 +8,7 @@ import (
 	"github.com/projectdiscovery/goflags"
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/gologger/levels"
+	"katanacrawlgo/pkg/challenge"
 	"katanacrawlgo/pkg/katana/output"
 	fileutil "github.com/projectdiscovery/utils/file"
 	logutil "github.com/projectdiscovery/utils/log"
@@ -61,6 +62,9 @@ type Options struct {
 	Parallelism int
 	// FormConfig is the path to the form configuration file
 	FormConfig string
+	// FormVariants is the maximum number of select option and radio choice
+	// combinations submitted per form, 0 submits each form once
+	FormVariants int
 	// Proxy is the URL for the proxy server
 	Proxy string
 	// Strategy is the crawling strategy. depth-first or breadth-first
@@ -71,6 +75,11 @@ type Options struct {
 	OutputFile string
 	// KnownFiles enables crawling of knows files like robots.txt, sitemap.xml, etc
 	KnownFiles string
+	// OpenAPI enables parsing of OpenAPI specifications and Swagger UI pages found while crawling
+	OpenAPI bool
+	// OpenAPIPost also sends the POST operations of OpenAPI specifications,
+	// which may create or modify data, only GET operations are sent otherwise
+	OpenAPIPost bool
 	// Fields is the fields to format in output
 	Fields string
 	// StoreFields is the fields to store in separate per-host files
@@ -95,6 +104,8 @@ type Options struct {
 	ScrapeJSLuiceResponses bool
 	// CustomHeaders is a list of custom headers to add to request
 	CustomHeaders goflags.StringSlice
+	// IdentityProfiles is a list of browser identity profiles rotated per target
+	IdentityProfiles goflags.StringSlice
 	// Headless enables headless scraping
 	Headless bool
 	// AutomaticFormFill enables optional automatic form filling and submission
@@ -115,6 +126,8 @@ type Options struct {
 	ChromeWSUrl string
 	// OnResult allows callback function on a result
 	OnResult OnResultCallback
+	// ChallengeTracker records challenge pages per host, a new one is used when nil
+	ChallengeTracker *challenge.Tracker
 	// StoreResponse specifies if katana should store http requests/responses
 	StoreResponse bool
 	// StoreResponseDir specifies if katana should use a custom directory to store http requests/responses
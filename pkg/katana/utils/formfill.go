@@ -1,43 +1,22 @@
 package utils
 
 import (
-	"fmt"
-	"strconv"
+	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"katanacrawlgo/pkg/formfill"
 	mapsutil "github.com/projectdiscovery/utils/maps"
-	"github.com/rs/xid"
 )
 
-// FormData is the global form fill data instance
-var FormData FormFillData
-
-func init() {
-	FormData = DefaultFormFillData
-}
-
-// FormFillData contains suggestions for form filling
-type FormFillData struct {
-	Email       string `yaml:"email"`
-	Color       string `yaml:"color"`
-	Password    string `yaml:"password"`
-	PhoneNumber string `yaml:"phone"`
-	Placeholder string `yaml:"placeholder"`
-}
-
-var DefaultFormFillData = FormFillData{
-	Email:       fmt.Sprintf("%s@example.org", xid.New().String()),
-	Color:       "#e66465",
-	Password:    "katanaP@assw0rd1",
-	PhoneNumber: "2124567890",
-	Placeholder: "katana",
-}
+// FormRules are the rules used to fill form fields, shared with crawlergo
+var FormRules = formfill.Default()
 
 // FormInput is an input for a form field
 type FormInput struct {
 	Type       string
 	Name       string
 	Value      string
+	Label      string
 	Attributes mapsutil.OrderedMap[string, string]
 }
 
@@ -57,16 +36,40 @@ type FormSelect struct {
 
 type FormTextArea struct {
 	Name       string
+	Label      string
 	Attributes mapsutil.OrderedMap[string, string]
 }
 
+// formFillField describes a form field for the form fill rules
+func formFillField(fieldType, name, label string, attributes mapsutil.OrderedMap[string, string]) formfill.Field {
+	get := func(key string) string {
+		value, _ := attributes.Get(key)
+		return value
+	}
+	if label == "" {
+		label = get("aria-label")
+	}
+	return formfill.Field{
+		Type:         strings.ToLower(fieldType),
+		Name:         name,
+		ID:           get("id"),
+		Class:        get("class"),
+		Label:        label,
+		Placeholder:  get("placeholder"),
+		Autocomplete: get("autocomplete"),
+		Min:          get("min"),
+		Max:          get("max"),
+		Step:         get("step"),
+	}
+}
+
 // FormInputFillSuggestions returns a list of form filling suggestions
 // for inputs returning the specified recommended values.
 func FormInputFillSuggestions(inputs []FormInput) mapsutil.OrderedMap[string, string] {
 	data := mapsutil.NewOrderedMap[string, string]()
 
 	// Fill checkboxes and radioboxes first or default values first
-	for i, input := range inputs {
+	for _, input := range inputs {
 		switch input.Type {
 		case "radio":
 			// Use a single radio name per value
@@ -77,53 +80,19 @@ func FormInputFillSuggestions(inputs []FormInput) mapsutil.OrderedMap[string, st
 			data.Set(input.Name, input.Value)
 
 		default:
-			// If there is a value, use it for the input. Else
-			// infer the values based on input types.
+			// If there is a value, use it for the input
 			if input.Value != "" {
 				data.Set(input.Name, input.Value)
-			} else if value, ok := input.Attributes.Get("placeholder"); ok {
-				inputs[i].Value = value
-				data.Set(input.Name, value)
 			}
 		}
 	}
 
-	// getIntWithdefault returns the integer value of the key or default value
-	getIntWithdefault := func(input *FormInput, key string, defaultValue int) int {
-		if value, ok := input.Attributes.Get(key); ok {
-			if intValue, err := strconv.Atoi(value); err == nil {
-				return intValue
-			}
-		}
-		return defaultValue
-	}
-
-	// Fill rest of the inputs based on their types or name and ids
+	// Fill rest of the inputs with the form fill rules
 	for _, input := range inputs {
 		if input.Value != "" {
 			continue
 		}
-		switch input.Type {
-		case "email":
-			data.Set(input.Name, FormData.Email)
-		case "color":
-			data.Set(input.Name, FormData.Color)
-		case "number", "range":
-			min := getIntWithdefault(&input, "min", 1)
-			max := getIntWithdefault(&input, "max", 10)
-			step := getIntWithdefault(&input, "step", 1)
-			val := min + step
-			if val > max {
-				val = max - step
-			}
-			data.Set(input.Name, strconv.Itoa(val))
-		case "password":
-			data.Set(input.Name, FormData.Password)
-		case "tel":
-			data.Set(input.Name, FormData.Password)
-		default:
-			data.Set(input.Name, FormData.Placeholder)
-		}
+		data.Set(input.Name, FormRules.Value(formFillField(input.Type, input.Name, input.Label, input.Attributes)))
 	}
 	return data
 }
@@ -151,13 +120,13 @@ func FormSelectFill(inputs []FormSelect) mapsutil.OrderedMap[string, string] {
 	return data
 }
 
-// FormTextAreaFill fills the form text areas with placeholder values.
+// FormTextAreaFill fills the form text areas with values from the form fill rules.
 // It takes a slice of FormTextArea structs as input and returns an OrderedMap
-// containing the form field names as keys and the placeholder values as values.
+// containing the form field names as keys and the fill values as values.
 func FormTextAreaFill(inputs []FormTextArea) mapsutil.OrderedMap[string, string] {
 	data := mapsutil.NewOrderedMap[string, string]()
 	for _, input := range inputs {
-		data.Set(input.Name, FormData.Placeholder)
+		data.Set(input.Name, FormRules.Value(formFillField("textarea", input.Name, input.Label, input.Attributes)))
 	}
 	return data
 }
@@ -192,6 +161,78 @@ func FormFillSuggestions(formFields []interface{}) mapsutil.OrderedMap[string, s
 	return merged
 }
 
+// FormVariant is a form fill suggestion for one combination of select options
+// and radio choices
+type FormVariant struct {
+	Label string
+	Data  mapsutil.OrderedMap[string, string]
+}
+
+// FormFillVariants returns the form fill suggestions for up to limit
+// combinations of select options and radio choices, as chosen by
+// formfill.Variants. It returns nil when the form has no alternative choices.
+func FormFillVariants(formFields []interface{}, limit int) []FormVariant {
+	variants := formfill.Variants(FormChoices(formFields), limit)
+	if len(variants) == 0 {
+		return nil
+	}
+	base := FormFillSuggestions(formFields)
+	result := make([]FormVariant, 0, len(variants))
+	for _, variant := range variants {
+		data := mapsutil.NewOrderedMap[string, string]()
+		base.Iterate(func(key, value string) bool {
+			if selected, ok := variant.Values[key]; ok {
+				value = selected
+			}
+			data.Set(key, value)
+			return true
+		})
+		result = append(result, FormVariant{Label: variant.Label, Data: data})
+	}
+	return result
+}
+
+// FormChoices returns the selects and radio groups of a form with their
+// enabled values in document order
+func FormChoices(formFields []interface{}) []formfill.Choice {
+	var choices []formfill.Choice
+	radioIndex := map[string]int{}
+	for _, item := range formFields {
+		switch v := item.(type) {
+		case FormSelect:
+			if v.Name == "" {
+				continue
+			}
+			choice := formfill.Choice{Name: v.Name}
+			for _, option := range v.SelectOptions {
+				if option.Attributes.Has("disabled") {
+					continue
+				}
+				if option.Selected != "" {
+					choice.Default = len(choice.Values)
+				}
+				choice.Values = append(choice.Values, option.Value)
+			}
+			choices = append(choices, choice)
+		case FormInput:
+			if !strings.EqualFold(v.Type, "radio") || v.Name == "" || v.Attributes.Has("disabled") {
+				continue
+			}
+			index, ok := radioIndex[v.Name]
+			if !ok {
+				index = len(choices)
+				radioIndex[v.Name] = index
+				choices = append(choices, formfill.Choice{Name: v.Name})
+			}
+			if v.Attributes.Has("checked") {
+				choices[index].Default = len(choices[index].Values)
+			}
+			choices[index].Values = append(choices[index].Values, v.Value)
+		}
+	}
+	return choices
+}
+
 // ConvertGoquerySelectionToFormInput converts goquery selection to form input
 func ConvertGoquerySelectionToFormInput(item *goquery.Selection) FormInput {
 	attrs := item.Nodes[0].Attr
@@ -209,9 +250,30 @@ func ConvertGoquerySelectionToFormInput(item *goquery.Selection) FormInput {
 			input.Attributes.Set(attribute.Key, attribute.Val)
 		}
 	}
+	input.Label = formFieldLabel(item)
 	return input
 }
 
+// formFieldLabel returns the text of the label of a form field, either
+// referencing it by id or wrapping it
+func formFieldLabel(item *goquery.Selection) string {
+	if id, ok := item.Attr("id"); ok && id != "" {
+		root := item.Parents().Last()
+		var label string
+		root.Find("label[for]").EachWithBreak(func(_ int, candidate *goquery.Selection) bool {
+			if value, _ := candidate.Attr("for"); value == id {
+				label = candidate.Text()
+				return false
+			}
+			return true
+		})
+		if label != "" {
+			return strings.TrimSpace(label)
+		}
+	}
+	return strings.TrimSpace(item.Closest("label").Text())
+}
+
 // ConvertGoquerySelectionToSelectOption converts a goquery.Selection object to a SelectOption object.
 // It extracts the attributes from the goquery.Selection object and populates a SelectOption object with the extracted values.
 func ConvertGoquerySelectionToSelectOption(item *goquery.Selection) SelectOption {
@@ -267,6 +329,7 @@ func ConvertGoquerySelectionToFormTextArea(item *goquery.Selection) FormTextArea
 			input.Attributes.Set(attribute.Key, attribute.Val)
 		}
 	}
+	input.Label = formFieldLabel(item)
 	return input
 }
 
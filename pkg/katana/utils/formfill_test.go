@@ -81,7 +81,64 @@ func TestFormInputFillSuggestions(t *testing.T) {
 			queryValuesWriter.Set(key, value)
 			return true
 		})
+		// dates are generated within the range of the date rule
+		require.Regexp(t, `^20[0-2][0-9]-[0-9]{2}-[0-9]{2}$`, queryValuesWriter.Get("Startdate"), "could not get generated date")
+		queryValuesWriter.Del("Startdate")
 		value := queryValuesWriter.Encode()
-		require.Equal(t, "Startdate=katana&color=green&country=india&firstname=katana&food=pasta&message=katana&num=51&password=katana&sport1=cricket&sport2=tennis&sport3=football&telephone=katanaP%40assw0rd1&upclick=%23a52a2a", value, "could not get correct encoded form")
+		require.Equal(t, "color=green&country=india&firstname=John&food=pasta&message=admin&num=51&password=Crawl3r%402024&sport1=cricket&sport2=tennis&sport3=football&telephone=2124567890&upclick=%23a52a2a", value, "could not get correct encoded form")
 	})
 }
+
+func TestFormInputFillSuggestionsLabels(t *testing.T) {
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(`<form>
+		<label for="f1">Your e-mail</label><input type="text" name="f1" id="f1">
+		<label>Phone <input type="text" name="f2"></label>
+		<input type="text" name="f3" autocomplete="postal-code">
+		<input type="text" name="f4" placeholder="Search products">
+	</form>`))
+	require.NoError(t, err, "could not read document")
+
+	var formFields []interface{}
+	document.Find("input").Each(func(_ int, item *goquery.Selection) {
+		formFields = append(formFields, ConvertGoquerySelectionToFormField(item))
+	})
+	dataMap := FormFillSuggestions(formFields)
+	email, _ := dataMap.Get("f1")
+	require.Contains(t, email, "@example.com", "could not match label by id")
+	phone, _ := dataMap.Get("f2")
+	require.Equal(t, "2124567890", phone, "could not match wrapping label")
+	zip, _ := dataMap.Get("f3")
+	require.Equal(t, "10001", zip, "could not match autocomplete")
+	search, _ := dataMap.Get("f4")
+	require.Equal(t, "test", search, "could not match placeholder")
+}
+
+func TestFormFillVariants(t *testing.T) {
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(htmlFormInputExample))
+	require.NoError(t, err)
+	formFields := []interface{}{}
+	document.Find("form input, form select, form textarea").Each(func(_ int, item *goquery.Selection) {
+		formFields = append(formFields, ConvertGoquerySelectionToFormField(item))
+	})
+
+	choices := FormChoices(formFields)
+	require.Len(t, choices, 3)
+	require.Equal(t, "color", choices[0].Name)
+	require.Equal(t, []string{"red", "blue", "green"}, choices[0].Values)
+	require.Equal(t, "food", choices[1].Name)
+	require.Equal(t, 2, choices[1].Default, "the selected option is the default")
+	require.Equal(t, []string{"india", "usa", "uk", "canada"}, choices[2].Values)
+
+	variants := FormFillVariants(formFields, 5)
+	require.Len(t, variants, 5)
+	require.Equal(t, "color=red&food=pasta&country=india", variants[0].Label)
+	require.Equal(t, "color=blue&food=pasta&country=india", variants[1].Label)
+	require.Equal(t, "color=red&food=pizza&country=india", variants[2].Label)
+	require.Equal(t, "color=red&food=pasta&country=usa", variants[3].Label)
+	food, _ := variants[2].Data.Get("food")
+	require.Equal(t, "pizza", food)
+	name, _ := variants[2].Data.Get("firstname")
+	require.NotEmpty(t, name, "other fields keep their suggestions")
+
+	require.Nil(t, FormFillVariants(formFields, 0))
+}
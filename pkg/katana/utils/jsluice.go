@@ -19,9 +19,16 @@ func IsPathCommonJSLibraryFile(path string) bool {
 	return commonJSLibraryFileRegexCompiled.MatchString(path)
 }
 
+// JSLuiceEndpoint is an endpoint found by jsluice along with the request
+// shape inferred from the call site (fetch, $.ajax, axios, XMLHttpRequest).
 type JSLuiceEndpoint struct {
-	Endpoint string
-	Type     string
+	Endpoint    string
+	Type        string
+	Method      string
+	QueryParams []string
+	BodyParams  []string
+	ContentType string
+	Headers     map[string]string
 }
 
 // ExtractJsluiceEndpoints extracts jsluice endpoints from a given string.
@@ -44,8 +51,13 @@ func ExtractJsluiceEndpoints(data string) []JSLuiceEndpoint {
 	for _, url := range foundURLs {
 		url := url
 		endpoints = append(endpoints, JSLuiceEndpoint{
-			Endpoint: url.URL,
-			Type:     url.Type,
+			Endpoint:    url.URL,
+			Type:        url.Type,
+			Method:      url.Method,
+			QueryParams: url.QueryParams,
+			BodyParams:  url.BodyParams,
+			ContentType: url.ContentType,
+			Headers:     url.Headers,
 		})
 	}
 	return endpoints
@@ -36,3 +36,30 @@ func TestIsPathCommonJSLibraryFile(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractJsluiceEndpoints(t *testing.T) {
+	data := `fetch("/api/users?page=1", {method: "POST", headers: {"Content-Type": "application/json"}});
+$.post("/api/login", {user: u, pass: p});`
+	var fetchEndpoint, postEndpoint *JSLuiceEndpoint
+	for _, endpoint := range ExtractJsluiceEndpoints(data) {
+		endpoint := endpoint
+		if endpoint.Type == "fetch" && endpoint.Method != "" {
+			fetchEndpoint = &endpoint
+		}
+		if endpoint.Type == "$.post" && endpoint.Method != "" {
+			postEndpoint = &endpoint
+		}
+	}
+	if fetchEndpoint == nil || postEndpoint == nil {
+		t.Fatalf("ExtractJsluiceEndpoints() did not return fetch and $.post endpoints")
+	}
+	if fetchEndpoint.Endpoint != "/api/users?page=1" || fetchEndpoint.Method != "POST" || fetchEndpoint.ContentType != "application/json" {
+		t.Errorf("ExtractJsluiceEndpoints() fetch = %+v", fetchEndpoint)
+	}
+	if len(fetchEndpoint.QueryParams) != 1 || fetchEndpoint.QueryParams[0] != "page" {
+		t.Errorf("ExtractJsluiceEndpoints() query params = %v, want [page]", fetchEndpoint.QueryParams)
+	}
+	if postEndpoint.Endpoint != "/api/login" || len(postEndpoint.BodyParams) != 2 {
+		t.Errorf("ExtractJsluiceEndpoints() $.post = %+v", postEndpoint)
+	}
+}
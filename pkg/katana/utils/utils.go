@@ -3,6 +3,8 @@ package utils
 import (
 	"strings"
 
+	"katanacrawlgo/pkg/identity"
+
 	"github.com/lukasbob/srcset"
 	"github.com/projectdiscovery/gologger"
 	urlutil "github.com/projectdiscovery/utils/url"
@@ -65,7 +67,7 @@ func ParseRefreshTag(value string) string {
 
 // WebUserAgent returns the chrome-web user agent
 func WebUserAgent() string {
-        return "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/113.0.0.0 Safari/537.36"
+        return identity.DefaultUserAgent
 }
 
 func FlattenHeaders(headers map[string][]string) map[string]string {
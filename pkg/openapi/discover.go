@@ -0,0 +1,90 @@
+// Package openapi discovers OpenAPI (Swagger) documents and expands them
+// into concrete requests: path parameters are filled with example or typed
+// values and request bodies are generated from their schemas.
+package openapi
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// SpecPaths are the locations commonly used to publish a specification,
+// probed relative to the root of a target
+var SpecPaths = []string{
+	"/swagger.json",
+	"/swagger.yaml",
+	"/openapi.json",
+	"/openapi.yaml",
+	"/v2/api-docs",
+	"/v3/api-docs",
+	"/api-docs",
+	"/swagger/v1/swagger.json",
+	"/swagger/doc.json",
+	"/api/swagger.json",
+	"/api/openapi.json",
+	"/api/v1/swagger.json",
+	"/api/v1/openapi.json",
+	"/docs/openapi.json",
+	"/.well-known/openapi.json",
+}
+
+var specPathRegex = regexp.MustCompile(`(?i)(?:(?:swagger|openapi|api-docs)(?:\.json|\.ya?ml)?|/v[23]/api-docs(?:/[^/]*)?|/swagger/doc\.json)$`)
+
+// uiConfigRegexes extract the specification URL from Swagger UI and ReDoc
+// configurations
+var uiConfigRegexes = []*regexp.Regexp{
+	// SwaggerUIBundle({url: "..."}), SwaggerUIBundle({urls: [{url: "...", name: "v1"}]})
+	regexp.MustCompile(`\burl\s*:\s*["'\x60]([^"'\x60\s]+\.(?:json|ya?ml)(?:\?[^"'\x60\s]*)?|[^"'\x60\s]*/(?:v[23]/)?api-docs[^"'\x60\s]*)["'\x60]`),
+	// ReDoc: <redoc spec-url="...">, Redoc.init("...")
+	regexp.MustCompile(`(?:spec-url\s*=\s*|Redoc\.init\(\s*)["']([^"'\s]+)["']`),
+}
+
+// uiMarkers identify Swagger UI and ReDoc pages and initializer scripts
+var uiMarkers = []string{"SwaggerUIBundle", "swagger-ui", "SwaggerUIStandalonePreset", "<redoc", "Redoc.init"}
+
+// IsSpecPath reports whether a URL path looks like a specification document
+func IsSpecPath(path string) bool {
+	return specPathRegex.MatchString(path)
+}
+
+// DetectSpecURLs returns the specifications referenced by a Swagger UI or
+// ReDoc page or initializer script. The results may be relative URLs.
+func DetectSpecURLs(content string) []string {
+	isUI := false
+	for _, marker := range uiMarkers {
+		if strings.Contains(content, marker) {
+			isUI = true
+			break
+		}
+	}
+	if !isUI {
+		return nil
+	}
+	seen := map[string]bool{}
+	var specs []string
+	for _, configRegex := range uiConfigRegexes {
+		for _, match := range configRegex.FindAllStringSubmatch(content, -1) {
+			if spec := match[1]; !seen[spec] {
+				seen[spec] = true
+				specs = append(specs, spec)
+			}
+		}
+	}
+	return specs
+}
+
+// ResolveAll resolves relative specification URLs against a page URL
+func ResolveAll(base string, refs []string) []string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil
+	}
+	var resolved []string
+	for _, ref := range refs {
+		if parsed, err := baseURL.Parse(ref); err == nil && parsed.Host != "" {
+			resolved = append(resolved, parsed.String())
+		}
+	}
+	return resolved
+}
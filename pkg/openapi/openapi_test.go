@@ -0,0 +1,211 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const swagger2Spec = `{
+  "swagger": "2.0",
+  "host": "api.example.com",
+  "basePath": "/v1/",
+  "schemes": ["https"],
+  "paths": {
+    "/users/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "type": "integer"}],
+      "get": {
+        "operationId": "getUser",
+        "parameters": [{"name": "fields", "in": "query", "type": "string", "enum": ["name", "email"]}]
+      },
+      "put": {
+        "operationId": "updateUser",
+        "parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/User"}}]
+      }
+    },
+    "/login": {
+      "post": {
+        "consumes": ["application/x-www-form-urlencoded"],
+        "parameters": [
+          {"name": "username", "in": "formData", "type": "string", "x-example": "admin"},
+          {"name": "password", "in": "formData", "type": "string", "format": "password"}
+        ]
+      }
+    }
+  },
+  "definitions": {
+    "User": {
+      "type": "object",
+      "properties": {
+        "id": {"type": "integer", "readOnly": true},
+        "name": {"type": "string", "example": "alice"},
+        "email": {"type": "string", "format": "email"},
+        "friends": {"type": "array", "items": {"$ref": "#/definitions/User"}}
+      }
+    }
+  }
+}`
+
+const openAPI3Spec = `
+openapi: 3.0.1
+servers:
+  - url: https://{region}.example.com/api
+    variables:
+      region:
+        default: eu
+paths:
+  /orders/{orderId}:
+    get:
+      parameters:
+        - name: orderId
+          in: path
+          required: true
+          schema:
+            type: string
+            format: uuid
+        - $ref: '#/components/parameters/Page'
+  /orders:
+    post:
+      requestBody:
+        $ref: '#/components/requestBodies/Order'
+  /upload:
+    post:
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                title:
+                  type: string
+                file:
+                  type: string
+                  format: binary
+components:
+  parameters:
+    Page:
+      name: page
+      in: query
+      schema:
+        type: integer
+        minimum: 1
+  requestBodies:
+    Order:
+      content:
+        application/xml:
+          schema:
+            $ref: '#/components/schemas/Order'
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Order'
+  schemas:
+    Order:
+      allOf:
+        - $ref: '#/components/schemas/Base'
+        - type: object
+          properties:
+            quantity:
+              type: integer
+            created:
+              type: string
+              format: date-time
+    Base:
+      type: object
+      properties:
+        status:
+          type: string
+          enum: [placed, shipped]
+`
+
+func TestIsSpecPath(t *testing.T) {
+	for _, path := range append([]string{"/static/swagger.yaml", "/v3/api-docs/public"}, SpecPaths...) {
+		assert.True(t, IsSpecPath(path), path)
+	}
+	for _, path := range []string{"/", "/swagger-ui.html", "/api/users", "/swagger-ui/index.html"} {
+		assert.False(t, IsSpecPath(path), path)
+	}
+}
+
+func TestDetectSpecURLs(t *testing.T) {
+	page := `<script>
+window.ui = SwaggerUIBundle({
+  urls: [{url: "/api/v1/spec.json", name: "v1"}, {url: '/v3/api-docs/internal', name: "internal"}],
+  dom_id: '#swagger-ui',
+});
+</script>
+<redoc spec-url="https://docs.example.com/openapi.yaml"></redoc>`
+	specs := DetectSpecURLs(page)
+	assert.Equal(t, []string{"/api/v1/spec.json", "/v3/api-docs/internal", "https://docs.example.com/openapi.yaml"}, specs)
+	assert.Equal(t, []string{"https://a.com/api/v1/spec.json", "https://a.com/v3/api-docs/internal", "https://docs.example.com/openapi.yaml"},
+		ResolveAll("https://a.com/docs/index.html", specs))
+
+	assert.Empty(t, DetectSpecURLs(`fetch({url: "/data.json"})`), "config outside of Swagger UI pages is ignored")
+}
+
+func TestParse(t *testing.T) {
+	doc, err := Parse([]byte(swagger2Spec))
+	require.NoError(t, err)
+	assert.Equal(t, VersionSwagger2, doc.Version)
+	assert.Equal(t, "https://api.example.com/v1", doc.BaseURL("http://docs.example.com/swagger.json"))
+
+	doc, err = Parse([]byte(openAPI3Spec))
+	require.NoError(t, err)
+	assert.Equal(t, VersionOpenAPI3, doc.Version)
+	assert.Equal(t, "https://eu.example.com/api", doc.BaseURL("https://example.com/openapi.yaml"))
+
+	_, err = Parse([]byte(`{"name": "package", "paths": {}}`))
+	assert.ErrorIs(t, err, ErrNotSpec)
+	assert.False(t, LooksLikeSpec([]byte(`<html><body>swagger</body></html>`)))
+}
+
+func TestRequestsSwagger2(t *testing.T) {
+	doc, err := Parse([]byte(swagger2Spec))
+	require.NoError(t, err)
+	requests := doc.Requests("https://api.example.com/swagger.json")
+	require.Len(t, requests, 3)
+
+	login := requests[0]
+	assert.Equal(t, "POST", login.Method)
+	assert.Equal(t, "https://api.example.com/v1/login", login.URL)
+	assert.Equal(t, "application/x-www-form-urlencoded", login.ContentType)
+	assert.Equal(t, "password=Test%40123456&username=admin", login.Body)
+
+	get := requests[1]
+	assert.Equal(t, "GET", get.Method)
+	assert.Equal(t, "https://api.example.com/v1/users/1?fields=name", get.URL)
+	assert.Equal(t, "getUser", get.OperationID)
+
+	put := requests[2]
+	assert.Equal(t, "PUT", put.Method)
+	assert.Equal(t, "application/json", put.Headers["Content-Type"])
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(put.Body), &body))
+	assert.Equal(t, "alice", body["name"])
+	assert.Equal(t, "test@example.com", body["email"])
+	assert.NotContains(t, body, "id", "read only properties are not sent")
+	assert.Empty(t, body["friends"], "recursive schemas stop at the reference cycle")
+}
+
+func TestRequestsOpenAPI3(t *testing.T) {
+	doc, err := Parse([]byte(openAPI3Spec))
+	require.NoError(t, err)
+	requests := doc.Requests("https://example.com/openapi.yaml")
+	require.Len(t, requests, 3)
+
+	create := requests[0]
+	assert.Equal(t, "https://eu.example.com/api/orders", create.URL)
+	assert.Equal(t, "application/json", create.ContentType)
+	assert.JSONEq(t, `{"status":"placed","quantity":1,"created":"2024-01-01T00:00:00Z"}`, create.Body)
+
+	get := requests[1]
+	assert.Equal(t, "GET", get.Method)
+	assert.Equal(t, "https://eu.example.com/api/orders/3fa85f64-5717-4562-b3fc-2c963f66afa6?page=1", get.URL)
+
+	upload := requests[2]
+	assert.True(t, strings.HasPrefix(upload.ContentType, "multipart/form-data; boundary="))
+	assert.Contains(t, upload.Body, `name="title"`)
+	assert.Contains(t, upload.Body, `name="file"; filename="test.txt"`)
+}
@@ -0,0 +1,437 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxRequests is the number of requests generated per specification
+	MaxRequests = 500
+	// maxSchemaDepth limits the nesting of generated bodies
+	maxSchemaDepth = 6
+	// maxProperties limits the properties generated per object
+	maxProperties = 30
+)
+
+var methods = []string{"get", "head", "options", "post", "put", "patch", "delete"}
+
+// Request is a concrete request generated for an operation
+type Request struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	OperationID string            `json:"operation_id,omitempty"`
+}
+
+// Requests generates one request per operation of the specification,
+// relative to the base URL derived from specURL
+func (doc *Document) Requests(specURL string) []*Request {
+	base := doc.BaseURL(specURL)
+	if base == "" {
+		return nil
+	}
+	paths, _ := doc.root["paths"].(map[string]interface{})
+	keys := make([]string, 0, len(paths))
+	for path := range paths {
+		keys = append(keys, path)
+	}
+	sort.Strings(keys)
+
+	var requests []*Request
+	for _, path := range keys {
+		pathItem, _ := doc.resolve(paths[path], nil)
+		if pathItem == nil {
+			continue
+		}
+		for _, method := range methods {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if len(requests) >= MaxRequests {
+				return requests
+			}
+			requests = append(requests, doc.request(base, path, strings.ToUpper(method), pathItem, operation))
+		}
+	}
+	return requests
+}
+
+func (doc *Document) request(base, path, method string, pathItem, operation map[string]interface{}) *Request {
+	req := &Request{
+		Method:      method,
+		Headers:     map[string]string{},
+		OperationID: stringValue(operation["operationId"]),
+	}
+	query := url.Values{}
+	form := map[string]interface{}{}
+	var formFiles []string
+	for _, param := range doc.parameters(pathItem, operation) {
+		name := stringValue(param["name"])
+		switch stringValue(param["in"]) {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(formatValue(doc.paramValue(param))))
+		case "query":
+			query.Set(name, formatValue(doc.paramValue(param)))
+		case "header":
+			req.Headers[name] = formatValue(doc.paramValue(param))
+		case "body":
+			// Swagger 2 request body
+			data, _ := json.Marshal(doc.sample(param["schema"], nil, 0))
+			req.Body = string(data)
+			req.ContentType = "application/json"
+		case "formData":
+			if stringValue(param["type"]) == "file" {
+				formFiles = append(formFiles, name)
+				continue
+			}
+			form[name] = doc.paramValue(param)
+		}
+	}
+	if len(form) > 0 || len(formFiles) > 0 {
+		if len(formFiles) > 0 || contains(doc.consumes(operation), "multipart/form-data") {
+			req.Body, req.ContentType = multipartBody(form, formFiles)
+		} else {
+			req.Body, req.ContentType = formBody(form), "application/x-www-form-urlencoded"
+		}
+	}
+	if requestBody, _ := doc.resolve(operation["requestBody"], nil); requestBody != nil {
+		req.Body, req.ContentType = doc.requestBody(requestBody)
+	}
+	if req.ContentType != "" {
+		req.Headers["Content-Type"] = req.ContentType
+	}
+
+	req.URL = base + path
+	if len(query) > 0 {
+		req.URL += "?" + query.Encode()
+	}
+	return req
+}
+
+// parameters merges path level and operation level parameters, the latter
+// override parameters with the same name and location
+func (doc *Document) parameters(pathItem, operation map[string]interface{}) []map[string]interface{} {
+	var merged []map[string]interface{}
+	index := map[string]int{}
+	for _, source := range []interface{}{pathItem["parameters"], operation["parameters"]} {
+		list, _ := source.([]interface{})
+		for _, item := range list {
+			param, _ := doc.resolve(item, nil)
+			if param == nil || stringValue(param["name"]) == "" {
+				continue
+			}
+			key := stringValue(param["in"]) + ":" + stringValue(param["name"])
+			if i, ok := index[key]; ok {
+				merged[i] = param
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, param)
+		}
+	}
+	return merged
+}
+
+func (doc *Document) consumes(operation map[string]interface{}) []interface{} {
+	if consumes, ok := operation["consumes"].([]interface{}); ok {
+		return consumes
+	}
+	consumes, _ := doc.root["consumes"].([]interface{})
+	return consumes
+}
+
+// paramValue returns the example of a parameter, or a value of its type
+func (doc *Document) paramValue(param map[string]interface{}) interface{} {
+	// x-example is the Swagger 2 vendor extension for parameter examples
+	for _, keyword := range []string{"example", "x-example"} {
+		if example, ok := param[keyword]; ok {
+			return example
+		}
+	}
+	if examples, ok := param["examples"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(examples) {
+			if example, _ := doc.resolve(examples[name], nil); example != nil {
+				if value, ok := example["value"]; ok {
+					return value
+				}
+			}
+		}
+	}
+	if schema, ok := param["schema"]; ok {
+		return doc.sample(schema, nil, 0)
+	}
+	if content, ok := param["content"].(map[string]interface{}); ok {
+		for _, mediaType := range sortedKeys(content) {
+			media, _ := content[mediaType].(map[string]interface{})
+			return doc.sample(media["schema"], nil, 0)
+		}
+	}
+	// Swagger 2 parameters carry the schema keywords themselves
+	return doc.sample(param, nil, 0)
+}
+
+// requestBody generates an OpenAPI 3 request body, preferring JSON then
+// form encodings
+func (doc *Document) requestBody(requestBody map[string]interface{}) (string, string) {
+	content, _ := requestBody["content"].(map[string]interface{})
+	if len(content) == 0 {
+		return "", ""
+	}
+	mediaTypes := sortedKeys(content)
+	selected := mediaTypes[0]
+	for _, preferred := range []func(string) bool{
+		func(mediaType string) bool { return strings.Contains(mediaType, "json") },
+		func(mediaType string) bool { return mediaType == "application/x-www-form-urlencoded" },
+		func(mediaType string) bool { return mediaType == "multipart/form-data" },
+	} {
+		if index := indexOf(mediaTypes, preferred); index >= 0 {
+			selected = mediaTypes[index]
+			break
+		}
+	}
+	media, _ := content[selected].(map[string]interface{})
+	value, ok := media["example"]
+	if !ok {
+		value = doc.sample(media["schema"], nil, 0)
+	}
+
+	switch {
+	case strings.Contains(selected, "json") || selected == "*/*":
+		data, _ := json.Marshal(value)
+		contentType := selected
+		if selected == "*/*" {
+			contentType = "application/json"
+		}
+		return string(data), contentType
+	case selected == "application/x-www-form-urlencoded":
+		object, _ := value.(map[string]interface{})
+		return formBody(object), selected
+	case selected == "multipart/form-data":
+		object, _ := value.(map[string]interface{})
+		fields := map[string]interface{}{}
+		var files []string
+		schema, _ := doc.resolve(media["schema"], nil)
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, field := range object {
+			property, _ := doc.resolve(properties[name], nil)
+			if stringValue(property["format"]) == "binary" || stringValue(property["format"]) == "base64" {
+				files = append(files, name)
+			} else {
+				fields[name] = field
+			}
+		}
+		return multipartBody(fields, files)
+	}
+	return formatValue(value), selected
+}
+
+// sample returns the example of a schema or a value matching its type
+func (doc *Document) sample(value interface{}, seen map[string]bool, depth int) interface{} {
+	if depth > maxSchemaDepth {
+		return nil
+	}
+	schema, seen := doc.resolve(value, seen)
+	if schema == nil {
+		return nil
+	}
+	for _, keyword := range []string{"example", "default", "const"} {
+		if example, ok := schema[keyword]; ok {
+			return example
+		}
+	}
+	if examples, ok := schema["examples"].([]interface{}); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		merged := map[string]interface{}{}
+		for _, item := range allOf {
+			if object, ok := doc.sample(item, seen, depth+1).(map[string]interface{}); ok {
+				for key, value := range object {
+					merged[key] = value
+				}
+			}
+		}
+		return merged
+	}
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if options, ok := schema[keyword].([]interface{}); ok && len(options) > 0 {
+			return doc.sample(options[0], seen, depth+1)
+		}
+	}
+
+	switch schemaType(schema) {
+	case "integer":
+		if minimum, ok := schema["minimum"].(float64); ok && minimum > 1 {
+			return int(minimum)
+		}
+		return 1
+	case "number":
+		return 1.5
+	case "boolean":
+		return true
+	case "array":
+		item := doc.sample(schema["items"], seen, depth+1)
+		if item == nil {
+			return []interface{}{}
+		}
+		return []interface{}{item}
+	case "object":
+		object := map[string]interface{}{}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range sortedKeys(properties) {
+			if len(object) >= maxProperties {
+				break
+			}
+			if property, _ := doc.resolve(properties[name], seen); property != nil && property["readOnly"] == true {
+				continue
+			}
+			if value := doc.sample(properties[name], seen, depth+1); value != nil {
+				object[name] = value
+			}
+		}
+		return object
+	case "file":
+		return "test"
+	}
+	return stringSample(schema)
+}
+
+func schemaType(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		// OpenAPI 3.1 type arrays such as ["string", "null"]
+		for _, item := range t {
+			if name := stringValue(item); name != "null" {
+				return name
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["items"]; ok {
+		return "array"
+	}
+	return "string"
+}
+
+func stringSample(schema map[string]interface{}) string {
+	var value string
+	switch stringValue(schema["format"]) {
+	case "date":
+		value = "2024-01-01"
+	case "date-time":
+		value = "2024-01-01T00:00:00Z"
+	case "time":
+		value = "00:00:00"
+	case "email":
+		value = "test@example.com"
+	case "uuid":
+		value = "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "uri", "url":
+		value = "https://example.com"
+	case "hostname":
+		value = "example.com"
+	case "ipv4":
+		value = "127.0.0.1"
+	case "ipv6":
+		value = "::1"
+	case "byte":
+		value = "dGVzdA=="
+	case "password":
+		value = "Test@123456"
+	default:
+		value = "test"
+	}
+	if minLength, ok := schema["minLength"].(float64); ok && len(value) < int(minLength) {
+		value += strings.Repeat("t", int(minLength)-len(value))
+	}
+	if maxLength, ok := schema["maxLength"].(float64); ok && maxLength > 0 && len(value) > int(maxLength) {
+		value = value[:int(maxLength)]
+	}
+	return value
+}
+
+// formatValue renders a sample value for a path, query or header parameter
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, formatValue(item))
+		}
+		return strings.Join(parts, ",")
+	case map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+func formBody(fields map[string]interface{}) string {
+	values := url.Values{}
+	for name, value := range fields {
+		values.Set(name, formatValue(value))
+	}
+	return values.Encode()
+}
+
+func multipartBody(fields map[string]interface{}, files []string) (string, string) {
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	for _, name := range sortedKeys(fields) {
+		_ = writer.WriteField(name, formatValue(fields[name]))
+	}
+	sort.Strings(files)
+	for _, name := range files {
+		part, err := writer.CreateFormFile(name, "test.txt")
+		if err == nil {
+			_, _ = part.Write([]byte("test"))
+		}
+	}
+	_ = writer.Close()
+	return buffer.String(), writer.FormDataContentType()
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func indexOf(values []string, match func(string) bool) int {
+	for i, value := range values {
+		if match(value) {
+			return i
+		}
+	}
+	return -1
+}
+
+func contains(values []interface{}, target string) bool {
+	return containsValue(values, target)
+}
@@ -0,0 +1,194 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Specification versions
+const (
+	VersionSwagger2 = 2
+	VersionOpenAPI3 = 3
+)
+
+// ErrNotSpec is returned for documents that are not OpenAPI specifications
+var ErrNotSpec = errors.New("document is not an OpenAPI specification")
+
+// Document is a parsed OpenAPI 2 or 3 specification kept as generic JSON
+// values, $ref pointers are resolved on access
+type Document struct {
+	Version int
+	root    map[string]interface{}
+}
+
+// LooksLikeSpec is a cheap check for a specification before parsing it
+func LooksLikeSpec(data []byte) bool {
+	head := data
+	if len(head) > 2048 {
+		head = head[:2048]
+	}
+	text := string(head)
+	return (strings.Contains(text, "swagger") || strings.Contains(text, "openapi")) && strings.Contains(string(data), "paths")
+}
+
+// Parse parses a JSON or YAML specification
+func Parse(data []byte) (*Document, error) {
+	var value interface{}
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal([]byte(trimmed), &value); err != nil {
+			return nil, err
+		}
+	} else if err := yaml.Unmarshal([]byte(trimmed), &value); err != nil {
+		return nil, err
+	}
+	root, ok := normalize(value).(map[string]interface{})
+	if !ok {
+		return nil, ErrNotSpec
+	}
+	doc := &Document{root: root}
+	// unquoted YAML versions are decoded as numbers
+	switch swagger := fmt.Sprint(root["swagger"]); {
+	case root["openapi"] != nil && strings.HasPrefix(fmt.Sprint(root["openapi"]), "3"):
+		doc.Version = VersionOpenAPI3
+	case swagger == "2.0" || swagger == "2":
+		doc.Version = VersionSwagger2
+	default:
+		return nil, ErrNotSpec
+	}
+	if _, ok := root["paths"].(map[string]interface{}); !ok {
+		return nil, ErrNotSpec
+	}
+	return doc, nil
+}
+
+// BaseURL returns the URL API paths are relative to. Servers (OpenAPI 3) or
+// host, basePath and schemes (Swagger 2) are resolved against the URL the
+// specification was fetched from.
+func (doc *Document) BaseURL(specURL string) string {
+	spec, err := url.Parse(specURL)
+	if err != nil {
+		return ""
+	}
+	origin := &url.URL{Scheme: spec.Scheme, Host: spec.Host}
+	if doc.Version == VersionSwagger2 {
+		base := *origin
+		if host := stringValue(doc.root["host"]); host != "" {
+			base.Host = host
+		}
+		if schemes, _ := doc.root["schemes"].([]interface{}); len(schemes) > 0 && !containsValue(schemes, spec.Scheme) {
+			base.Scheme = stringValue(schemes[0])
+		}
+		base.Path = strings.TrimSuffix(stringValue(doc.root["basePath"]), "/")
+		return base.String()
+	}
+
+	servers, _ := doc.root["servers"].([]interface{})
+	for _, item := range servers {
+		server, _ := item.(map[string]interface{})
+		serverURL := stringValue(server["url"])
+		if serverURL == "" {
+			continue
+		}
+		// variables use their default, e.g. https://{region}.example.com
+		variables, _ := server["variables"].(map[string]interface{})
+		for name, item := range variables {
+			variable, _ := item.(map[string]interface{})
+			if def, ok := variable["default"]; ok && def != nil {
+				serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", fmt.Sprint(def))
+			}
+		}
+		resolved, err := spec.Parse(serverURL)
+		if err != nil || strings.Contains(resolved.Host, "{") {
+			continue
+		}
+		resolved.RawQuery = ""
+		resolved.Fragment = ""
+		return strings.TrimSuffix(resolved.String(), "/")
+	}
+	return origin.String()
+}
+
+// resolve follows a $ref pointer within the document, other documents are
+// not fetched. Seen pointers guard against reference cycles.
+func (doc *Document) resolve(value interface{}, seen map[string]bool) (map[string]interface{}, map[string]bool) {
+	object, _ := value.(map[string]interface{})
+	for object != nil {
+		ref, ok := object["$ref"].(string)
+		if !ok {
+			break
+		}
+		if seen[ref] || !strings.HasPrefix(ref, "#/") {
+			return nil, seen
+		}
+		next := make(map[string]bool, len(seen)+1)
+		for key := range seen {
+			next[key] = true
+		}
+		next[ref] = true
+		seen = next
+		object = doc.pointer(ref)
+	}
+	return object, seen
+}
+
+// pointer returns the object of a local JSON pointer such as #/components/schemas/User
+func (doc *Document) pointer(ref string) map[string]interface{} {
+	var current interface{} = doc.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		if unescaped, err := url.PathUnescape(part); err == nil {
+			part = unescaped
+		}
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = object[part]
+	}
+	object, _ := current.(map[string]interface{})
+	return object
+}
+
+// normalize converts the map[interface{}]interface{} values YAML produces
+// for non string keys, such as response codes, into map[string]interface{}
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[fmt.Sprint(key)] = normalize(item)
+		}
+		return object
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalize(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	}
+	return value
+}
+
+func stringValue(value interface{}) string {
+	text, _ := value.(string)
+	return text
+}
+
+func containsValue(values []interface{}, target string) bool {
+	for _, value := range values {
+		if stringValue(value) == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,288 @@
+// Package paraminv aggregates the parameter names an application accepts,
+// as observed by both crawl engines, into a per-host inventory suitable for
+// fuzzing: where each parameter is sent, example values and the endpoints
+// that accept it.
+package paraminv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Parameter locations
+const (
+	LocationQuery  = "query"
+	LocationBody   = "body"
+	LocationJSON   = "json"
+	LocationHeader = "header"
+	LocationCookie = "cookie"
+)
+
+const (
+	// MaxExamples is the number of distinct example values kept per parameter
+	MaxExamples = 5
+	// MaxEndpoints is the number of distinct endpoints kept per parameter
+	MaxEndpoints = 20
+	// MaxValueLength truncates long example values
+	MaxValueLength = 100
+)
+
+// standardHeaders are sent by every browser and carry no application parameter
+var standardHeaders = map[string]struct{}{
+	"accept": {}, "accept-encoding": {}, "accept-language": {}, "cache-control": {}, "connection": {},
+	"content-length": {}, "content-type": {}, "cookie": {}, "host": {}, "origin": {}, "pragma": {},
+	"referer": {}, "range": {}, "upgrade-insecure-requests": {}, "user-agent": {}, "te": {},
+	"if-modified-since": {}, "if-none-match": {}, "dnt": {}, "priority": {}, "purpose": {},
+}
+
+// Param is a single parameter name seen on a host
+type Param struct {
+	Name      string   `json:"name"`
+	Locations []string `json:"locations"`
+	Examples  []string `json:"examples,omitempty"`
+	Endpoints []string `json:"endpoints"`
+	Sources   []string `json:"sources"`
+	Count     int      `json:"count"`
+}
+
+// HostInventory is the JSON document written for a host
+type HostInventory struct {
+	Host   string   `json:"host"`
+	Params []*Param `json:"params"`
+}
+
+// Inventory collects parameters per host, it is safe for concurrent use
+type Inventory struct {
+	mutex sync.Mutex
+	hosts map[string]map[string]*Param
+}
+
+// New creates an empty inventory
+func New() *Inventory {
+	return &Inventory{hosts: make(map[string]map[string]*Param)}
+}
+
+// Add records a parameter seen at an endpoint. Endpoint is a URL; its
+// query and fragment are dropped.
+func (inv *Inventory) Add(source, method, endpoint, location, name, value string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return
+	}
+	host := strings.ToLower(parsed.Host)
+	if method == "" {
+		method = "GET"
+	}
+	endpointKey := strings.ToUpper(method) + " " + parsed.Scheme + "://" + parsed.Host + parsed.EscapedPath()
+
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	params, ok := inv.hosts[host]
+	if !ok {
+		params = make(map[string]*Param)
+		inv.hosts[host] = params
+	}
+	param, ok := params[name]
+	if !ok {
+		param = &Param{Name: name}
+		params[name] = param
+	}
+	param.Count++
+	param.Locations = appendUnique(param.Locations, location, 0)
+	param.Endpoints = appendUnique(param.Endpoints, endpointKey, MaxEndpoints)
+	if source != "" {
+		param.Sources = appendUnique(param.Sources, source, 0)
+	}
+	if value != "" {
+		if len(value) > MaxValueLength {
+			value = value[:MaxValueLength]
+		}
+		param.Examples = appendUnique(param.Examples, value, MaxExamples)
+	}
+}
+
+// AddRequest records the query, body, header and cookie parameters of a request
+func (inv *Inventory) AddRequest(source, method, rawURL string, headers map[string]string, body string) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+	for name, values := range parsed.Query() {
+		inv.Add(source, method, rawURL, LocationQuery, name, first(values))
+	}
+
+	contentType := ""
+	for key, value := range headers {
+		lowerKey := strings.ToLower(key)
+		switch {
+		case lowerKey == "content-type":
+			contentType = value
+		case lowerKey == "cookie":
+			for _, cookie := range strings.Split(value, ";") {
+				name, cookieValue, _ := strings.Cut(strings.TrimSpace(cookie), "=")
+				inv.Add(source, method, rawURL, LocationCookie, name, cookieValue)
+			}
+		case strings.HasPrefix(lowerKey, ":") || strings.HasPrefix(lowerKey, "sec-"):
+		default:
+			if _, ok := standardHeaders[lowerKey]; !ok {
+				inv.Add(source, method, rawURL, LocationHeader, key, value)
+			}
+		}
+	}
+	inv.addBody(source, method, rawURL, contentType, body)
+}
+
+// AddForm records the fields of an HTML form
+func (inv *Inventory) AddForm(source, method, action string, names []string) {
+	location := LocationBody
+	if method == "" || strings.EqualFold(method, "GET") {
+		location = LocationQuery
+	}
+	for _, name := range names {
+		inv.Add(source, method, action, location, name, "")
+	}
+}
+
+func (inv *Inventory) addBody(source, method, rawURL, contentType, body string) {
+	if body == "" {
+		return
+	}
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	trimmed := strings.TrimSpace(body)
+	switch {
+	case strings.Contains(mediaType, "json") || (mediaType == "" && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "["))):
+		var data interface{}
+		if err := json.Unmarshal([]byte(trimmed), &data); err == nil {
+			inv.addJSON(source, method, rawURL, data)
+		}
+		return
+	case mediaType == "multipart/form-data":
+		reader := multipart.NewReader(bytes.NewReader([]byte(body)), params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			value := part.FileName()
+			if value == "" {
+				data, _ := io.ReadAll(io.LimitReader(part, MaxValueLength))
+				value = string(data)
+			}
+			inv.Add(source, method, rawURL, LocationBody, part.FormName(), value)
+		}
+		return
+	}
+	values, err := url.ParseQuery(trimmed)
+	if err != nil {
+		return
+	}
+	for name, value := range values {
+		inv.Add(source, method, rawURL, LocationBody, name, first(value))
+	}
+}
+
+// addJSON records every object key of a JSON document, nested keys included
+func (inv *Inventory) addJSON(source, method, rawURL string, data interface{}) {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			example := ""
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+			case nil:
+			default:
+				example = fmt.Sprint(item)
+			}
+			inv.Add(source, method, rawURL, LocationJSON, key, example)
+			inv.addJSON(source, method, rawURL, item)
+		}
+	case []interface{}:
+		for _, item := range value {
+			inv.addJSON(source, method, rawURL, item)
+		}
+	}
+}
+
+// Hosts returns the sorted hosts of the inventory
+func (inv *Inventory) Hosts() []string {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	hosts := make([]string, 0, len(inv.hosts))
+	for host := range inv.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// Host returns a snapshot of the parameters of a host sorted by name
+func (inv *Inventory) Host(host string) *HostInventory {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	result := &HostInventory{Host: host, Params: []*Param{}}
+	for _, param := range inv.hosts[host] {
+		clone := *param
+		clone.Locations = append([]string(nil), param.Locations...)
+		clone.Examples = append([]string(nil), param.Examples...)
+		clone.Endpoints = append([]string(nil), param.Endpoints...)
+		clone.Sources = append([]string(nil), param.Sources...)
+		sort.Strings(clone.Locations)
+		sort.Strings(clone.Endpoints)
+		sort.Strings(clone.Sources)
+		result.Params = append(result.Params, &clone)
+	}
+	sort.Slice(result.Params, func(i, j int) bool {
+		return result.Params[i].Name < result.Params[j].Name
+	})
+	return result
+}
+
+// Wordlist returns the sorted unique parameter names of all hosts
+func (inv *Inventory) Wordlist() []string {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	seen := make(map[string]struct{})
+	var words []string
+	for _, params := range inv.hosts {
+		for name := range params {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			words = append(words, name)
+		}
+	}
+	sort.Strings(words)
+	return words
+}
+
+func appendUnique(items []string, item string, limit int) []string {
+	for _, existing := range items {
+		if existing == item {
+			return items
+		}
+	}
+	if limit > 0 && len(items) >= limit {
+		return items
+	}
+	return append(items, item)
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
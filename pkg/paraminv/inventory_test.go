@@ -0,0 +1,89 @@
+package paraminv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func paramByName(t *testing.T, inv *Inventory, host, name string) *Param {
+	for _, param := range inv.Host(host).Params {
+		if param.Name == name {
+			return param
+		}
+	}
+	t.Fatalf("param %s not found on %s", name, host)
+	return nil
+}
+
+func TestAddRequest(t *testing.T) {
+	inv := New()
+	inv.AddRequest("crawlergo", "GET", "https://example.com/search?q=shoes&page=2", map[string]string{
+		"User-Agent":     "Mozilla/5.0",
+		"Cookie":         "session=abc; theme=dark",
+		"X-Api-Key":      "secret",
+		"Sec-Fetch-Mode": "cors",
+	}, "")
+	inv.AddRequest("katana", "POST", "https://example.com/api/users", map[string]string{
+		"Content-Type": "application/json",
+	}, `{"user":{"name":"bob","roles":["admin"]},"items":[{"sku":"A1"}]}`)
+	inv.AddRequest("katana", "POST", "https://example.com/login", nil, "username=admin&password=123456")
+	inv.AddRequest("katana", "GET", "https://example.com/search?q=hats", nil, "")
+
+	q := paramByName(t, inv, "example.com", "q")
+	assert.Equal(t, []string{LocationQuery}, q.Locations)
+	assert.Equal(t, []string{"shoes", "hats"}, q.Examples)
+	assert.Equal(t, []string{"GET https://example.com/search"}, q.Endpoints)
+	assert.Equal(t, []string{"crawlergo", "katana"}, q.Sources)
+	assert.Equal(t, 2, q.Count)
+
+	assert.Equal(t, []string{LocationCookie}, paramByName(t, inv, "example.com", "session").Locations)
+	assert.Equal(t, []string{LocationHeader}, paramByName(t, inv, "example.com", "X-Api-Key").Locations)
+	assert.Equal(t, []string{"bob"}, paramByName(t, inv, "example.com", "name").Examples)
+	assert.Equal(t, []string{LocationJSON}, paramByName(t, inv, "example.com", "user").Locations)
+	assert.Empty(t, paramByName(t, inv, "example.com", "user").Examples)
+	assert.Equal(t, []string{LocationJSON}, paramByName(t, inv, "example.com", "sku").Locations)
+	assert.Equal(t, []string{"POST https://example.com/login"}, paramByName(t, inv, "example.com", "password").Endpoints)
+
+	assert.Equal(t, []string{"X-Api-Key", "items", "name", "page", "password", "q", "roles", "session", "sku", "theme", "user", "username"},
+		inv.Wordlist())
+}
+
+func TestAddBodyFormats(t *testing.T) {
+	inv := New()
+	body := "--XYZ\r\nContent-Disposition: form-data; name=\"title\"\r\n\r\nhello\r\n" +
+		"--XYZ\r\nContent-Disposition: form-data; name=\"avatar\"; filename=\"a.png\"\r\nContent-Type: image/png\r\n\r\nPNG\r\n--XYZ--\r\n"
+	inv.AddRequest("crawlergo", "POST", "http://example.com:8080/upload", map[string]string{"content-type": "multipart/form-data; boundary=XYZ"}, body)
+	inv.AddRequest("crawlergo", "POST", "http://example.com:8080/broken", map[string]string{"Content-Type": "application/json"}, `{"a":`)
+	inv.AddForm("katana", "", "http://example.com:8080/find", []string{"term", ""})
+	inv.AddForm("katana", "post", "http://example.com:8080/comment", []string{"body"})
+	inv.Add("katana", "GET", "not a url", LocationQuery, "x", "")
+
+	host := "example.com:8080"
+	assert.Equal(t, []string{host}, inv.Hosts())
+	assert.Equal(t, []string{"hello"}, paramByName(t, inv, host, "title").Examples)
+	assert.Equal(t, []string{"a.png"}, paramByName(t, inv, host, "avatar").Examples)
+	assert.Equal(t, []string{LocationQuery}, paramByName(t, inv, host, "term").Locations)
+	assert.Equal(t, []string{"POST http://example.com:8080/comment"}, paramByName(t, inv, host, "body").Endpoints)
+	assert.Len(t, inv.Host(host).Params, 4)
+}
+
+func TestWriteFiles(t *testing.T) {
+	inv := New()
+	inv.AddRequest("katana", "GET", "http://127.0.0.1:8000/?id=1", nil, "")
+	inv.AddRequest("katana", "GET", "https://example.org/?q=1&id=2", nil, "")
+
+	dir := filepath.Join(t.TempDir(), "params")
+	require.NoError(t, inv.WriteFiles(dir))
+
+	wordlist, err := os.ReadFile(filepath.Join(dir, WordlistFile))
+	require.NoError(t, err)
+	assert.Equal(t, "id\nq\n", string(wordlist))
+	assert.FileExists(t, filepath.Join(dir, "127.0.0.1_8000.json"))
+	data, err := os.ReadFile(filepath.Join(dir, "example.org.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"host": "example.org"`)
+}
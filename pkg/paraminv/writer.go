@@ -0,0 +1,41 @@
+package paraminv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WordlistFile is the name of the wordlist written next to the host files
+const WordlistFile = "params.txt"
+
+// WriteFiles writes one JSON file per host and a wordlist of all parameter
+// names into dir, creating it when needed.
+func (inv *Inventory) WriteFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, host := range inv.Hosts() {
+		data, err := json.MarshalIndent(inv.Host(host), "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, HostFileName(host)), data, 0644); err != nil {
+			return err
+		}
+	}
+	words := inv.Wordlist()
+	content := strings.Join(words, "\n")
+	if len(words) > 0 {
+		content += "\n"
+	}
+	return os.WriteFile(filepath.Join(dir, WordlistFile), []byte(content), 0644)
+}
+
+// HostFileName returns the JSON file name of a host, ports are kept with
+// an underscore so the name is valid on every platform
+func HostFileName(host string) string {
+	replacer := strings.NewReplacer(":", "_", "/", "_", "\\", "_", "[", "", "]", "")
+	return replacer.Replace(host) + ".json"
+}
@@ -0,0 +1,133 @@
+package upload
+
+import (
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Endpoint is a URL receiving multipart form submissions
+type Endpoint struct {
+	Method     string   `json:"method"`
+	URL        string   `json:"url"`
+	FileFields []string `json:"file_fields,omitempty"` // fields carrying a file, when visible in the body
+}
+
+// IsMultipart reports whether a content type is a multipart form
+func IsMultipart(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.HasPrefix(strings.ToLower(strings.TrimSpace(contentType)), "multipart/form-data")
+	}
+	return mediaType == "multipart/form-data"
+}
+
+// file parts are matched on their header rather than parsed, browsers leave
+// the file content out of the body they report for intercepted requests
+var fileFieldRegex = regexp.MustCompile(`(?i)content-disposition:[^\r\n]*?\bname="([^"]*)"[^\r\n]*?\bfilename=`)
+
+// FileFields returns the names of the fields of a multipart body that carry a file
+func FileFields(body string) []string {
+	var fields []string
+	seen := map[string]bool{}
+	for _, match := range fileFieldRegex.FindAllStringSubmatch(body, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			fields = append(fields, match[1])
+		}
+	}
+	return fields
+}
+
+// Detect returns the upload endpoint of a request, nil unless it submits a
+// multipart form
+func Detect(method, rawURL, contentType, body string) *Endpoint {
+	if !IsMultipart(contentType) {
+		return nil
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return nil
+	}
+	parsed.Fragment = ""
+	return &Endpoint{
+		Method:     strings.ToUpper(method),
+		URL:        parsed.String(),
+		FileFields: FileFields(body),
+	}
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// WriteFile adds a sample as the file part of a multipart form
+func WriteFile(writer *multipart.Writer, field string, sample *Sample) error {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(field), sample.Name))
+	header.Set("Content-Type", sample.MIME)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(sample.Data())
+	return err
+}
+
+// EndpointSet collects upload endpoints from concurrent sources, merging
+// the file fields seen for the same method and URL
+type EndpointSet struct {
+	mutex     sync.Mutex
+	endpoints map[string]*Endpoint
+}
+
+// NewEndpointSet returns an empty set
+func NewEndpointSet() *EndpointSet {
+	return &EndpointSet{endpoints: make(map[string]*Endpoint)}
+}
+
+// Add records an endpoint, reporting whether it is new
+func (set *EndpointSet) Add(endpoint *Endpoint) bool {
+	if endpoint == nil {
+		return false
+	}
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+	key := endpoint.Method + " " + endpoint.URL
+	existing, ok := set.endpoints[key]
+	if !ok {
+		set.endpoints[key] = &Endpoint{
+			Method:     endpoint.Method,
+			URL:        endpoint.URL,
+			FileFields: append([]string(nil), endpoint.FileFields...),
+		}
+		return true
+	}
+	for _, field := range endpoint.FileFields {
+		if !contains(existing.FileFields, field) {
+			existing.FileFields = append(existing.FileFields, field)
+		}
+	}
+	return false
+}
+
+// List returns the endpoints sorted by URL and method
+func (set *EndpointSet) List() []Endpoint {
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+	endpoints := make([]Endpoint, 0, len(set.endpoints))
+	for _, endpoint := range set.endpoints {
+		endpoints = append(endpoints, *endpoint)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].URL != endpoints[j].URL {
+			return endpoints[i].URL < endpoints[j].URL
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+	return endpoints
+}
@@ -0,0 +1,250 @@
+// Package upload provides small valid sample files for file inputs and
+// recognises multipart upload endpoints. A sample is chosen from the accept
+// attribute of the input, its content is generated in memory and, for the
+// browser, written once to a temporary directory. The same samples are used
+// by crawlergo and katana.
+package upload
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Sample is a file submitted to file inputs
+type Sample struct {
+	Name       string   // file name, the extension matches the content
+	MIME       string   // content type sent with the file
+	Extensions []string // extensions the sample satisfies in an accept attribute
+	MIMETypes  []string // content types the sample satisfies in an accept attribute
+
+	generate func() []byte
+}
+
+// Data returns the content of the sample
+func (s *Sample) Data() []byte {
+	return s.generate()
+}
+
+// Samples are the available samples, the first one is used when the accept
+// attribute is empty or matches none of them
+var Samples = []*Sample{
+	{
+		Name:       "sample.png",
+		MIME:       "image/png",
+		Extensions: []string{".png"},
+		MIMETypes:  []string{"image/png"},
+		generate:   generatePNG,
+	},
+	{
+		Name:       "sample.jpg",
+		MIME:       "image/jpeg",
+		Extensions: []string{".jpg", ".jpeg", ".jpe", ".jfif"},
+		MIMETypes:  []string{"image/jpeg", "image/jpg", "image/pjpeg"},
+		generate:   generateJPEG,
+	},
+	{
+		Name:       "sample.pdf",
+		MIME:       "application/pdf",
+		Extensions: []string{".pdf"},
+		MIMETypes:  []string{"application/pdf", "application/x-pdf"},
+		generate:   generatePDF,
+	},
+	{
+		Name:       "sample.txt",
+		MIME:       "text/plain",
+		Extensions: []string{".txt", ".text", ".log", ".csv"},
+		MIMETypes:  []string{"text/plain", "text/csv"},
+		generate:   generateText,
+	},
+	{
+		Name:       "sample.zip",
+		MIME:       "application/zip",
+		Extensions: []string{".zip"},
+		MIMETypes:  []string{"application/zip", "application/x-zip-compressed", "application/x-zip"},
+		generate:   generateZip,
+	},
+	{
+		Name:       "sample.docx",
+		MIME:       "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		Extensions: []string{".docx", ".doc"},
+		MIMETypes:  []string{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/msword"},
+		generate:   generateDocx,
+	},
+}
+
+// Select returns the sample for an accept attribute such as
+// "image/*,.pdf". Tokens are tried in order, so the first acceptable type
+// the page lists is preferred.
+func Select(accept string) *Sample {
+	for _, token := range strings.Split(accept, ",") {
+		token = strings.ToLower(strings.TrimSpace(token))
+		// parameters such as "text/plain;charset=utf-8" are ignored
+		if index := strings.IndexByte(token, ';'); index >= 0 {
+			token = strings.TrimSpace(token[:index])
+		}
+		if token == "" {
+			continue
+		}
+		for _, sample := range Samples {
+			if sample.accepts(token) {
+				return sample
+			}
+		}
+	}
+	return Samples[0]
+}
+
+func (s *Sample) accepts(token string) bool {
+	switch {
+	case strings.HasPrefix(token, "."):
+		return contains(s.Extensions, token)
+	case token == "*/*" || token == "*":
+		return true
+	case strings.HasSuffix(token, "/*"):
+		return strings.HasPrefix(s.MIME, strings.TrimSuffix(token, "*"))
+	default:
+		return contains(s.MIMETypes, token)
+	}
+}
+
+func contains(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	dirLock sync.Mutex
+	dir     string
+	written = map[string]string{}
+)
+
+// Path returns the path of the sample for an accept attribute, writing it
+// to the temporary directory on first use
+func Path(accept string) (string, error) {
+	sample := Select(accept)
+
+	dirLock.Lock()
+	defer dirLock.Unlock()
+	if path, ok := written[sample.Name]; ok {
+		return path, nil
+	}
+	if dir == "" {
+		created, err := os.MkdirTemp("", "katanacrawlgo-upload-")
+		if err != nil {
+			return "", err
+		}
+		// the browser needs an absolute path
+		if dir, err = filepath.Abs(created); err != nil {
+			return "", err
+		}
+	}
+	path := filepath.Join(dir, sample.Name)
+	if err := os.WriteFile(path, sample.Data(), 0644); err != nil {
+		return "", err
+	}
+	written[sample.Name] = path
+	return path, nil
+}
+
+// Cleanup removes the temporary directory holding the written samples
+func Cleanup() error {
+	dirLock.Lock()
+	defer dirLock.Unlock()
+	if dir == "" {
+		return nil
+	}
+	err := os.RemoveAll(dir)
+	dir = ""
+	written = map[string]string{}
+	return err
+}
+
+func sampleImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, color.White)
+	return img
+}
+
+func generatePNG() []byte {
+	var buffer bytes.Buffer
+	_ = png.Encode(&buffer, sampleImage())
+	return buffer.Bytes()
+}
+
+func generateJPEG() []byte {
+	var buffer bytes.Buffer
+	_ = jpeg.Encode(&buffer, sampleImage(), nil)
+	return buffer.Bytes()
+}
+
+const sampleText = "sample upload\n"
+
+func generateText() []byte {
+	return []byte(sampleText)
+}
+
+// generatePDF returns a single empty page PDF with a correct cross-reference table
+func generatePDF() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 200] /Resources << >> >>",
+	}
+	var buffer bytes.Buffer
+	buffer.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buffer.Len()
+		fmt.Fprintf(&buffer, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buffer.Len()
+	fmt.Fprintf(&buffer, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buffer, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buffer.Bytes()
+}
+
+func generateZip() []byte {
+	return zipFiles([][2]string{{"sample.txt", sampleText}})
+}
+
+// generateDocx returns the minimal set of parts a word processing document needs
+func generateDocx() []byte {
+	return zipFiles([][2]string{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/></Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/></Relationships>`},
+		{"word/document.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p><w:r><w:t>sample upload</w:t></w:r></w:p></w:body></w:document>`},
+	})
+}
+
+func zipFiles(files [][2]string) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for _, file := range files {
+		entry, err := writer.Create(file[0])
+		if err != nil {
+			continue
+		}
+		_, _ = entry.Write([]byte(file[1]))
+	}
+	_ = writer.Close()
+	return buffer.Bytes()
+}
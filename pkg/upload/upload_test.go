@@ -0,0 +1,118 @@
+package upload
+
+import (
+	"archive/zip"
+	"bytes"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect(t *testing.T) {
+	for accept, name := range map[string]string{
+		"":                          "sample.png",
+		"image/*":                   "sample.png",
+		"image/jpeg":                "sample.jpg",
+		".JPEG":                     "sample.jpg",
+		".pdf,image/*":              "sample.pdf",
+		"text/plain;charset=utf-8":  "sample.txt",
+		"text/*":                    "sample.txt",
+		".zip":                      "sample.zip",
+		"application/msword, .docx": "sample.docx",
+		".exe":                      "sample.png",
+	} {
+		assert.Equal(t, name, Select(accept).Name, accept)
+	}
+}
+
+func TestSamplesAreValid(t *testing.T) {
+	_, err := png.Decode(bytes.NewReader(Select(".png").Data()))
+	assert.NoError(t, err)
+	_, err = jpeg.Decode(bytes.NewReader(Select(".jpg").Data()))
+	assert.NoError(t, err)
+	assert.Equal(t, sampleText, string(Select(".txt").Data()))
+
+	pdf := Select(".pdf").Data()
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	require.NotNil(t, startxref)
+	offset, _ := strconv.Atoi(string(startxref[1]))
+	assert.True(t, bytes.HasPrefix(pdf[offset:], []byte("xref\n")))
+	for _, entry := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(pdf, -1) {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		assert.Regexp(t, `^\d+ 0 obj`, string(pdf[offset:offset+8]))
+	}
+
+	archive := Select(".zip").Data()
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	assert.Equal(t, "sample.txt", reader.File[0].Name)
+
+	docx := Select(".docx").Data()
+	reader, err = zip.NewReader(bytes.NewReader(docx), int64(len(docx)))
+	require.NoError(t, err)
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	assert.ElementsMatch(t, []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml"}, names)
+}
+
+func TestPath(t *testing.T) {
+	defer Cleanup()
+
+	path, err := Path(".pdf")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(path, "sample.pdf"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, Select(".pdf").Data(), data)
+
+	again, err := Path("application/pdf")
+	require.NoError(t, err)
+	assert.Equal(t, path, again)
+
+	require.NoError(t, Cleanup())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDetect(t *testing.T) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("title", "test")
+	require.NoError(t, WriteFile(writer, "avatar", Select("image/*")))
+	_ = writer.Close()
+
+	endpoint := Detect("post", "https://example.com/profile/avatar#top", writer.FormDataContentType(), body.String())
+	require.NotNil(t, endpoint)
+	assert.Equal(t, "POST", endpoint.Method)
+	assert.Equal(t, "https://example.com/profile/avatar", endpoint.URL)
+	assert.Equal(t, []string{"avatar"}, endpoint.FileFields)
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	assert.Equal(t, "sample.png", form.File["avatar"][0].Filename)
+
+	assert.Nil(t, Detect("POST", "https://example.com/login", "application/x-www-form-urlencoded", "a=b"))
+	assert.Nil(t, Detect("POST", "/relative", "multipart/form-data; boundary=x", ""))
+
+	set := NewEndpointSet()
+	assert.True(t, set.Add(endpoint))
+	assert.False(t, set.Add(&Endpoint{Method: "POST", URL: endpoint.URL, FileFields: []string{"cover"}}))
+	assert.False(t, set.Add(nil))
+	assert.True(t, set.Add(Detect("PUT", "https://example.com/files", "multipart/form-data; boundary=x", "")))
+	list := set.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, "https://example.com/files", list[0].URL)
+	assert.Equal(t, []string{"avatar", "cover"}, list[1].FileFields)
+}